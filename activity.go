@@ -0,0 +1,45 @@
+package openrouter
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ActivityItem is the usage of a model endpoint on a day. Usage is in USD.
+type ActivityItem struct {
+	Date               string  `json:"date"`
+	Model              string  `json:"model"`
+	ModelPermaslug     string  `json:"model_permaslug"`
+	EndpointID         string  `json:"endpoint_id"`
+	ProviderName       string  `json:"provider_name"`
+	Usage              float64 `json:"usage"`
+	BYOKUsageInference float64 `json:"byok_usage_inference"`
+	Requests           int     `json:"requests"`
+	PromptTokens       int     `json:"prompt_tokens"`
+	CompletionTokens   int     `json:"completion_tokens"`
+	ReasoningTokens    int     `json:"reasoning_tokens"`
+}
+
+// ActivityList is the response of the activity API.
+type ActivityList struct {
+	Data []ActivityItem `json:"data"`
+}
+
+// GetActivity — API call to get the daily usage rows of the account, grouped by
+// model endpoint. A zero date returns the last 30 completed days.
+func (c *Client) GetActivity(ctx context.Context, date time.Time) (activity ActivityList, err error) {
+	path := "/activity"
+	if !date.IsZero() {
+		path += "?" + url.Values{"date": {date.Format(time.DateOnly)}}.Encode()
+	}
+
+	req, err := c.requestBuilder.Build(ctx, http.MethodGet, c.fullURL(path), nil)
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &activity)
+	return
+}
@@ -0,0 +1,35 @@
+package openrouter
+
+import "context"
+
+// AuthProvider supplies the API key of each request, e.g. from a secret
+// manager, so credentials can rotate without rebuilding the client.
+type AuthProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// AuthProviderFunc adapts a function to the AuthProvider interface.
+type AuthProviderFunc func(ctx context.Context) (string, error)
+
+func (f AuthProviderFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// StaticToken is an AuthProvider always returning the same key.
+type StaticToken string
+
+func (t StaticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// apiKey returns the key to authenticate the next request with.
+func (c *Client) apiKey(ctx context.Context) (string, error) {
+	switch {
+	case c.config.AuthProvider != nil:
+		return c.config.AuthProvider.Token(ctx)
+	case c.config.KeyPool != nil:
+		return c.config.KeyPool.Next()
+	default:
+		return c.config.authToken, nil
+	}
+}
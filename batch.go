@@ -0,0 +1,115 @@
+package openrouter
+
+import (
+	"context"
+	"sync"
+)
+
+const defaultBatchWorkers = 4
+
+// BatchOptions configures CreateChatCompletionBatch.
+type BatchOptions struct {
+	// Workers is the number of concurrent requests, defaults to 4.
+	Workers int
+	// Retries is the number of times an item failing with a retryable error
+	// (rate limit, server or network error) is retried, on top of the retries of
+	// the client.
+	Retries int
+	// OnProgress is called after each item completes. It may be called concurrently.
+	OnProgress func(done, total int)
+}
+
+type BatchResult struct {
+	Response *ChatCompletionResponse
+	Err      error
+	Attempts int
+}
+
+// BatchResponse holds the results in the order of the requests and the
+// aggregated usage of the successful ones.
+type BatchResponse struct {
+	Results []BatchResult
+	Usage   Usage
+	Failed  int
+}
+
+// CreateChatCompletionBatch — creates the chat completions of requests with
+// bounded concurrency. Items failing after all their retries are reported in
+// their BatchResult, the batch itself never fails.
+func (c *Client) CreateChatCompletionBatch(
+	ctx context.Context,
+	requests []*ChatCompletionRequest,
+	opts BatchOptions,
+) *BatchResponse {
+	return runBatch(ctx, c, requests, opts, c.isRetryable)
+}
+
+func runBatch(
+	ctx context.Context,
+	client ChatClient,
+	requests []*ChatCompletionRequest,
+	opts BatchOptions,
+	retryable func(err error) bool,
+) *BatchResponse {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultBatchWorkers
+	}
+
+	batch := &BatchResponse{Results: make([]BatchResult, len(requests))}
+	indexes := make(chan int)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	for range min(workers, len(requests)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result := runBatchItem(ctx, client, requests[i], opts.Retries, retryable)
+				batch.Results[i] = result
+
+				mu.Lock()
+				done++
+				if result.Err != nil {
+					batch.Failed++
+				} else {
+					batch.Usage.Add(result.Response.Usage)
+				}
+				progress := done
+				mu.Unlock()
+
+				if opts.OnProgress != nil {
+					opts.OnProgress(progress, len(requests))
+				}
+			}
+		}()
+	}
+
+	for i := range requests {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return batch
+}
+
+func runBatchItem(
+	ctx context.Context,
+	client ChatClient,
+	request *ChatCompletionRequest,
+	retries int,
+	retryable func(err error) bool,
+) BatchResult {
+	var result BatchResult
+	for result.Attempts <= retries {
+		result.Attempts++
+		result.Response, result.Err = client.CreateChatCompletion(ctx, request)
+		if result.Err == nil || ctx.Err() != nil || !retryable(result.Err) {
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_CreateChatCompletionBatch(t *testing.T) {
+	var inflight, peak, flaky atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		current := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			old := peak.Load()
+			if current <= old || peak.CompareAndSwap(old, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		var request ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if request.Model == "failing" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"error":{"code":400,"message":"bad request"}}`)
+			return
+		}
+		if request.Model == "flaky" && flaky.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, `{"error":{"code":503,"message":"unavailable"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"model":"`+request.Model+`","choices":[{"message":{"content":"ok"}}],"usage":{"total_tokens":2}}`)
+	})
+
+	requests := make([]*ChatCompletionRequest, 10)
+	for i := range requests {
+		requests[i] = &ChatCompletionRequest{Model: Gpt4}
+	}
+	requests[3].Model = "failing"
+	requests[5].Model = "flaky"
+	client.config.Retry.MaxRetries = -1
+
+	var (
+		mu       sync.Mutex
+		progress []int
+	)
+	batch := client.CreateChatCompletionBatch(context.Background(), requests, BatchOptions{
+		Workers: 3,
+		Retries: 1,
+		OnProgress: func(done, total int) {
+			mu.Lock()
+			defer mu.Unlock()
+			progress = append(progress, done)
+		},
+	})
+
+	if got := peak.Load(); got > 3 {
+		t.Errorf("expected at most 3 concurrent requests, got %d", got)
+	}
+	if batch.Failed != 1 || batch.Usage.TotalTokens != 18 {
+		t.Errorf("unexpected batch totals: %d failed, usage %+v", batch.Failed, batch.Usage)
+	}
+	if failed := batch.Results[3]; failed.Err == nil || failed.Attempts != 1 {
+		t.Errorf("expected the non-retryable failure not to be retried, got %+v", failed)
+	}
+	if retried := batch.Results[5]; retried.Err != nil || retried.Attempts != 2 {
+		t.Errorf("expected the retryable failure to be retried once, got %+v", retried)
+	}
+	if result := batch.Results[0]; result.Err != nil || result.Attempts != 1 || result.Response.Model != Gpt4 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if len(progress) != 10 {
+		t.Errorf("expected a progress report per item, got %v", progress)
+	}
+}
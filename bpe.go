@@ -0,0 +1,169 @@
+package openrouter
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BPEEncoding names a byte pair encoding of the OpenAI models.
+type BPEEncoding string
+
+const (
+	EncodingCL100K BPEEncoding = "cl100k_base"
+	EncodingO200K  BPEEncoding = "o200k_base"
+)
+
+var (
+	ErrUnknownEncoding = errors.New("unknown BPE encoding")
+)
+
+// The pre-tokenization patterns of the encodings, less the `\s+(?!\S)`
+// alternative that Go regexps can't express, see bpePieces.
+var bpePatterns = map[BPEEncoding]*regexp.Regexp{
+	EncodingCL100K: regexp.MustCompile(`^(?:(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}|` +
+		` ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+)`),
+	EncodingO200K: regexp.MustCompile(`^(?:[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+` +
+		`(?i:'s|'t|'re|'ve|'m|'ll|'d)?|` +
+		`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|` +
+		`\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+)`),
+}
+
+// OpenAIEncoding returns the encoding of an OpenAI model, e.g. EncodingO200K for
+// "openai/gpt-4o", or "" for the models of other families.
+func OpenAIEncoding(model string) BPEEncoding {
+	model, _, _ = strings.Cut(model, ":")
+	model, found := strings.CutPrefix(model, "openai/")
+	if !found && strings.Contains(model, "/") {
+		return ""
+	}
+	for _, prefix := range []string{"gpt-4o", "chatgpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "gpt-oss", "o1", "o3", "o4"} {
+		if strings.HasPrefix(model, prefix) {
+			return EncodingO200K
+		}
+	}
+	for _, prefix := range []string{"gpt-4", "gpt-3.5", "text-embedding-3", "text-embedding-ada-002"} {
+		if strings.HasPrefix(model, prefix) {
+			return EncodingCL100K
+		}
+	}
+	return ""
+}
+
+// BPETokenCounter counts tokens with a byte pair encoding. The package doesn't
+// embed the ranks of the encodings, load them from the tiktoken files, e.g.
+// https://openaipublic.blob.core.windows.net/encodings/o200k_base.tiktoken.
+// Special tokens are counted as text.
+type BPETokenCounter struct {
+	ranks   map[string]int
+	pattern *regexp.Regexp
+}
+
+// NewBPETokenCounter reads the ranks of encoding in the tiktoken format: a
+// base64 token and its rank per line.
+func NewBPETokenCounter(encoding BPEEncoding, ranks io.Reader) (*BPETokenCounter, error) {
+	pattern, ok := bpePatterns[encoding]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEncoding, encoding)
+	}
+	counter := &BPETokenCounter{ranks: make(map[string]int), pattern: pattern}
+	scanner := bufio.NewScanner(ranks)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		token, rank, found := strings.Cut(line, " ")
+		if !found {
+			return nil, fmt.Errorf("invalid rank line %q", line)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token %q: %w", token, err)
+		}
+		value, err := strconv.Atoi(rank)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rank %q: %w", rank, err)
+		}
+		counter.ranks[string(decoded)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return counter, nil
+}
+
+func (c *BPETokenCounter) CountTokens(text string) int {
+	tokens := 0
+	for _, piece := range bpePieces(c.pattern, text) {
+		tokens += c.countPiece(piece)
+	}
+	return tokens
+}
+
+// countPiece merges the adjacent parts of piece of lowest rank until none is
+// in the ranks, and returns the number of parts left.
+func (c *BPETokenCounter) countPiece(piece string) int {
+	if _, ok := c.ranks[piece]; ok {
+		return 1
+	}
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, bestRank := -1, 0
+		for i := 0; i+2 < len(bounds); i++ {
+			rank, ok := c.ranks[piece[bounds[i]:bounds[i+2]]]
+			if ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+	return len(bounds) - 1
+}
+
+// bpePieces splits text as the pre-tokenization of tiktoken does. A run of
+// spaces followed by text leaves its last space to the next piece, which is the
+// `\s+(?!\S)` alternative of the tiktoken patterns.
+func bpePieces(pattern *regexp.Regexp, text string) []string {
+	var pieces []string
+	for text != "" {
+		end := len(text)
+		if match := pattern.FindStringIndex(text); match != nil && match[1] > 0 {
+			end = match[1]
+		} else {
+			_, end = utf8.DecodeRuneInString(text)
+		}
+		piece := text[:end]
+		if end < len(text) && utf8.RuneCountInString(piece) > 1 && isSpaceRun(piece) {
+			_, size := utf8.DecodeLastRuneInString(piece)
+			piece = piece[:len(piece)-size]
+		}
+		pieces = append(pieces, piece)
+		text = text[len(piece):]
+	}
+	return pieces
+}
+
+// isSpaceRun reports whether s only has whitespace, without line breaks, which
+// only the `\s+` alternative of the patterns matches.
+func isSpaceRun(s string) bool {
+	for _, r := range s {
+		if !unicode.IsSpace(r) || r == '\r' || r == '\n' {
+			return false
+		}
+	}
+	return true
+}
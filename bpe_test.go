@@ -0,0 +1,72 @@
+package openrouter
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func testRanks(tokens ...string) string {
+	var ranks strings.Builder
+	for rank, token := range tokens {
+		fmt.Fprintf(&ranks, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+	}
+	return ranks.String()
+}
+
+func TestBPETokenCounter(t *testing.T) {
+	counter, err := NewBPETokenCounter(EncodingCL100K, strings.NewReader(testRanks("a", "b", "c", " ", "ab", "abc", " a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "abc" is a token, " abc" merges to " " and "abc" as "ab" ranks before " a".
+	if got := counter.CountTokens("abc abc"); got != 3 {
+		t.Errorf("got %d tokens, want 3", got)
+	}
+	// "a", " " and " b", the last space of the run going to the next word.
+	if got := counter.CountTokens("a  b"); got != 4 {
+		t.Errorf("got %d tokens, want 4", got)
+	}
+
+	if _, err = NewBPETokenCounter("p50k_base", strings.NewReader("")); !errors.Is(err, ErrUnknownEncoding) {
+		t.Errorf("expected ErrUnknownEncoding, got %v", err)
+	}
+	if _, err = NewBPETokenCounter(EncodingO200K, strings.NewReader("YQ== first\n")); err == nil {
+		t.Error("expected an error for an invalid rank")
+	}
+}
+
+func TestBPEPieces(t *testing.T) {
+	tests := []struct {
+		encoding BPEEncoding
+		text     string
+		want     []string
+	}{
+		{EncodingCL100K, "Hello world's  12345!\n\n  x", []string{"Hello", " world", "'s", " ", " ", "123", "45", "!\n\n", " ", " x"}},
+		{EncodingO200K, "HelloWorld  ok ", []string{"Hello", "World", " ", " ok", " "}},
+	}
+	for _, test := range tests {
+		got := bpePieces(bpePatterns[test.encoding], test.text)
+		if strings.Join(got, "|") != strings.Join(test.want, "|") {
+			t.Errorf("%s: got %q, want %q", test.encoding, got, test.want)
+		}
+	}
+}
+
+func TestOpenAIEncoding(t *testing.T) {
+	tests := map[string]BPEEncoding{
+		"openai/gpt-4o":        EncodingO200K,
+		"openai/gpt-4o:online": EncodingO200K,
+		"openai/o3-mini":       EncodingO200K,
+		"openai/gpt-4-turbo":   EncodingCL100K,
+		"gpt-3.5-turbo":        EncodingCL100K,
+		"anthropic/claude-3.5": "",
+	}
+	for model, want := range tests {
+		if got := OpenAIEncoding(model); got != want {
+			t.Errorf("OpenAIEncoding(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package openrouter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const defaultCacheTTL = time.Hour
+
+// CacheStore stores chat completion responses by request hash.
+type CacheStore interface {
+	Get(ctx context.Context, key string) (*ChatCompletionResponse, bool)
+	Set(ctx context.Context, key string, response *ChatCompletionResponse, ttl time.Duration)
+}
+
+// ResponseCache configures the response cache of the client. By default only
+// deterministic requests (temperature 0 or seed set) are cached.
+type ResponseCache struct {
+	Store CacheStore
+	// TTL defaults to one hour.
+	TTL time.Duration
+	// CacheAll also caches non-deterministic requests.
+	CacheAll bool
+	// Semantic, if set, also serves requests similar to a cached one.
+	Semantic *SemanticCache
+}
+
+// cacheKey locates a request in the cache.
+type cacheKey struct {
+	hash     string
+	semantic *semanticKey
+}
+
+func (rc *ResponseCache) cacheable(request *ChatCompletionRequest) bool {
+	if rc.CacheAll {
+		return true
+	}
+	return (request.Temperature != nil && *request.Temperature == 0) || request.Seed != nil
+}
+
+func (rc *ResponseCache) ttl() time.Duration {
+	if rc.TTL > 0 {
+		return rc.TTL
+	}
+	return defaultCacheTTL
+}
+
+// Hash returns a stable fingerprint of the request, identifying the same
+// logical request across retries and processes: the hex SHA-256 of its JSON
+// encoding, the stream flag and the usage accounting excluded. The keys of the
+// response cache and of the request deduplication derive from it, scoped to
+// the credentials and spend key of the request, and it is logged with the
+// debug dumps. It is empty if the request can't be marshaled.
+func (r *ChatCompletionRequest) Hash() string {
+	hash, _ := requestHash(r)
+	return hash
+}
+
+// requestHash returns the SHA-256 of the request JSON encoding, stream flag and
+// usage accounting excluded. encoding/json emits struct fields in declaration order and sorts map
+// keys, so equal requests have equal hashes.
+func requestHash(request *ChatCompletionRequest) (string, error) {
+	normalized := *request
+	normalized.Stream = false
+	normalized.Usage = nil
+	data, err := json.Marshal(&normalized)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (c *Client) cachedResponse(ctx context.Context, request *ChatCompletionRequest) (cacheKey, *ChatCompletionResponse) {
+	rc := c.config.ResponseCache
+	if rc == nil || rc.Store == nil || !rc.cacheable(request) {
+		return cacheKey{}, nil
+	}
+	hash, err := requestHash(request)
+	if err != nil {
+		return cacheKey{}, nil
+	}
+	hash = c.tenantKey(ctx, hash)
+	key := cacheKey{hash: hash}
+	if response, ok := rc.Store.Get(ctx, hash); ok {
+		if hit := cacheHit(response); hit != nil {
+			return key, hit
+		}
+	}
+	if rc.Semantic != nil && rc.Semantic.Embedder != nil {
+		var response *ChatCompletionResponse
+		key.semantic, response = rc.Semantic.lookup(ctx, rc.Store, request, c.tenantKey(ctx, ""))
+		if response != nil {
+			return key, cacheHit(response)
+		}
+	}
+	return key, nil
+}
+
+// cacheHit returns a copy of a cached response, for the caller to modify it
+// without altering the cache, or nil if it can't be copied. The timings of the
+// request which filled the cache are dropped.
+func cacheHit(response *ChatCompletionResponse) *ChatCompletionResponse {
+	hit, err := cloneResponse(response)
+	if err != nil {
+		return nil
+	}
+	hit.Timings = nil
+	return hit
+}
+
+func (c *Client) cacheResponse(ctx context.Context, key cacheKey, response *ChatCompletionResponse) {
+	if key.hash == "" {
+		return
+	}
+	// the response is handed to the caller, the cache keeps its own copy
+	stored, err := cloneResponse(response)
+	if err != nil {
+		return
+	}
+	rc := c.config.ResponseCache
+	rc.Store.Set(ctx, key.hash, stored, rc.ttl())
+	if key.semantic != nil {
+		rc.Semantic.add(key.semantic, key.hash)
+	}
+}
+
+// MemoryCache is an in-memory CacheStore, expired entries are evicted lazily.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	response  *ChatCompletionResponse
+	expiresAt time.Time
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryCacheEntry)}
+}
+
+func (m *MemoryCache) Get(_ context.Context, key string) (*ChatCompletionResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry.response, true
+}
+
+func (m *MemoryCache) Set(_ context.Context, key string, response *ChatCompletionResponse, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = memoryCacheEntry{response: response, expiresAt: time.Now().Add(ttl)}
+}
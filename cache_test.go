@@ -0,0 +1,136 @@
+package openrouter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChatCompletionRequest_Hash(t *testing.T) {
+	request := &ChatCompletionRequest{Model: Gpt4, Messages: []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "hi"}}}
+	hash := request.Hash()
+	if len(hash) != 64 {
+		t.Fatalf("unexpected hash %q", hash)
+	}
+
+	streamed := *request
+	streamed.Stream, streamed.Usage = true, &UsageConfig{Include: true}
+	if streamed.Hash() != hash {
+		t.Error("expected the stream flag and the usage accounting to be ignored")
+	}
+	other := *request
+	other.Messages = []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "hello"}}
+	if other.Hash() == hash {
+		t.Error("expected different messages to change the hash")
+	}
+}
+
+func TestMemoryCache_TTL(t *testing.T) {
+	cache := NewMemoryCache()
+	response := &ChatCompletionResponse{ID: "1"}
+	cache.Set(context.Background(), "fresh", response, time.Hour)
+	cache.Set(context.Background(), "expired", response, -time.Second)
+
+	if got, ok := cache.Get(context.Background(), "fresh"); !ok || got != response {
+		t.Errorf("expected the fresh entry, got %v, %v", got, ok)
+	}
+	if _, ok := cache.Get(context.Background(), "expired"); ok {
+		t.Error("expected the expired entry to be evicted")
+	}
+	if _, ok := cache.entries["expired"]; ok {
+		t.Error("expected the expired entry to be deleted")
+	}
+}
+
+func TestClient_ResponseCache(t *testing.T) {
+	var requests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = io.WriteString(w, `{"id":"gen","choices":[{"message":{"content":"ok"}}]}`)
+	})
+	client.config.ResponseCache = &ResponseCache{Store: NewMemoryCache()}
+
+	zero, seed := float32(0), 1
+	send := func(request *ChatCompletionRequest) {
+		t.Helper()
+		request.Model = Gpt4
+		request.Messages = []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "hi"}}
+		if _, err := client.CreateChatCompletion(context.Background(), request); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	send(&ChatCompletionRequest{Temperature: &zero})
+	send(&ChatCompletionRequest{Temperature: &zero})
+	send(&ChatCompletionRequest{Seed: &seed})
+	send(&ChatCompletionRequest{Seed: &seed})
+	if requests != 2 {
+		t.Errorf("expected the deterministic requests to be cached, got %d requests", requests)
+	}
+
+	send(&ChatCompletionRequest{})
+	send(&ChatCompletionRequest{})
+	if requests != 4 {
+		t.Errorf("expected the non-deterministic requests not to be cached, got %d requests", requests)
+	}
+
+	client.config.ResponseCache.CacheAll = true
+	send(&ChatCompletionRequest{})
+	send(&ChatCompletionRequest{})
+	if requests != 5 {
+		t.Errorf("expected CacheAll to cache any request, got %d requests", requests)
+	}
+}
+
+func TestClient_ResponseCacheCopies(t *testing.T) {
+	var requests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = io.WriteString(w, `{"id":"gen","choices":[{"message":{"content":"ok"}}],"usage":{"total_tokens":3}}`)
+	})
+	client.config.ResponseCache = &ResponseCache{Store: NewMemoryCache(), CacheAll: true}
+
+	request := &ChatCompletionRequest{Model: Gpt4, Messages: []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "hi"}}}
+	for i := range 3 {
+		resp, err := client.CreateChatCompletion(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Choices[0].Message.Content != "ok" || resp.Usage.TotalTokens != 3 {
+			t.Fatalf("response %d was altered through a previous one: %+v", i, resp)
+		}
+		if i > 0 && resp.Timings != nil {
+			t.Errorf("expected the cache hit to have no timings, got %+v", resp.Timings)
+		}
+		resp.Choices[0].Message.Content = "mutated"
+		resp.Usage.TotalTokens = 0
+	}
+	if requests != 1 {
+		t.Errorf("expected a single request, got %d", requests)
+	}
+}
+
+func TestClient_ResponseCachePerTenant(t *testing.T) {
+	var keys []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Authorization"))
+		_, _ = io.WriteString(w, `{"id":"gen","choices":[{"message":{"content":"ok"}}]}`)
+	})
+	client.config.ResponseCache = &ResponseCache{Store: NewMemoryCache(), CacheAll: true}
+
+	request := &ChatCompletionRequest{Model: Gpt4, Messages: []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "hi"}}}
+	for _, tenant := range []*Client{client.WithKey("a"), client.WithKey("b"), client.WithKey("a")} {
+		if _, err := tenant.CreateChatCompletion(context.Background(), request); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.WithKey("a").CreateChatCompletion(WithSpendKey(context.Background(), "team"), request); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(keys, ",") != "Bearer a,Bearer b,Bearer a" {
+		t.Errorf("expected a cached response per tenant, got %v", keys)
+	}
+}
@@ -0,0 +1,85 @@
+package openrouter
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SupportsParameter reports whether the model lists the request parameter,
+// e.g. "tools", in its supported parameters.
+func (m Model) SupportsParameter(name string) bool {
+	return slices.Contains(m.SupportedParameters, name)
+}
+
+// SupportsTools reports whether the model accepts tools.
+func (m Model) SupportsTools() bool {
+	return m.SupportsParameter("tools")
+}
+
+// SupportsJSONSchema reports whether the model accepts JSON schema response
+// formats (structured outputs).
+func (m Model) SupportsJSONSchema() bool {
+	return m.SupportsParameter("structured_outputs")
+}
+
+// SupportsReasoning reports whether the model accepts reasoning settings.
+func (m Model) SupportsReasoning() bool {
+	return m.SupportsParameter("reasoning")
+}
+
+// SupportsVision reports whether the model accepts images as input.
+func (m Model) SupportsVision() bool {
+	return m.SupportsInputModality(ModalityImage)
+}
+
+// SupportsInputModality reports whether the model accepts the input modality,
+// e.g. ModalityAudio or "file".
+func (m Model) SupportsInputModality(modality Modality) bool {
+	return slices.Contains(m.Architecture.InputModalities, string(modality))
+}
+
+// SupportsOutputModality reports whether the model can generate the modality.
+func (m Model) SupportsOutputModality(modality Modality) bool {
+	return slices.Contains(m.Architecture.OutputModalities, string(modality))
+}
+
+// CapabilityWarnings lists the features used by the request that the model
+// doesn't support according to the catalog. Capabilities the catalog doesn't
+// report aren't checked.
+func CapabilityWarnings(request *ChatCompletionRequest, model Model) []string {
+	var warnings []string
+	if len(model.SupportedParameters) > 0 {
+		if len(request.Tools) > 0 && !model.SupportsTools() {
+			warnings = append(warnings, fmt.Sprintf("%s doesn't support tools", model.ID))
+		}
+		if request.ResponseFormat != nil && request.ResponseFormat.Type == ResponseFormatTypeJSONSchema &&
+			!model.SupportsJSONSchema() {
+			warnings = append(warnings, fmt.Sprintf("%s doesn't support JSON schema response formats", model.ID))
+		}
+	}
+	if len(model.Architecture.InputModalities) > 0 {
+		if messagesHavePart(request.Messages, ChatMessagePartTypeImageURL) && !model.SupportsVision() {
+			warnings = append(warnings, fmt.Sprintf("%s doesn't accept images", model.ID))
+		}
+		if messagesHavePart(request.Messages, ChatMessagePartTypeInputAudio) && !model.SupportsInputModality(ModalityAudio) {
+			warnings = append(warnings, fmt.Sprintf("%s doesn't accept audio", model.ID))
+		}
+	}
+	for _, modality := range request.Modalities {
+		if len(model.Architecture.OutputModalities) > 0 && !model.SupportsOutputModality(modality) {
+			warnings = append(warnings, fmt.Sprintf("%s can't generate %s", model.ID, modality))
+		}
+	}
+	return warnings
+}
+
+func messagesHavePart(messages []ChatCompletionMessage, partType ChatMessagePartType) bool {
+	for _, message := range messages {
+		for _, part := range message.MultiContent {
+			if part.Type == partType {
+				return true
+			}
+		}
+	}
+	return false
+}
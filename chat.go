@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"slices"
+	"time"
 )
 
 // Chat message role defined by the Sensa API.
@@ -14,11 +16,17 @@ const (
 	ChatMessageRoleUser      = "user"
 	ChatMessageRoleSystem    = "system"
 	ChatMessageRoleAssistant = "assistant"
+	ChatMessageRoleTool      = "tool"
+	// ChatMessageRoleDeveloper replaces the system role for the newer OpenAI
+	// models, see NormalizeSystemMessages.
+	ChatMessageRoleDeveloper = "developer"
 )
 
 var (
 	ErrChatCompletionStreamNotSupported = errors.New("streaming is not supported with this method, please use CreateChatCompletionStream") //nolint:lll
 	ErrCompletionUnsupportedModel       = errors.New("this model is not supported with this method")                                       //nolint:lll
+	ErrCompletionStreamNotSupported     = errors.New("streaming is not supported with this method, please use CreateCompletionStream")     //nolint:lll
+	ErrContentFieldsMisused             = errors.New("can't use both Content and MultiContent properties simultaneously")                  //nolint:lll
 )
 
 // CreateChatCompletion — API call to Create a completion for the chat message.
@@ -34,15 +42,123 @@ func (c *Client) CreateChatCompletion(
 	if !checkSupportsModel(request.Model) {
 		return nil, ErrCompletionUnsupportedModel
 	}
-
-	req, err := c.requestBuilder.Build(ctx, http.MethodPost, c.fullURL(urlSuffix), request)
+	request = c.prepareChatRequest(ctx, request)
+	c.filterChatRequest(ctx, request)
+	if err = c.validateChatRequest(ctx, request); err != nil {
+		return nil, err
+	}
+	request, restore, err := c.sanitizeChatRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey, cached := c.cachedResponse(ctx, request)
+	if cached != nil {
+		return restoreResponse(cached, restore), nil
+	}
+	request, releaseCircuit, err := c.routeCircuit(request)
 	if err != nil {
 		return nil, err
 	}
+	defer releaseCircuit()
+	c.adaptChatRequest(request)
+	if request, err = c.constrainEndpoints(ctx, request); err != nil {
+		return nil, err
+	}
+	ctx = c.withFingerprint(ctx, request)
+	if err = c.checkBudget(ctx, request); err != nil {
+		return nil, err
+	}
 
-	err = c.sendRequest(req, &response)
+	response, err = c.deduplicate(ctx, request, func() (resp *ChatCompletionResponse, err error) {
+		transformed := c.transformChatRequest(request)
+		req, err := c.requestBuilder.Build(ctx, http.MethodPost, c.fullURL(urlSuffix), transformed)
+		if err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		err = c.sendRequestWithRetry(req, &resp, c.excludeFailingProvider(ctx, transformed, urlSuffix))
+		if resp != nil {
+			resp.Timings = &Timings{Start: start, Duration: time.Since(start)}
+		}
+		c.recordCircuit(request.Model, err)
+		if err != nil {
+			return nil, err
+		}
+		c.enrichNativeUsage(ctx, resp)
+		c.recordSpend(ctx, resp.Usage)
+		if c.config.Continuation != nil && len(resp.Choices) > 0 {
+			if resp, err = c.continueTruncated(ctx, request, resp); err != nil {
+				return nil, err
+			}
+		}
+		c.cacheResponse(ctx, cacheKey, resp)
+		return resp, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return response, err
+	return restoreResponse(response, restore), nil
+}
+
+// excludeFailingProvider returns, when enabled by the retry config, the builder
+// of retries ignoring the providers that failed request.
+func (c *Client) excludeFailingProvider(
+	ctx context.Context,
+	request *ChatCompletionRequest,
+	urlSuffix string,
+) func(failed Attempt) (*http.Request, error) {
+	if !c.config.Retry.ExcludeFailingProvider {
+		return nil
+	}
+	retry := *request
+	return func(failed Attempt) (*http.Request, error) {
+		if failed.Provider != "" && (retry.Provider == nil || !slices.Contains(retry.Provider.Ignore, failed.Provider)) {
+			provider := ProviderPreferences{}
+			if retry.Provider != nil {
+				provider = *retry.Provider
+			}
+			provider.Ignore = append(slices.Clone(provider.Ignore), failed.Provider)
+			retry.Provider = &provider
+			c.logger().Printf("Provider %s failed, retrying without it", failed.Provider)
+		}
+		return c.requestBuilder.Build(ctx, http.MethodPost, c.fullURL(urlSuffix), &retry)
+	}
+}
+
+// prepareChatRequest returns a copy of request with the client-level request
+// settings applied. The rest of the pipeline only rewrites this copy, so that
+// the caller's request can be reused, e.g. across models.
+func (c *Client) prepareChatRequest(ctx context.Context, request *ChatCompletionRequest) *ChatCompletionRequest {
+	request = cloneChatRequest(request)
+	c.config.Defaults.apply(request)
+	request.Provider = c.config.Privacy.apply(request.Provider)
+	request.User = c.userID(ctx, request.User)
+	return request
+}
+
+// adaptChatRequest translates the token limit and, when NormalizeSystemPrompts
+// is set, the system prompt of the prepared request for the model it is sent
+// to, once the circuit breaker settled it.
+func (c *Client) adaptChatRequest(request *ChatCompletionRequest) {
+	translateTokenLimits(request)
+	if c.config.NormalizeSystemPrompts {
+		request.Messages = NormalizeSystemMessages(request.Model, request.Messages)
+	}
+}
+
+// cloneChatRequest copies request along with its messages and provider
+// preferences, which the pipeline rewrites.
+func cloneChatRequest(request *ChatCompletionRequest) *ChatCompletionRequest {
+	clone := *request
+	clone.Messages = slices.Clone(request.Messages)
+	for i := range clone.Messages {
+		clone.Messages[i].MultiContent = slices.Clone(clone.Messages[i].MultiContent)
+	}
+	if request.Provider != nil {
+		provider := *request.Provider
+		provider.Order, provider.Only = slices.Clone(provider.Order), slices.Clone(provider.Only)
+		provider.Ignore, provider.Quantizations = slices.Clone(provider.Ignore), slices.Clone(provider.Quantizations)
+		clone.Provider = &provider
+	}
+	return &clone
 }
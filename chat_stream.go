@@ -1,14 +1,13 @@
 package openrouter
 
 import (
-	"bufio"
 	"context"
-
-	utils "github.com/dedlockdave/go-openrouter/internal"
 )
 
 type ChatCompletionStream struct {
-	streamReader
+	*streamReader[ChatCompletionResponse]
+	// RouterArm is the arm chosen by the Router that opened the stream, if any.
+	RouterArm string
 }
 
 // CreateChatCompletionStream — API call to create a chat completion w/ streaming
@@ -24,28 +23,43 @@ func (c *Client) CreateChatCompletionStream(
 		err = ErrCompletionUnsupportedModel
 		return
 	}
+	request = c.prepareChatRequest(ctx, request)
+	c.filterChatRequest(ctx, request)
+	if err = c.validateChatRequest(ctx, request); err != nil {
+		return
+	}
+	if request, _, err = c.sanitizeChatRequest(ctx, request); err != nil {
+		return
+	}
+	request, releaseCircuit, err := c.routeCircuit(request)
+	if err != nil {
+		return
+	}
+	defer releaseCircuit()
+	c.adaptChatRequest(request)
+	if request, err = c.constrainEndpoints(ctx, request); err != nil {
+		return
+	}
+	ctx = c.withFingerprint(ctx, request)
+	if err = c.checkBudget(ctx, request); err != nil {
+		return
+	}
 	request.Stream = true
-	req, err := c.newStreamRequest(ctx, "POST", urlSuffix, request)
+	req, err := c.newStreamRequest(ctx, "POST", urlSuffix, c.transformChatRequest(request))
 	if err != nil {
 		return
 	}
 
-	resp, err := c.config.HTTPClient.Do(req) //nolint:bodyclose // body is closed in stream.Close()
+	resp, err := sendRequestStream[ChatCompletionResponse](c, req)
+	c.recordCircuit(request.Model, err)
 	if err != nil {
 		return
 	}
-	if isFailureStatusCode(resp) {
-		return nil, c.handleErrorResp(resp)
+	if c.config.SpendTracker != nil {
+		resp.onUsage = func(_ string, usage *Usage) { c.recordSpend(ctx, usage) }
 	}
-
 	stream = &ChatCompletionStream{
-		streamReader: streamReader{
-			emptyMessagesLimit: c.config.EmptyMessagesLimit,
-			reader:             bufio.NewReader(resp.Body),
-			response:           resp,
-			errAccumulator:     utils.NewErrorAccumulator(),
-			unmarshaler:        &utils.JSONUnmarshaler{},
-		},
+		streamReader: resp,
 	}
 	return
 }
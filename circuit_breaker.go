@@ -0,0 +1,188 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+)
+
+// CircuitOpenError is returned while the circuit of a model is open. It matches
+// ErrCircuitOpen with errors.Is.
+type CircuitOpenError struct {
+	Model   string
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s for model %s until %s", ErrCircuitOpen, e.Model, e.RetryAt.Format(time.RFC3339))
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitBreaker trips the circuit of a model after Threshold consecutive
+// failures. While open, requests for the model fail fast with a
+// *CircuitOpenError, or are redirected to the model of Fallbacks if its own
+// circuit is closed. After Cooldown a single trial request is let through: its
+// success closes the circuit, its failure opens it again.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+	// Fallbacks maps a model to the model used while its circuit is open.
+	Fallbacks map[string]string
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+		Fallbacks: make(map[string]string),
+		circuits:  make(map[string]*circuit),
+	}
+}
+
+// Allow reports whether a request for model may be sent.
+func (b *CircuitBreaker) Allow(model string) error {
+	_, err := b.allow(model)
+	return err
+}
+
+// allow is Allow, also returning the opening time of the circuit when the
+// request is its trial.
+func (b *CircuitBreaker) allow(model string) (trial time.Time, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	circ, ok := b.circuits[model]
+	if !ok || circ.openedAt.IsZero() {
+		return time.Time{}, nil
+	}
+	retryAt := circ.openedAt.Add(b.Cooldown)
+	if circ.trial || time.Now().Before(retryAt) {
+		return time.Time{}, &CircuitOpenError{Model: model, RetryAt: retryAt}
+	}
+	circ.trial = true
+	return circ.openedAt, nil
+}
+
+// releaseTrial lets another trial through the circuit of model, opened at
+// openedAt, when the trial request ended before its outcome was recorded.
+func (b *CircuitBreaker) releaseTrial(model string, openedAt time.Time) {
+	if openedAt.IsZero() {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if circ, ok := b.circuits[model]; ok && circ.openedAt.Equal(openedAt) {
+		circ.trial = false
+	}
+}
+
+// Success closes the circuit of model.
+func (b *CircuitBreaker) Success(model string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.circuits, model)
+}
+
+// Failure records a failure for model, opening its circuit once the threshold is reached.
+func (b *CircuitBreaker) Failure(model string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	circ, ok := b.circuits[model]
+	if !ok {
+		circ = &circuit{}
+		b.circuits[model] = circ
+	}
+	circ.failures++
+	if circ.trial || circ.failures >= b.Threshold {
+		circ.openedAt = time.Now()
+		circ.trial = false
+	}
+}
+
+// route returns the model a request for model must be sent to, and the release
+// of the trial the request may be, to call once the request ended.
+func (b *CircuitBreaker) route(model string) (string, func(), error) {
+	trial, err := b.allow(model)
+	if err == nil {
+		return model, func() { b.releaseTrial(model, trial) }, nil
+	}
+	b.mu.Lock()
+	fallback, ok := b.Fallbacks[model]
+	b.mu.Unlock()
+	if !ok {
+		return "", nil, err
+	}
+	trial, fallbackErr := b.allow(fallback)
+	if fallbackErr != nil {
+		return "", nil, err
+	}
+	return fallback, func() { b.releaseTrial(fallback, trial) }, nil
+}
+
+// record reports the outcome of a request. Client errors other than timeouts and
+// rate limits prove the model is reachable and count as successes, context
+// cancellations are ignored.
+func (b *CircuitBreaker) record(model string, err error) {
+	if errors.Is(err, context.Canceled) {
+		b.mu.Lock()
+		if circ, ok := b.circuits[model]; ok {
+			circ.trial = false
+		}
+		b.mu.Unlock()
+		return
+	}
+	status := errorStatusCode(err)
+	if err == nil || (status >= http.StatusBadRequest && status < http.StatusInternalServerError &&
+		status != http.StatusRequestTimeout && status != http.StatusTooManyRequests) {
+		b.Success(model)
+		return
+	}
+	b.Failure(model)
+}
+
+// routeCircuit returns the request to send, redirected to the fallback model
+// while the circuit of its model is open. The returned release must be called
+// once the request ended: a half-open circuit whose trial request stopped before
+// recordCircuit, e.g. on a budget error, would otherwise stay open.
+func (c *Client) routeCircuit(request *ChatCompletionRequest) (*ChatCompletionRequest, func(), error) {
+	breaker := c.config.CircuitBreaker
+	if breaker == nil {
+		return request, func() {}, nil
+	}
+	model, release, err := breaker.route(request.Model)
+	if err != nil {
+		return nil, nil, err
+	}
+	if model != request.Model {
+		redirected := *request
+		redirected.Model = model
+		request = &redirected
+	}
+	return request, release, nil
+}
+
+func (c *Client) recordCircuit(model string, err error) {
+	if c.config.CircuitBreaker != nil {
+		c.config.CircuitBreaker.record(model, err)
+	}
+}
@@ -0,0 +1,138 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	breaker := NewCircuitBreaker(2, 10*time.Millisecond)
+
+	breaker.Failure("m")
+	if err := breaker.Allow("m"); err != nil {
+		t.Fatalf("expected a closed circuit below the threshold, got %v", err)
+	}
+	breaker.Failure("m")
+	if err := breaker.Allow("m"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected an open circuit, got %v", err)
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	if err := breaker.Allow("m"); err != nil {
+		t.Fatalf("expected a trial after the cooldown, got %v", err)
+	}
+	if err := breaker.Allow("m"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected a single trial, got %v", err)
+	}
+	breaker.Failure("m")
+	if err := breaker.Allow("m"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the failed trial to open the circuit again, got %v", err)
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	if err := breaker.Allow("m"); err != nil {
+		t.Fatalf("expected a trial after the cooldown, got %v", err)
+	}
+	breaker.Success("m")
+	if err := breaker.Allow("m"); err != nil {
+		t.Fatalf("expected the successful trial to close the circuit, got %v", err)
+	}
+}
+
+func TestClient_CircuitBreakerFallback(t *testing.T) {
+	var models []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var request ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		models = append(models, request.Model)
+		if request.Model == "primary" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = io.WriteString(w, `{"error":{"code":500,"message":"down"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"model":"fallback","choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	})
+	client.config.Retry.MaxRetries = -1
+	client.config.CircuitBreaker = NewCircuitBreaker(1, time.Hour)
+	client.config.CircuitBreaker.Fallbacks["primary"] = "fallback"
+
+	if _, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: "primary"}); err == nil {
+		t.Fatal("expected the primary model to fail")
+	}
+	resp, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: "primary"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Model != "fallback" || len(models) != 2 || models[1] != "fallback" {
+		t.Errorf("expected the fallback model, got %s after %v", resp.Model, models)
+	}
+}
+
+func TestClient_CircuitBreakerCrossFamilyFallback(t *testing.T) {
+	var body map[string]any
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = io.WriteString(w, `{"model":"anthropic/claude-3.5-sonnet","choices":[{"message":{"content":"ok"}}]}`)
+	})
+	client.config.NormalizeSystemPrompts = true
+	client.config.CircuitBreaker = NewCircuitBreaker(1, time.Hour)
+	client.config.CircuitBreaker.Fallbacks["openai/o3"] = "anthropic/claude-3.5-sonnet"
+	client.config.CircuitBreaker.Failure("openai/o3")
+
+	_, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{
+		Model: "openai/o3",
+		Messages: []ChatCompletionMessage{
+			{Role: ChatMessageRoleSystem, Content: "be brief"},
+			{Role: ChatMessageRoleUser, Content: "hi"},
+		},
+		MaxCompletionTokens: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The request is translated for the fallback model, not for openai/o3.
+	role := body["messages"].([]any)[0].(map[string]any)["role"]
+	if body["model"] != "anthropic/claude-3.5-sonnet" || body["max_tokens"] != 100.0 ||
+		body["max_completion_tokens"] != nil || role != ChatMessageRoleSystem {
+		t.Errorf("unexpected body %v", body)
+	}
+}
+
+func TestClient_CircuitBreakerReleasesTrial(t *testing.T) {
+	failing := true
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = io.WriteString(w, `{"error":{"code":500,"message":"down"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"model":"m","choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	})
+	client.config.Retry.MaxRetries = -1
+	client.config.CircuitBreaker = NewCircuitBreaker(1, time.Millisecond)
+	client.config.SpendTracker = NewSpendTracker()
+
+	if _, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4}); err == nil {
+		t.Fatal("expected the request to fail")
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// the trial request stops at the budget check, before its outcome is known
+	client.config.SpendTracker.SetBudget("", 1)
+	client.config.SpendTracker.Record("", 2)
+	_, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected a budget error, got %v", err)
+	}
+
+	client.config.SpendTracker = nil
+	failing = false
+	if _, err = client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4}); err != nil {
+		t.Fatalf("expected a new trial, got %v", err)
+	}
+}
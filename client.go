@@ -1,27 +1,45 @@
 package openrouter
 
 import (
-	"bytes"
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
-	"math"
-	"math/rand"
 	"net/http"
-	"strings"
 	"time"
 
 	utils "github.com/dedlockdave/go-openrouter/internal"
 )
 
+var (
+	ErrBodyNotReplayable = errors.New("request body can't be replayed, GetBody is not set")
+)
+
 type Client struct {
 	config ClientConfig
 
 	requestBuilder utils.RequestBuilder
+	catalog        *modelCatalog
+	inflight       *utils.SingleFlight
+	rateLimit      *rateLimiter
+	lifecycle      *lifecycle
+	// ownsHTTPClient is set when the HTTP client was built for this client, see
+	// ClientConfig.WithTransportConfig, rather than given or shared.
+	ownsHTTPClient bool
+}
+
+// ChatClient is the client API used by applications, implemented by Client and
+// by FakeClient for tests.
+type ChatClient interface {
+	CreateChatCompletion(ctx context.Context, request *ChatCompletionRequest) (*ChatCompletionResponse, error)
+	CreateChatCompletionStream(ctx context.Context, request *ChatCompletionRequest) (*ChatCompletionStream, error)
+	ListModels(ctx context.Context) (ModelsList, error)
 }
 
+var _ ChatClient = (*Client)(nil)
+
 func NewClient(auth, xTitle, httpReferer string) (*Client, error) {
 	config, err := DefaultConfig(auth, xTitle, httpReferer)
 	if err != nil {
@@ -31,75 +49,186 @@ func NewClient(auth, xTitle, httpReferer string) (*Client, error) {
 }
 
 func NewClientWithConfig(config ClientConfig) *Client {
-	return &Client{
+	client := &Client{
 		config:         config,
 		requestBuilder: utils.NewRequestBuilder(),
+		catalog:        &modelCatalog{},
+		inflight:       &utils.SingleFlight{},
+		rateLimit:      &rateLimiter{},
+		lifecycle:      newLifecycle(),
+	}
+	if shared := config.Shared; shared != nil {
+		shared.init()
+		client.config.HTTPClient = shared.httpClient
+		client.rateLimit = shared.rateLimit
+		client.catalog = shared.catalog
 	}
+	client.ownsHTTPClient = client.config.HTTPClient != nil && client.config.HTTPClient == config.ownedHTTPClient
+	return client
 }
 
-const (
-	maxRetries     = 3
-	initialBackoff = 1 * time.Second
-)
+// WithConfig returns a client deriving its config from the config of c modified
+// by update. The derived client shares the HTTP client, the models catalog and
+// the in-flight deduplication of c, making it cheap to create per tenant.
+func (c *Client) WithConfig(update func(config *ClientConfig)) *Client {
+	derived := *c
+	derived.config.Headers = c.config.Headers.Clone()
+	derived.lifecycle = c.lifecycle.child()
+	update(&derived.config)
+	derived.ownsHTTPClient = derived.config.HTTPClient != c.config.HTTPClient &&
+		derived.config.HTTPClient == derived.config.ownedHTTPClient
+	return &derived
+}
 
-var retryableErrors = []string{
-	"Overloaded",
-	"Internal Server Error",
-	"Provider returned error",
+// WithKey returns a derived client authenticating with key instead of the key,
+// key pool or auth provider of c.
+func (c *Client) WithKey(key string) *Client {
+	return c.WithConfig(func(config *ClientConfig) {
+		config.authToken = key
+		config.KeyPool = nil
+		config.AuthProvider = nil
+	})
 }
 
-func shouldRetry(err error) bool {
-	if err == nil {
-		return false
-	}
+func (c *Client) sendRequest(req *http.Request, v any) error {
+	return c.sendRequestWithRetry(req, v, nil)
+}
 
-	errMsg := err.Error()
-	for _, retryableErr := range retryableErrors {
-		if strings.Contains(errMsg, retryableErr) {
-			return true
-		}
-	}
-	return false
+// sendRequestWithRetry is sendRequest with retryRequest, if not nil, building
+// the request of each retry from the failed attempt.
+func (c *Client) sendRequestWithRetry(
+	req *http.Request,
+	v any,
+	retryRequest func(failed Attempt) (*http.Request, error),
+) error {
+	_, err := c.doRequestWithRetry(req, v, retryRequest)
+	return err
 }
 
-func (c *Client) sendRequest(req *http.Request, v any) error {
-	var lastErr error
+// doRequestWithRetry is sendRequestWithRetry returning the response of the last
+// attempt, if any, see doRequest.
+func (c *Client) doRequestWithRetry(
+	req *http.Request,
+	v any,
+	retryRequest func(failed Attempt) (*http.Request, error),
+) (*http.Response, error) {
+	ctx, release, err := c.lifecycle.acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
-	for attempt := 0; attempt <= maxRetries; attempt++ {
+	retry := c.config.Retry.withDefaults()
+	if retry.MaxElapsedTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, retry.MaxElapsedTime)
+		defer cancel()
+	}
+
+	var (
+		res             *http.Response
+		attempts        []Attempt
+		backoff         time.Duration
+		creditsDeadline time.Time
+	)
+	for attempt := 0; attempt <= retry.MaxRetries; attempt++ {
 		if attempt > 0 {
-			// Calculate exponential backoff with jitter
-			backoff := float64(initialBackoff) * math.Pow(2, float64(attempt-1))
-			jitter := (rand.Float64()*0.5 + 0.5) // 50%-150% of base backoff
-			sleepDuration := time.Duration(backoff * jitter)
-			time.Sleep(sleepDuration)
-
-			// Clone the request for retry since the original body may have been consumed
-			var err error
-			req, err = cloneRequest(req)
-			if err != nil {
-				return fmt.Errorf("failed to clone request for retry: %w", err)
+			if err := sleepContext(ctx, backoff); err != nil {
+				return res, fmt.Errorf("%w: %w", err, &MultiAttemptError{Attempts: attempts})
+			}
+
+			if retryRequest != nil {
+				if req, err = retryRequest(attempts[len(attempts)-1]); err != nil {
+					return res, fmt.Errorf("failed to build request for retry: %w", err)
+				}
 			}
 		}
 
-		err := c.doRequest(req, v)
+		var err error
+		res, err = c.doAttempt(ctx, req, v, retry.AttemptTimeout)
 		if err == nil {
-			return nil
+			return res, nil
+		}
+		attempts = append(attempts, newAttempt(attempt+1, res, err))
+		if errors.Is(err, ErrInsufficientCredits) && c.config.KeyPool == nil {
+			// only new credits can help, the attempt is repeated once they are
+			// available without counting as a retry
+			if waitErr := c.waitForCredits(ctx, &creditsDeadline); waitErr != nil {
+				return res, err
+			}
+			backoff = 0
+			attempt--
+			continue
 		}
 
-		// lastErr = err
-		// if !shouldRetry(err) {
-		// 	return err
-		// }
-
-		if attempt < maxRetries {
-			log.Printf("Request failed with error: %v. Retrying attempt %d/%d", err, attempt+1, maxRetries)
+		retryable := c.isRetryable(err)
+		willRetry := retryable && attempt < retry.MaxRetries
+		backoff = 0
+		if willRetry {
+			backoff = retry.backoff(attempt + 1)
+			c.logger().Printf("Request failed with error: %v. Retrying attempt %d/%d", err, attempt+1, retry.MaxRetries)
+		}
+		if c.config.OnRetry != nil {
+			c.config.OnRetry(RetryEvent{Attempt: attempt + 1, Err: err, Backoff: backoff, WillRetry: willRetry})
+		}
+		if !retryable {
+			if len(attempts) == 1 {
+				return res, err
+			}
+			break
 		}
 	}
 
-	return fmt.Errorf("all retry attempts failed, last error: %w", lastErr)
+	return res, &MultiAttemptError{Attempts: attempts}
+}
+
+// doAttempt sends a fresh copy of the request with ctx, bounded by timeout when
+// > 0. req itself is never sent, so it can be sent again by the next attempt.
+func (c *Client) doAttempt(ctx context.Context, req *http.Request, v any, timeout time.Duration) (*http.Response, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	attemptReq, err := newAttemptRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.doRequest(attemptReq, v)
+}
+
+// newAttemptRequest returns a copy of req with ctx and a fresh body obtained
+// from req.GetBody.
+func newAttemptRequest(ctx context.Context, req *http.Request) (*http.Request, error) {
+	attemptReq := req.Clone(ctx)
+	if req.Body == nil || req.Body == http.NoBody {
+		return attemptReq, nil
+	}
+	if req.GetBody == nil {
+		return nil, ErrBodyNotReplayable
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request body: %w", err)
+	}
+	attemptReq.Body = body
+	return attemptReq, nil
 }
 
-func (c *Client) doRequest(req *http.Request, v any) error {
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// doRequest sends the request once. The returned response, if any, has its body
+// consumed and closed but exposes the status code and headers.
+func (c *Client) doRequest(req *http.Request, v any) (*http.Response, error) {
 	req.Header.Set("Accept", "application/json; charset=utf-8")
 
 	// Check whether Content-Type is already set, Upload Files API requires
@@ -109,60 +238,96 @@ func (c *Client) doRequest(req *http.Request, v any) error {
 		req.Header.Set("Content-Type", "application/json; charset=utf-8")
 	}
 
-	c.setCommonHeaders(req)
+	if err := c.setCommonHeaders(req); err != nil {
+		return nil, err
+	}
+	c.dumpRequest(req)
+	if err := c.throttle(req.Context()); err != nil {
+		return nil, err
+	}
 
 	res, err := c.config.HTTPClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	c.reportKey(req, res)
+	c.recordRateLimit(res)
+	defer func() { res.Body.Close() }()
+
+	if err = decompressBody(res); err != nil {
+		return res, fmt.Errorf("failed to decompress response body: %w", err)
 	}
-	defer res.Body.Close()
 
 	// Handle non-200 responses
 	if res.StatusCode != http.StatusOK {
-		return c.handleErrorResp(res)
+		return res, c.handleErrorResp(res)
 	}
 
 	// Check for empty response body
 	if res.Body == nil {
-		return fmt.Errorf("empty response body")
+		return res, fmt.Errorf("empty response body")
 	}
 
 	bodyBytes, err := io.ReadAll(res.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return res, fmt.Errorf("failed to read response body: %w", err)
+	}
+	c.dumpResponse(res, bodyBytes)
+
+	_, rawBody := v.(*string)
+	if v != nil && !rawBody && !isJSONResponse(res.Header.Get("Content-Type"), bodyBytes) {
+		return res, newUnexpectedResponseError(res, bodyBytes, nil)
 	}
 
-	// First try to unmarshal as error response
+	// First try to unmarshal as error response. An error accompanied by choices is
+	// a warning attached to the response, otherwise it is fatal.
 	var errorResp ErrorResponse
 	if err := json.Unmarshal(bodyBytes, &errorResp); err == nil {
 		if errorResp.Error != nil && errorResp.Error.Message != "" {
-			return fmt.Errorf("API error: %s", errorResp.Error.Message)
+			errorResp.Error.HTTPStatusCode = res.StatusCode
+			if v == nil || json.Unmarshal(bodyBytes, v) != nil || !attachWarning(v, errorResp.Error) {
+				return res, errorResp.Error
+			}
+			return res, nil
 		}
 	}
 
 	// If v is nil, we don't need to decode anything
 	if v == nil {
-		return nil
+		return res, nil
 	}
 
 	// Handle string responses
 	if result, ok := v.(*string); ok {
 		*result = string(bodyBytes)
-		return nil
+		return res, nil
 	}
 
-	// Try to decode JSON response
-	if err := json.Unmarshal(bodyBytes, v); err != nil {
-		return fmt.Errorf("failed to decode response: %w, body: %s", err, string(bodyBytes))
+	if err := c.config.Decoding.unmarshaler().Unmarshal(bodyBytes, v); err != nil {
+		return res, newUnexpectedResponseError(res, bodyBytes, fmt.Errorf("failed to decode response: %w", err))
 	}
 
-	return nil
+	return res, nil
 }
 
-func (c *Client) setCommonHeaders(req *http.Request) {
+func (c *Client) setCommonHeaders(req *http.Request) error {
+	key, err := c.apiKey(req.Context())
+	if err != nil {
+		return err
+	}
 	req.Header.Set("HTTP-Referer", c.config.HttpReferer)
 	req.Header.Set("X-Title", c.config.XTitle)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.authToken))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", key))
+	req.Header.Set("User-Agent", c.userAgent())
+	c.setAcceptEncoding(req)
+	for name, values := range c.config.Headers {
+		req.Header.Del(name)
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	setIdempotencyHeader(req)
+	return nil
 }
 
 func isFailureStatusCode(resp *http.Response) bool {
@@ -190,41 +355,88 @@ func (c *Client) newStreamRequest(
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Connection", "keep-alive")
 
-	c.setCommonHeaders(req)
+	if err = c.setCommonHeaders(req); err != nil {
+		return nil, err
+	}
 	return req, nil
 }
 
-func (c *Client) handleErrorResp(resp *http.Response) error {
-	var errRes ErrorResponse
+func sendRequestStream[T streamable](c *Client, req *http.Request) (*streamReader[T], error) {
+	resp, release, start, err := c.openStream(req)
+	if err != nil {
+		return nil, err
+	}
+	return &streamReader[T]{
+		emptyMessagesLimit: c.config.EmptyMessagesLimit,
+		reader:             bufio.NewReader(resp.Body),
+		response:           resp,
+		errAccumulator:     utils.NewErrorAccumulator(),
+		unmarshaler:        c.config.Decoding.unmarshaler(),
+		logger:             c.streamLogger(req.Context()),
+		logPrefix:          logPrefix(req.Context()),
+		release:            release,
+		timings:            Timings{Start: start},
+	}, nil
+}
 
-	err := json.NewDecoder(resp.Body).Decode(&errRes)
-	if err != nil || errRes.Error == nil {
-		reqErr := &RequestError{
-			HTTPStatusCode: resp.StatusCode,
-			Err:            err,
-		}
-		if errRes.Error != nil {
-			reqErr.Err = errRes.Error
+// openStream sends a streaming request and checks the response status. On
+// success the caller owns the response body and calls release once done with
+// it. start is when the request was sent.
+func (c *Client) openStream(req *http.Request) (resp *http.Response, release func(), start time.Time, err error) {
+	ctx, done, err := c.lifecycle.acquire(req.Context())
+	if err != nil {
+		return nil, nil, start, err
+	}
+	defer func() {
+		if err != nil {
+			done()
 		}
-		return reqErr
+	}()
+	req = req.WithContext(ctx)
+
+	c.dumpRequest(req)
+	if err = c.throttle(ctx); err != nil {
+		return nil, nil, start, err
+	}
+	start = time.Now()
+	resp, err = c.config.HTTPClient.Do(req) //nolint:bodyclose // body is closed by the caller
+	if err != nil {
+		return nil, nil, start, err
 	}
+	c.reportKey(req, resp)
+	c.recordRateLimit(resp)
+	if isFailureStatusCode(resp) {
+		defer resp.Body.Close()
+		return nil, nil, start, c.handleErrorResp(resp)
+	}
+	return resp, done, start, nil
+}
 
-	errRes.Error.HTTPStatusCode = resp.StatusCode
-	return errRes.Error
+// handleErrorResp decodes the error of a failed response, a 402 being reported
+// as an *InsufficientCreditsError.
+func (c *Client) handleErrorResp(resp *http.Response) error {
+	err := c.decodeErrorResp(resp)
+	if resp.StatusCode == http.StatusPaymentRequired {
+		return &InsufficientCreditsError{Err: err}
+	}
+	return err
 }
 
-func cloneRequest(req *http.Request) (*http.Request, error) {
-	clone := req.Clone(req.Context())
+func (c *Client) decodeErrorResp(resp *http.Response) error {
+	var errRes ErrorResponse
 
-	// If there's a body, we need to clone it
-	if req.Body != nil {
-		bodyBytes, err := io.ReadAll(req.Body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read original request body: %w", err)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &RequestError{HTTPStatusCode: resp.StatusCode, Err: err}
+	}
+	c.dumpResponse(resp, body)
+	if err = json.Unmarshal(body, &errRes); err != nil || errRes.Error == nil {
+		return &RequestError{
+			HTTPStatusCode: resp.StatusCode,
+			Err:            newUnexpectedResponseError(resp, body, err),
 		}
-		req.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))   // Restore original body
-		clone.Body = io.NopCloser(bytes.NewBuffer(bodyBytes)) // Set cloned body
 	}
 
-	return clone, nil
+	errRes.Error.HTTPStatusCode = resp.StatusCode
+	return errRes.Error
 }
@@ -0,0 +1,1268 @@
+package openrouter
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config, err := DefaultConfig("test-key", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.BaseURL = server.URL
+	return NewClientWithConfig(config)
+}
+
+func TestClient_ErrorWithChoicesIsWarning(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"model":"m","choices":[{"message":{"role":"assistant","content":"part"}}],`+
+			`"error":{"code":502,"message":"Provider returned error"}}`)
+	})
+
+	resp, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Warning == nil || resp.Warning.Message != "Provider returned error" {
+		t.Errorf("unexpected warning %#v", resp.Warning)
+	}
+	if resp.Choices[0].Message.Content != "part" {
+		t.Errorf("unexpected choices %#v", resp.Choices)
+	}
+}
+
+func TestClient_ErrorWithoutChoicesIsAPIError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"error":{"code":400,"message":"bad request"}}`)
+	})
+
+	req, err := client.requestBuilder.Build(context.Background(), http.MethodGet, client.fullURL("/x"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp *ChatCompletionResponse
+	_, err = client.doRequest(req, &resp)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "bad request" {
+		t.Errorf("expected *APIError, got %v", err)
+	}
+}
+
+func TestClient_GzipResponse(t *testing.T) {
+	var encodings []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Accept-Encoding"))
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			_, _ = io.WriteString(w, `{"data":[{"id":"openai/gpt-4"}]}`)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = io.WriteString(gz, `{"data":[{"id":"openai/gpt-4"}]}`)
+		_ = gz.Close()
+	})
+
+	for _, disable := range []bool{false, true} {
+		client.config.DisableCompression = disable
+		models, err := client.ListModels(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(models.Data) != 1 || models.Data[0].ID != OpenaiGpt4 {
+			t.Errorf("unexpected models %#v", models)
+		}
+	}
+	if len(encodings) != 2 || encodings[0] != "gzip" || encodings[1] != "identity" {
+		t.Errorf("unexpected Accept-Encoding headers %q", encodings)
+	}
+}
+
+func TestClient_ContinuesTruncatedResponse(t *testing.T) {
+	var prefills []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var request ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		last := request.Messages[len(request.Messages)-1]
+		if last.Role != ChatMessageRoleAssistant {
+			_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"Hello, "},`+
+				`"finish_reason":"MAX_TOKENS"}],"usage":{"completion_tokens":2}}`)
+			return
+		}
+		prefills = append(prefills, last.Content)
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"world"},`+
+			`"finish_reason":"stop"}],"usage":{"completion_tokens":1}}`)
+	})
+	client.config.Continuation = &ContinuationPolicy{}
+
+	resp, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{
+		Model:    Gpt4,
+		Messages: []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Choices[0].Message.Content; got != "Hello, world" {
+		t.Errorf("got content %q", got)
+	}
+	if resp.Choices[0].FinishReason != FinishReasonStop || resp.Usage.CompletionTokens != 3 {
+		t.Errorf("unexpected response %#v", resp)
+	}
+	if len(prefills) != 1 || prefills[0] != "Hello, " {
+		t.Errorf("unexpected prefills %q", prefills)
+	}
+}
+
+func TestClient_RetryExcludesFailingProvider(t *testing.T) {
+	var ignored [][]string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var request ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if request.Provider == nil {
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = io.WriteString(w, `{"error":{"code":502,"message":"Provider returned error",`+
+				`"metadata":{"provider_name":"Broken"}}}`)
+			return
+		}
+		ignored = append(ignored, request.Provider.Ignore)
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	})
+	client.config.Retry = RetryConfig{InitialBackoff: time.Millisecond, ExcludeFailingProvider: true}
+
+	if _, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ignored) != 1 || len(ignored[0]) != 1 || ignored[0][0] != "Broken" {
+		t.Errorf("unexpected ignored providers %q", ignored)
+	}
+}
+
+func TestClient_RateLimitState(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "20")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.UnixMilli(), 10))
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	})
+	client.config.Throttle = ThrottleConfig{MinRemaining: 1, MaxWait: 20 * time.Millisecond}
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	state := client.RateLimitState()
+	if state.Limit != 20 || state.Remaining != 0 || !state.Reset.Equal(reset) {
+		t.Errorf("unexpected state %+v", state)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	client.config.Throttle.MaxWait = 0
+	if err := client.throttle(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected throttling until the deadline, got %v", err)
+	}
+}
+
+func TestClient_SemanticCache(t *testing.T) {
+	var calls int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"Paris"}}]}`)
+	})
+	embeddings := map[string][]float64{
+		"capital of France?":     {1, 0.1},
+		"what's France capital?": {1, 0.12},
+		"best pizza in Naples?":  {0, 1},
+	}
+	embedder := EmbedderFunc(func(_ context.Context, text string) ([]float64, error) {
+		return embeddings[text], nil
+	})
+	client.config.ResponseCache = &ResponseCache{
+		Store:    NewMemoryCache(),
+		CacheAll: true,
+		Semantic: NewSemanticCache(embedder, 0.99),
+	}
+
+	for _, prompt := range []string{"capital of France?", "what's France capital?", "best pizza in Naples?"} {
+		_, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{
+			Model:    Gpt4,
+			Messages: []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: prompt}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("got %d API calls, want 2", calls)
+	}
+}
+
+func TestClient_AuthProvider(t *testing.T) {
+	var got []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+		_, _ = io.WriteString(w, `{"data":{"total_credits":1}}`)
+	})
+	keys := []string{"first", "rotated"}
+	client.config.AuthProvider = AuthProviderFunc(func(context.Context) (string, error) {
+		key := keys[0]
+		keys = keys[1:]
+		return key, nil
+	})
+
+	for range 2 {
+		if _, err := client.GetCredits(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(got) != 2 || got[0] != "Bearer first" || got[1] != "Bearer rotated" {
+		t.Errorf("unexpected authorization headers %q", got)
+	}
+}
+
+func TestClient_Close(t *testing.T) {
+	started := make(chan struct{})
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		close(started)
+		<-r.Context().Done()
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err = client.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the drain to time out, got %v", err)
+	}
+	if _, err = stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stream.Recv(); err == nil {
+		t.Error("expected the aborted stream to fail")
+	}
+	if _, err = client.GetCredits(context.Background()); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("expected ErrClientClosed, got %v", err)
+	}
+}
+
+func TestClient_WithKey(t *testing.T) {
+	var (
+		auth    []string
+		tenants []string
+		catalog int
+	)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/models" {
+			catalog++
+			_, _ = io.WriteString(w, `{"data":[{"id":"`+Gpt4+`"}]}`)
+			return
+		}
+		auth, tenants = append(auth, r.Header.Get("Authorization")), append(tenants, r.Header.Get("X-Tenant"))
+		_, _ = io.WriteString(w, `{"data":{}}`)
+	})
+	client.config.KeyPool = NewKeyPool(KeyStrategyRoundRobin, "pooled")
+	client.config = client.config.WithHeader("X-Tenant", "parent")
+
+	tenant := client.WithKey("tenant-key")
+	tenant.config.Headers.Set("X-Tenant", "acme")
+	for _, c := range []*Client{client, tenant} {
+		if _, err := c.GetCredits(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.GetModel(context.Background(), Gpt4); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if auth[0] != "Bearer pooled" || auth[1] != "Bearer tenant-key" {
+		t.Errorf("unexpected authorization headers %q", auth)
+	}
+	if tenants[0] != "parent" || tenants[1] != "acme" {
+		t.Errorf("expected the headers of the derived client to be its own, got %q", tenants)
+	}
+	if catalog != 1 {
+		t.Errorf("expected the models catalog to be shared, got %d fetches", catalog)
+	}
+}
+
+func TestClient_CloseDerived(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"data":{}}`)
+	})
+	tenant, sibling := client.WithKey("tenant"), client.WithKey("sibling")
+
+	if err := tenant.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tenant.GetCredits(context.Background()); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("expected ErrClientClosed, got %v", err)
+	}
+	for _, open := range []*Client{client, sibling} {
+		if _, err := open.GetCredits(context.Background()); err != nil {
+			t.Errorf("expected the client to stay open, got %v", err)
+		}
+	}
+
+	if err := client.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sibling.GetCredits(context.Background()); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("expected the derived client to be closed with its parent, got %v", err)
+	}
+}
+
+type idleConnsCloser struct {
+	http.RoundTripper
+	closed int
+}
+
+func (t *idleConnsCloser) CloseIdleConnections() {
+	t.closed++
+}
+
+func TestClient_CloseIdleConnections(t *testing.T) {
+	closed := make(chan struct{})
+	var once sync.Once
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"data":{}}`)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			once.Do(func() { close(closed) })
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+
+	config, err := DefaultConfig("test-key", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.BaseURL = server.URL
+	given := &idleConnsCloser{RoundTripper: http.DefaultTransport}
+	borrower := NewClientWithConfig(config.WithHttpClientConfig(&http.Client{Transport: given}))
+	owner := NewClientWithConfig(config.WithTransportConfig(TransportConfig{}))
+	if owner.WithKey("tenant").ownsHTTPClient {
+		t.Error("expected the derived client not to own the HTTP client of its parent")
+	}
+	for _, client := range []*Client{borrower, owner} {
+		if _, err = client.GetCredits(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if err = client.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if given.closed != 0 {
+		t.Errorf("expected the given HTTP client to be left untouched, got %d closes", given.closed)
+	}
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Error("expected the idle connections of the owned HTTP client to be closed")
+	}
+}
+
+func TestClient_UserAgent(t *testing.T) {
+	var userAgent, tenant string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		userAgent, tenant = r.Header.Get("User-Agent"), r.Header.Get("X-Tenant")
+		_, _ = io.WriteString(w, `{"data":{}}`)
+	})
+	client.config = client.config.WithUserAgent("my-app/1.2").WithHeader("x-tenant", "acme")
+
+	if _, err := client.GetCredits(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if userAgent != "my-app/1.2 go-openrouter/"+Version || tenant != "acme" {
+		t.Errorf("unexpected headers %q, %q", userAgent, tenant)
+	}
+}
+
+func TestClient_Warmup(t *testing.T) {
+	var mu sync.Mutex
+	remotes := make(map[string]bool)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		remotes[r.RemoteAddr] = true
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	})
+	client.config = client.config.WithTransportConfig(TransportConfig{MaxIdleConnsPerHost: 3})
+
+	if err := client.Warmup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(remotes) != 3 {
+		t.Errorf("got %d connections, want 3", len(remotes))
+	}
+}
+
+func TestClient_OnRetry(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, `{"error":{"code":503,"message":"Overloaded"}}`)
+	})
+	var events []RetryEvent
+	client.config.Retry = RetryConfig{MaxRetries: 2, InitialBackoff: time.Millisecond}
+	client.config.OnRetry = func(event RetryEvent) { events = append(events, event) }
+
+	if _, err := client.GetCredits(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	if !events[0].WillRetry || events[0].Backoff <= 0 || events[2].WillRetry || events[2].Attempt != 3 {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
+
+func TestClient_RetryReplaysBody(t *testing.T) {
+	var bodies []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	})
+	client.config.Retry = RetryConfig{InitialBackoff: time.Millisecond}
+
+	if _, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4}); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 || bodies[0] == "" || bodies[0] != bodies[1] {
+		t.Errorf("unexpected bodies %q", bodies)
+	}
+}
+
+func TestClient_UnexpectedResponse(t *testing.T) {
+	status := http.StatusOK
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, "<html>"+strings.Repeat("x", 1000)+"</html>")
+	})
+	client.config.Retry = RetryConfig{MaxRetries: -1}
+
+	for _, status = range []int{http.StatusOK, 520} {
+		_, err := client.GetCredits(context.Background())
+		var unexpectedErr *UnexpectedResponseError
+		if !errors.As(err, &unexpectedErr) {
+			t.Fatalf("expected UnexpectedResponseError, got %v", err)
+		}
+		if unexpectedErr.HTTPStatusCode != status || unexpectedErr.ContentType != "text/html" ||
+			!strings.HasPrefix(unexpectedErr.Body, "<html>") || len(unexpectedErr.Body) > maxBodySnippet+3 {
+			t.Errorf("unexpected error %+v", unexpectedErr)
+		}
+	}
+}
+
+func TestClient_UploadFile(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		content, _ := io.ReadAll(file)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"purpose":      r.FormValue("purpose"),
+			"filename":     header.Filename,
+			"content_type": header.Header.Get("Content-Type"),
+			"content":      string(content),
+		})
+	})
+
+	var got map[string]string
+	err := client.UploadFile(context.Background(), "/files", FileUpload{
+		FileName: "notes.json",
+		Reader:   strings.NewReader(`{"a":1}`),
+		Fields:   map[string]string{"purpose": "batch"},
+	}, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"purpose": "batch", "filename": "notes.json", "content_type": "application/json", "content": `{"a":1}`,
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("got %s %q, want %q", key, got[key], value)
+		}
+	}
+}
+
+func TestClient_DebugLogsFingerprint(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	})
+	var logs strings.Builder
+	client.config.Logger = log.New(&logs, "", 0)
+
+	request := &ChatCompletionRequest{Model: Gpt4, Messages: []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "hi"}}}
+	hash := request.Hash()
+	if len(hash) != 64 {
+		t.Fatalf("unexpected hash %q", hash)
+	}
+	if _, err := client.CreateChatCompletion(WithDebug(context.Background()), request); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(logs.String(), "openrouter: ["+hash+"] "); got != 2 {
+		t.Errorf("fingerprint logged %d times, want 2:\n%s", got, logs.String())
+	}
+
+	streamed := *request
+	streamed.Stream = true
+	if streamed.Hash() != hash {
+		t.Error("the stream flag changed the hash")
+	}
+}
+
+func TestClient_DebugRedactsSecrets(t *testing.T) {
+	const apiKey, proxyAuth, cookie = "sk-or-secret", "Basic cHJveHk6c2VjcmV0", "session=secret"
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", cookie)
+		if r.Header.Get("Accept") == "text/event-stream" {
+			_, _ = io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\ndata: [DONE]\n\n")
+			return
+		}
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	}).WithConfig(func(config *ClientConfig) {
+		*config = config.WithHeader("Proxy-Authorization", proxyAuth).WithHeader("Cookie", cookie)
+		config.authToken = apiKey
+	})
+	var logs strings.Builder
+	client.config.Logger = log.New(&logs, "", 0)
+
+	request := &ChatCompletionRequest{Model: Gpt4}
+	if _, err := client.CreateChatCompletion(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	if logs.Len() != 0 {
+		t.Fatalf("expected no dump without debugging, got:\n%s", logs.String())
+	}
+
+	ctx := WithDebug(context.Background())
+	if _, err := client.CreateChatCompletion(ctx, request); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := client.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	stream.Close()
+
+	dump := logs.String()
+	if !strings.Contains(dump, "stream data: ") || strings.Count(dump, redacted) < 6 {
+		t.Errorf("expected the redacted requests, responses and stream lines, got:\n%s", dump)
+	}
+	for _, secret := range []string{apiKey, proxyAuth, cookie} {
+		if strings.Contains(dump, secret) {
+			t.Errorf("secret %q leaked in the debug dumps:\n%s", secret, dump)
+		}
+	}
+}
+
+func TestClient_Timings(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == "text/event-stream" {
+			_, _ = io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+			w.(http.Flusher).Flush()
+			time.Sleep(5 * time.Millisecond)
+			_, _ = io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\ndata: [DONE]\n\n")
+			return
+		}
+		_, _ = io.WriteString(w, `{"system_fingerprint":"fp_1","choices":[{"message":{"content":"ok"}}]}`)
+	})
+
+	resp, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.SystemFingerprint != "fp_1" || resp.Timings == nil || resp.Timings.Duration <= 0 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	timings := stream.Timings()
+	if timings.TimeToFirstToken < 5*time.Millisecond || timings.Duration < timings.TimeToFirstToken {
+		t.Errorf("unexpected stream timings %+v", timings)
+	}
+}
+
+func TestClient_WithUser(t *testing.T) {
+	var users []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var request ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		users = append(users, request.User)
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	})
+	client.config.UserIDHasher = HMACUserID([]byte("secret"))
+
+	ctx := WithUser(context.Background(), "alice")
+	request := &ChatCompletionRequest{Model: Gpt4, User: "bob"}
+	for _, request := range []*ChatCompletionRequest{{Model: Gpt4}, request} {
+		if _, err := client.CreateChatCompletion(ctx, request); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if request.User != "bob" {
+		t.Errorf("the request of the caller was modified: %q", request.User)
+	}
+	hash := HMACUserID([]byte("secret"))
+	if len(users) != 2 || users[0] != hash("alice") || users[0] == "alice" || users[1] != hash("bob") {
+		t.Errorf("unexpected users %q", users)
+	}
+}
+
+func TestClient_Sanitizer(t *testing.T) {
+	var sent string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var request ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		sent = request.Messages[0].Content
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"I will write to [EMAIL_1]."}}]}`)
+	})
+	client.config.Sanitizer = &PatternSanitizer{
+		Patterns: []SanitizePattern{{Name: "email", Regexp: regexp.MustCompile(`[\w.]+@[\w.]+`)}},
+		Restore:  true,
+	}
+
+	request := &ChatCompletionRequest{
+		Model:    Gpt4,
+		Messages: []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "Email bob@example.com, cc bob@example.com"}},
+	}
+	resp, err := client.CreateChatCompletion(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != "Email [EMAIL_1], cc [EMAIL_1]" {
+		t.Errorf("unexpected sanitized content %q", sent)
+	}
+	if request.Messages[0].Content != "Email bob@example.com, cc bob@example.com" {
+		t.Errorf("caller's message was modified: %q", request.Messages[0].Content)
+	}
+	if resp.Choices[0].Message.Content != "I will write to bob@example.com." {
+		t.Errorf("placeholders not restored: %q", resp.Choices[0].Message.Content)
+	}
+}
+
+func TestClient_ModelsDiskCache(t *testing.T) {
+	var requests, notModified int
+	unavailable := true
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if unavailable {
+			unavailable = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, `{"error":{"code":503,"message":"unavailable"}}`)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, `{"data":[{"id":"openai/gpt-4o"}]}`)
+	})
+	path := t.TempDir() + "/models.json"
+	client.config.ModelsCachePath = path
+	client.config.Retry.InitialBackoff = time.Millisecond
+
+	newClient := func() *Client { return NewClientWithConfig(client.config) }
+	for i := 0; i < 2; i++ {
+		if _, err := newClient().GetModel(context.Background(), "openai/gpt-4o"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != 2 {
+		t.Fatalf("expected a retry then the second client to read the disk cache, got %d requests", requests)
+	}
+
+	unavailable = true
+	cached, _ := readModelsCache(path)
+	cached.FetchedAt = time.Now().Add(-2 * modelCatalogTTL)
+	if err := writeModelsCache(path, cached); err != nil {
+		t.Fatal(err)
+	}
+	models, err := newClient().Models(context.Background())
+	if err != nil || len(models.Data) != 1 || notModified != 1 || requests != 4 {
+		t.Errorf("expected a retried revalidation, got %+v, %v, %d not modified", models, err, notModified)
+	}
+}
+
+func TestClient_ModelsFetchUnlocked(t *testing.T) {
+	arrived, release := make(chan struct{}), make(chan struct{})
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		close(arrived)
+		<-release
+		_, _ = io.WriteString(w, `{"data":[{"id":"openai/gpt-4o"}]}`)
+	})
+	client.catalog.endpoints = map[string]cachedModelEndpoints{
+		"openai/gpt-4o": {endpoints: ModelEndpoints{ID: "openai/gpt-4o"}, fetchedAt: time.Now()},
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := client.GetModel(context.Background(), "openai/gpt-4o")
+		done <- err
+	}()
+	<-arrived
+	read := make(chan struct{})
+	go func() {
+		_, _ = client.modelEndpoints(context.Background(), "openai/gpt-4o")
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		t.Error("expected the catalog to be readable while the models are fetched")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient_Complete(t *testing.T) {
+	var models []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var request ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		models = append(models, request.Model)
+		if request.Model == "openrouter/auto" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"error":{"code":400,"message":"bad model"}}`)
+			return
+		}
+		if request.Messages[0].Role != ChatMessageRoleSystem || request.Usage == nil || !request.Usage.Include {
+			t.Errorf("unexpected request %+v", request)
+		}
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"4"}}],"usage":{"total_tokens":9,"cost":0.001}}`)
+	})
+	client.config.Retry.MaxRetries = -1
+	client.config.FallbackPolicy = &FallbackPolicy{Models: []FallbackModel{{Model: "openai/gpt-4o-mini"}}}
+
+	text, usage, err := client.Complete(context.Background(), "2+2?",
+		CompleteModel("openrouter/auto"), CompleteSystem("Answer with a number."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "4" || usage.TotalTokens != 9 || usage.Cost != 0.001 {
+		t.Errorf("unexpected completion %q, %+v", text, usage)
+	}
+	if len(models) != 2 || models[1] != "openai/gpt-4o-mini" {
+		t.Errorf("expected a fallback, got %q", models)
+	}
+}
+
+func TestClient_MaxCostPerRequest(t *testing.T) {
+	var completions int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/models" {
+			_, _ = io.WriteString(w, `{"data":[{"id":"openai/gpt-4o","context_length":128000,`+
+				`"pricing":{"prompt":"0.0000025","completion":"0.00001"}}]}`)
+			return
+		}
+		completions++
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	})
+	client.config.MaxCostPerRequest = 0.01
+
+	messages := []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "Hi"}}
+	_, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{
+		Model: "openai/gpt-4o", Messages: messages,
+	})
+	var capErr *CostCapExceededError
+	if !errors.Is(err, ErrCostCapExceeded) || !errors.As(err, &capErr) ||
+		capErr.Estimate.MaxCompletionTokens != 128000-capErr.Estimate.PromptTokens {
+		t.Fatalf("expected the cost cap to be exceeded, got %v", err)
+	}
+
+	_, err = client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{
+		Model: "openai/gpt-4o", Messages: messages, MaxTokens: 500,
+	})
+	if err != nil || completions != 1 {
+		t.Errorf("expected the capped request to be sent, got %v, %d completions", err, completions)
+	}
+
+	_, err = client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{
+		Model: "openai/gpt-4o:online", Messages: messages, MaxTokens: 500,
+	})
+	if err != nil || completions != 2 {
+		t.Errorf("expected the variant to be priced as its base model, got %v, %d completions", err, completions)
+	}
+
+	_, err = client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{
+		Model: "openrouter/auto", Messages: messages, MaxTokens: 500,
+	})
+	if !errors.Is(err, ErrCostUnknown) || !errors.Is(err, ErrModelNotFound) || completions != 2 {
+		t.Errorf("expected the unknown model to be refused, got %v, %d completions", err, completions)
+	}
+}
+
+func TestClient_MinContextLength(t *testing.T) {
+	var only, fetched []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/models/meta-llama/llama-3-70b/endpoints" {
+			_, _ = io.WriteString(w, `{"data":{"id":"meta-llama/llama-3-70b","endpoints":[`+
+				`{"provider_name":"DeepInfra","tag":"deepinfra/fp8","context_length":8192,"quantization":"fp8"},`+
+				`{"provider_name":"Together","context_length":131072,"quantization":"fp16"},`+
+				`{"provider_name":"Lambda","context_length":131072,"quantization":"fp8"}]}}`)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/endpoints") {
+			fetched = append(fetched, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error":{"code":404,"message":"Model not found"}}`)
+			return
+		}
+		var request ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		only = request.Provider.Only
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	})
+
+	request := &ChatCompletionRequest{
+		Model:    "meta-llama/llama-3-70b",
+		Provider: &ProviderPreferences{MinContextLength: 32000, Quantizations: []string{QuantizationFP8}},
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	if len(only) != 1 || only[0] != "lambda" {
+		t.Errorf("unexpected eligible providers %q", only)
+	}
+	if request.Provider.Only != nil {
+		t.Errorf("the request of the caller was modified: %+v", request.Provider)
+	}
+
+	// the endpoints of the fallback model are used once the circuit breaker
+	// redirects the request
+	client.config.CircuitBreaker = NewCircuitBreaker(1, time.Hour)
+	client.config.CircuitBreaker.Fallbacks["meta-llama/llama-3-70b"] = "meta-llama/llama-3-8b"
+	client.config.CircuitBreaker.Failure("meta-llama/llama-3-70b")
+	client.config.Retry.MaxRetries = -1
+	if _, err := client.CreateChatCompletion(context.Background(), request); err == nil ||
+		len(fetched) != 1 || fetched[0] != "/models/meta-llama/llama-3-8b/endpoints" {
+		t.Errorf("expected the endpoints of the fallback model to be fetched, got %v, %v", fetched, err)
+	}
+	client.config.CircuitBreaker = nil
+
+	request.Provider = &ProviderPreferences{MinContextLength: 200000}
+	if _, err := client.CreateChatCompletion(context.Background(), request); !errors.Is(err, ErrNoEligibleEndpoint) {
+		t.Errorf("expected ErrNoEligibleEndpoint, got %v", err)
+	}
+}
+
+func TestClient_PingAndCheckModel(t *testing.T) {
+	var requests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/key":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"error":{"code":401,"message":"No auth credentials found"}}`)
+		case "/models/openai/gpt-4o/endpoints":
+			_, _ = io.WriteString(w, `{"data":{"endpoints":[{"provider_name":"OpenAI","status":-2}]}}`)
+		}
+	})
+
+	var apiErr *APIError
+	if err := client.Ping(context.Background()); !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != 401 {
+		t.Errorf("expected an authentication error, got %v", err)
+	}
+	if err := client.CheckModel(context.Background(), "openai/gpt-4o"); !errors.Is(err, ErrModelUnavailable) {
+		t.Errorf("expected ErrModelUnavailable, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected no retries, got %d requests", requests)
+	}
+}
+
+func TestClient_SharedState(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "7")
+		_, _ = io.WriteString(w, `{"data":[]}`)
+	})
+	shared := NewSharedState(TransportConfig{})
+	client.config.Shared = shared
+
+	first, second := NewClientWithConfig(client.config), NewClientWithConfig(client.config)
+	if first.config.HTTPClient != shared.HTTPClient() || second.config.HTTPClient != shared.HTTPClient() {
+		t.Fatal("expected the clients to share the HTTP client")
+	}
+	if _, err := first.ListModels(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if state := second.RateLimitState(); state.Remaining != 7 {
+		t.Errorf("expected the rate limit state to be shared, got %+v", state)
+	}
+
+	client.config.Shared = &SharedState{}
+	zero := NewClientWithConfig(client.config)
+	if zero.config.HTTPClient == nil || zero.config.HTTPClient != client.config.Shared.HTTPClient() {
+		t.Fatal("expected the zero-value shared state to provide an HTTP client")
+	}
+	if _, err := zero.ListModels(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient_CreateChatCompletionRaw(t *testing.T) {
+	var received []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+		if strings.Contains(string(body), `"stream": true`) {
+			_, _ = io.WriteString(w, "data: {\"choices\":[]}\n\ndata: [DONE]\n\n")
+			return
+		}
+		if len(received) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"error":{"code":400,"message":"bad request"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"id":"gen-1","x_custom":true}`)
+	})
+	client.config.Retry.MaxRetries = -1
+
+	body := json.RawMessage(`{"model": "openai/gpt-4o",  "messages": [], "x_vendor": 1}`)
+	var apiErr *APIError
+	if _, err := client.CreateChatCompletionRaw(context.Background(), body); !errors.As(err, &apiErr) {
+		t.Fatalf("expected an API error, got %v", err)
+	}
+	response, err := client.CreateChatCompletionRaw(context.Background(), body)
+	if err != nil || string(response) != `{"id":"gen-1","x_custom":true}` || received[1] != string(body) {
+		t.Errorf("unexpected response %s, %v, sent %s", response, err, received[1])
+	}
+
+	stream, err := client.CreateChatCompletionRawStream(context.Background(),
+		json.RawMessage(`{"model": "openai/gpt-4o", "stream": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, _ := io.ReadAll(stream)
+	if err = stream.Close(); err != nil || !strings.HasSuffix(string(events), "data: [DONE]\n\n") {
+		t.Errorf("unexpected stream %q, %v", events, err)
+	}
+}
+
+func TestClient_StreamErrorReleasesClient(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"error":{"code":400,"message":"bad request"}}`)
+	})
+
+	var apiErr *APIError
+	if _, err := client.CreateChatCompletionStream(context.Background(), &ChatCompletionRequest{Model: Gpt4}); !errors.As(err, &apiErr) {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Close(ctx); err != nil {
+		t.Errorf("expected the failed stream to be released, got %v", err)
+	}
+}
+
+func TestClient_Decoding(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chat/completions" {
+			_, _ = io.WriteString(w, `{"model":"m","drift":1,`+
+				`"choices":[{"message":{"role":"assistant","content":"ok","bogus":true}}]}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"total_credits":12345678901234567890,"total_usage":1,"new_field":true}}`)
+	})
+	client.config.Retry.MaxRetries = -1
+
+	client.config.Decoding = DecodingConfig{UseNumber: true}
+	var out map[string]map[string]any
+	if err := client.Do(context.Background(), http.MethodGet, "/credits", nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := out["data"]["total_credits"].(json.Number); !ok || n.String() != "12345678901234567890" {
+		t.Errorf("expected a json.Number, got %#v", out["data"]["total_credits"])
+	}
+
+	client.config.Decoding = DecodingConfig{DisallowUnknownFields: true}
+	if _, err := client.GetCredits(context.Background()); err == nil || !strings.Contains(err.Error(), "new_field") {
+		t.Errorf("expected an unknown field error, got %v", err)
+	}
+	_, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	if err == nil || !strings.Contains(err.Error(), "choices[0].message.bogus, drift") {
+		t.Errorf("expected an unknown fields error, got %v", err)
+	}
+
+	client.config.Decoding = DecodingConfig{}
+	resp, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	if err != nil || string(resp.ExtraFields["drift"]) != "1" {
+		t.Errorf("expected the extra fields to be kept, got %v, %v", resp, err)
+	}
+}
+
+func TestClient_InsufficientCredits(t *testing.T) {
+	var completions, checks int
+	toppedUp := true
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/credits" {
+			checks++
+			if checks == 1 || !toppedUp {
+				_, _ = io.WriteString(w, `{"data":{"total_credits":10,"total_usage":10}}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"data":{"total_credits":20,"total_usage":10}}`)
+			return
+		}
+		completions++
+		if completions == 1 {
+			w.WriteHeader(http.StatusPaymentRequired)
+			_, _ = io.WriteString(w, `{"error":{"code":402,"message":"Insufficient credits"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"model":"m","choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	if !errors.Is(err, ErrInsufficientCredits) || completions != 1 {
+		t.Fatalf("expected a single failed request, got %v after %d requests", err, completions)
+	}
+
+	completions = 0
+	// without MaxWait, the wait is only bounded by the context
+	client.config.CreditsWait = &CreditsWaitPolicy{Interval: time.Millisecond}
+	resp, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Choices[0].Message.Content != "ok" || checks != 2 || completions != 2 {
+		t.Errorf("unexpected response %+v after %d checks", resp, checks)
+	}
+	completions, toppedUp = 0, false
+	client.config.CreditsWait = &CreditsWaitPolicy{Interval: time.Millisecond, MaxWait: 10 * time.Millisecond}
+	_, err = client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	if !errors.Is(err, ErrInsufficientCredits) || completions != 1 {
+		t.Errorf("expected the wait to expire, got %v after %d requests", err, completions)
+	}
+}
+
+func TestClient_NativeUsage(t *testing.T) {
+	var polls int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/generation" {
+			if polls++; polls == 1 {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = io.WriteString(w, `{"error":{"code":404,"message":"Generation not found"}}`)
+				return
+			}
+			if r.URL.Query().Get("id") != "gen-1" {
+				t.Errorf("unexpected generation %s", r.URL.RawQuery)
+			}
+			_, _ = io.WriteString(w, `{"data":{"id":"gen-1","tokens_prompt":10,"tokens_completion":5,`+
+				`"native_tokens_prompt":12,"native_tokens_completion":7,"native_tokens_reasoning":3}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"id":"gen-1","model":"m","choices":[{"message":{"role":"assistant","content":"ok"}}],`+
+			`"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`)
+	})
+	client.config.NativeUsage = &NativeUsagePolicy{PollInterval: time.Millisecond}
+
+	resp, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NativeUsage{PromptTokens: 12, CompletionTokens: 7, ReasoningTokens: 3}
+	if resp.Usage.PromptTokens != 10 || resp.Usage.Native == nil || *resp.Usage.Native != want || polls != 2 {
+		t.Errorf("unexpected usage %+v, native %+v after %d polls", resp.Usage, resp.Usage.Native, polls)
+	}
+}
+
+func TestClient_DeduplicateRequests(t *testing.T) {
+	var hits atomic.Int32
+	arrived, release := make(chan struct{}), make(chan struct{})
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			close(arrived)
+		}
+		<-release
+		_, _ = io.WriteString(w, `{"model":"m","choices":[{"message":{"role":"assistant","content":"ok"}}],`+
+			`"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2,"cost":0.5}}`)
+	})
+	client.config.DeduplicateRequests = true
+	client.config.SpendTracker = NewSpendTracker()
+
+	const callers = 3
+	responses := make([]*ChatCompletionResponse, callers)
+	var wg sync.WaitGroup
+	send := func(i int) {
+		defer wg.Done()
+		resp, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+		if err != nil {
+			t.Error(err)
+		}
+		responses[i] = resp
+	}
+	wg.Add(callers)
+	go send(0)
+	<-arrived
+	for i := 1; i < callers; i++ {
+		go send(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if spent := client.config.SpendTracker.Spent(""); hits.Load() != 1 || spent != 0.5 {
+		t.Errorf("expected one request and its spend, got %d requests and %v", hits.Load(), spent)
+	}
+	responses[0].Choices[0].Message.Content = "changed"
+	for _, resp := range responses[1:] {
+		if resp == responses[0] || resp.Choices[0].Message.Content != "ok" {
+			t.Errorf("expected a copy of the response, got %+v", resp)
+		}
+	}
+}
+
+func TestClient_DeduplicateRequestsPerTenant(t *testing.T) {
+	var mu sync.Mutex
+	keys := map[string]bool{}
+	both := make(chan struct{})
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys[r.Header.Get("Authorization")] = true
+		if len(keys) == 2 {
+			close(both)
+		}
+		mu.Unlock()
+		select {
+		case <-both:
+		case <-time.After(time.Second):
+		}
+		_, _ = io.WriteString(w, `{"model":"m","choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	})
+	client.config.DeduplicateRequests = true
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(tenant *Client) {
+			defer wg.Done()
+			if _, err := tenant.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4}); err != nil {
+				t.Error(err)
+			}
+		}(client.WithKey(key))
+	}
+	wg.Wait()
+
+	if !keys["Bearer a"] || !keys["Bearer b"] {
+		t.Errorf("expected a request per tenant, got %v", keys)
+	}
+}
+
+func TestClient_RequestUnchanged(t *testing.T) {
+	var body map[string]any
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/models" {
+			_, _ = io.WriteString(w, `{"data":[{"id":"anthropic/claude-3.5-sonnet",`+
+				`"supported_parameters":["max_tokens","temperature"]}]}`)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = io.WriteString(w, `{"model":"m","choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	})
+	client.config.DropUnsupportedParameters = true
+	client.config.NormalizeSystemPrompts = true
+	client.config.Privacy = PrivacyPolicy{DenyDataCollection: true}
+	client.config.Defaults = RequestDefaults{Metadata: map[string]string{"team": "search"}}
+
+	seed := 7
+	request := &ChatCompletionRequest{
+		Model: "anthropic/claude-3.5-sonnet",
+		Messages: []ChatCompletionMessage{
+			{Role: ChatMessageRoleSystem, Content: "be brief"},
+			{Role: ChatMessageRoleUser, Content: "hi"},
+			{Role: ChatMessageRoleDeveloper, Content: "answer in French"},
+		},
+		MaxCompletionTokens: 100,
+		Seed:                &seed,
+		Provider:            &ProviderPreferences{Order: []string{"anthropic"}},
+	}
+	ctx := WithUser(context.Background(), "user-1")
+	if _, err := client.CreateChatCompletion(ctx, request); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := body["seed"]; ok || body["max_tokens"] != float64(100) || body["user"] != "user-1" {
+		t.Errorf("unexpected body %v", body)
+	}
+	if request.Seed == nil || request.MaxCompletionTokens != 100 || request.MaxTokens != 0 || len(request.Messages) != 3 ||
+		request.Provider.DataCollection != "" || request.Metadata != nil || request.User != "" {
+		t.Errorf("the request of the caller was modified: %+v", request)
+	}
+}
+
+func TestClient_RetriesOnlyTransientErrors(t *testing.T) {
+	var hits int
+	status := http.StatusBadRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, `{"error":{"code":`+strconv.Itoa(status)+`,"message":"failed"}}`)
+	})
+	client.config.Retry = RetryConfig{MaxRetries: 2, InitialBackoff: time.Millisecond}
+
+	_, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusBadRequest || hits != 1 {
+		t.Errorf("expected a single attempt for a 400, got %d attempts and %v", hits, err)
+	}
+
+	hits, status = 0, http.StatusServiceUnavailable
+	_, err = client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	var multiErr *MultiAttemptError
+	if !errors.As(err, &multiErr) || hits != 3 {
+		t.Errorf("expected 3 attempts for a 503, got %d attempts and %v", hits, err)
+	}
+}
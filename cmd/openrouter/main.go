@@ -0,0 +1,167 @@
+// Command openrouter is a small command line client of the OpenRouter API,
+// built on this library:
+//
+//	openrouter chat -m <model> [prompt]   stream a reply to stdout, reading the prompt from stdin if absent
+//	openrouter models [-search <text>]    list the models of the catalog
+//	openrouter credits                    show the credits of the account
+//	openrouter generation <id>            show the metadata of a generation
+//
+// The API key is read from the OPENROUTER_API_KEY environment variable.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+
+	openrouter "github.com/dedlockdave/go-openrouter"
+)
+
+const usage = `usage: openrouter <command> [flags]
+
+commands:
+  chat -m <model> [prompt]   stream a reply, the prompt is read from stdin if absent
+  models [-search <text>]    list the models of the catalog
+  credits                    show the credits of the account
+  generation <id>            show the metadata of a generation
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, flag.Arg(0), flag.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "openrouter:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, command string, args []string) error {
+	key := os.Getenv("OPENROUTER_API_KEY")
+	if key == "" {
+		return errors.New("OPENROUTER_API_KEY is not set")
+	}
+	config, err := openrouter.DefaultConfig(key, "openrouter-cli", "")
+	if err != nil {
+		return err
+	}
+	if path, err := openrouter.DefaultModelsCachePath(); err == nil {
+		config.ModelsCachePath = path
+	}
+	client := openrouter.NewClientWithConfig(config)
+
+	switch command {
+	case "chat":
+		return chat(ctx, client, args)
+	case "models":
+		return models(ctx, client, args)
+	case "credits":
+		return credits(ctx, client)
+	case "generation":
+		return generation(ctx, client, args)
+	default:
+		flag.Usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func chat(ctx context.Context, client *openrouter.Client, args []string) error {
+	flags := flag.NewFlagSet("chat", flag.ExitOnError)
+	model := flags.String("m", "", "model ID, e.g. openai/gpt-4o")
+	system := flags.String("system", "", "system prompt")
+	_ = flags.Parse(args)
+	if *model == "" {
+		return errors.New("chat: -m is required")
+	}
+
+	prompt := strings.Join(flags.Args(), " ")
+	if prompt == "" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		prompt = string(data)
+	}
+
+	var messages []openrouter.ChatCompletionMessage
+	if *system != "" {
+		messages = append(messages, openrouter.ChatCompletionMessage{Role: openrouter.ChatMessageRoleSystem, Content: *system})
+	}
+	messages = append(messages, openrouter.ChatCompletionMessage{Role: openrouter.ChatMessageRoleUser, Content: prompt})
+
+	_, usage, err := client.StreamText(ctx, &openrouter.ChatCompletionRequest{
+		Model:    *model,
+		Messages: messages,
+		Usage:    &openrouter.UsageConfig{Include: true},
+	}, os.Stdout)
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+	if usage != nil {
+		fmt.Fprintf(os.Stderr, "tokens: %d prompt, %d completion, cost: %f\n",
+			usage.PromptTokens, usage.CompletionTokens, usage.Cost)
+	}
+	return nil
+}
+
+func models(ctx context.Context, client *openrouter.Client, args []string) error {
+	flags := flag.NewFlagSet("models", flag.ExitOnError)
+	search := flags.String("search", "", "only list the models whose ID or name contains this text")
+	_ = flags.Parse(args)
+
+	list, err := client.Models(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCONTEXT\tPROMPT\tCOMPLETION")
+	needle := strings.ToLower(*search)
+	for _, model := range list.Data {
+		if needle != "" &&
+			!strings.Contains(strings.ToLower(model.ID), needle) &&
+			!strings.Contains(strings.ToLower(model.Name), needle) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n",
+			model.ID, model.ContextLength, model.Pricing.Prompt, model.Pricing.Completion)
+	}
+	return w.Flush()
+}
+
+func credits(ctx context.Context, client *openrouter.Client) error {
+	credits, err := client.GetCredits(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("total: %.4f\nused: %.4f\nremaining: %.4f\n",
+		credits.TotalCredits, credits.TotalUsage, credits.Remaining())
+	return nil
+}
+
+func generation(ctx context.Context, client *openrouter.Client, args []string) error {
+	if len(args) != 1 {
+		return errors.New("generation: expected a generation ID")
+	}
+	generation, err := client.GetGeneration(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(generation)
+}
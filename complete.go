@@ -0,0 +1,67 @@
+package openrouter
+
+import "context"
+
+// DefaultCompleteModel is the model of Complete when neither an option, the
+// client defaults nor the fallback policy set one: the OpenRouter auto router.
+const DefaultCompleteModel = "openrouter/auto"
+
+// CompleteOption customizes the request of Complete.
+type CompleteOption func(request *ChatCompletionRequest)
+
+// CompleteModel sets the model, e.g. "openai/gpt-4o".
+func CompleteModel(model string) CompleteOption {
+	return func(request *ChatCompletionRequest) { request.Model = model }
+}
+
+// CompleteSystem prepends a system prompt.
+func CompleteSystem(prompt string) CompleteOption {
+	return func(request *ChatCompletionRequest) {
+		system := ChatCompletionMessage{Role: ChatMessageRoleSystem, Content: prompt}
+		request.Messages = append([]ChatCompletionMessage{system}, request.Messages...)
+	}
+}
+
+// CompleteMaxTokens bounds the length of the answer.
+func CompleteMaxTokens(maxTokens int) CompleteOption {
+	return func(request *ChatCompletionRequest) { request.MaxTokens = maxTokens }
+}
+
+// CompleteTemperature sets the sampling temperature.
+func CompleteTemperature(temperature float32) CompleteOption {
+	return func(request *ChatCompletionRequest) { request.Temperature = &temperature }
+}
+
+// CompleteRequest gives access to the whole request, for the settings without
+// a dedicated option.
+func CompleteRequest(update func(request *ChatCompletionRequest)) CompleteOption {
+	return CompleteOption(update)
+}
+
+// Complete sends prompt as a user message and returns the text of the answer
+// with its usage, the cost included. It goes through the fallback chain of the
+// config, see CreateChatCompletionWithFallback, and the retries of the client.
+func (c *Client) Complete(ctx context.Context, prompt string, options ...CompleteOption) (string, Usage, error) {
+	request := &ChatCompletionRequest{
+		Messages: []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: prompt}},
+		Usage:    &UsageConfig{Include: true},
+	}
+	for _, option := range options {
+		option(request)
+	}
+	policy := c.config.FallbackPolicy
+	if request.Model == "" && c.config.Defaults.Model == "" && (policy == nil || len(policy.Models) == 0) {
+		request.Model = DefaultCompleteModel
+	}
+
+	response, _, err := c.CreateChatCompletionWithFallback(ctx, request)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	var usage Usage
+	usage.Add(response.Usage)
+	if len(response.Choices) == 0 {
+		return "", usage, ErrEmptyChoices
+	}
+	return response.Choices[0].Message.Content, usage, nil
+}
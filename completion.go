@@ -0,0 +1,114 @@
+package openrouter
+
+import (
+	"context"
+	"net/http"
+)
+
+// CompletionRequest represents a request structure for the prompt based completions API.
+type CompletionRequest struct {
+	Model             string               `json:"model"`
+	Prompt            string               `json:"prompt"`
+	MaxTokens         int                  `json:"max_tokens,omitempty"`
+	Stream            bool                 `json:"stream,omitempty"`
+	Temperature       *float32             `json:"temperature,omitempty"`
+	TopP              *float32             `json:"top_p,omitempty"`
+	TopK              *uint                `json:"top_k,omitempty"`
+	MinP              *float32             `json:"min_p,omitempty"`
+	TopA              *float32             `json:"top_a,omitempty"`
+	FrequencyPenalty  *float32             `json:"frequency_penalty,omitempty"`
+	PresencePenalty   *float32             `json:"presence_penalty,omitempty"`
+	RepetitionPenalty *float32             `json:"repetition_penalty,omitempty"`
+	Seed              *int                 `json:"seed,omitempty"`
+	LogitBias         map[string]int       `json:"logit_bias,omitempty"`
+	Stop              StringOrSlice        `json:"stop,omitempty"`
+	Provider          *ProviderPreferences `json:"provider,omitempty"`
+}
+
+type CompletionChoice struct {
+	Text         string       `json:"text"`
+	Index        uint         `json:"index,omitempty"`
+	FinishReason FinishReason `json:"finish_reason,omitempty"`
+	// NativeFinishReason is the finish reason as reported by the provider.
+	NativeFinishReason string `json:"native_finish_reason,omitempty"`
+}
+
+// CompletionResponse represents a response structure for the completions API.
+type CompletionResponse struct {
+	ID       string             `json:"id,omitempty"`
+	Object   string             `json:"object,omitempty"`
+	Created  int64              `json:"created,omitempty"`
+	Model    string             `json:"model"`
+	Provider string             `json:"provider,omitempty"`
+	Choices  []CompletionChoice `json:"choices"`
+	Usage    *Usage             `json:"usage,omitempty"`
+	// Warning is set when the API reported an error along with the choices.
+	Warning *APIWarning `json:"-"`
+}
+
+type CompletionStream struct {
+	*streamReader[CompletionResponse]
+}
+
+// CreateCompletion — API call to create a completion for the prompt.
+func (c *Client) CreateCompletion(
+	ctx context.Context,
+	request *CompletionRequest,
+) (response *CompletionResponse, err error) {
+	if request.Stream {
+		return nil, ErrCompletionStreamNotSupported
+	}
+
+	urlSuffix := "/completions"
+	if !checkSupportsModel(request.Model) {
+		return nil, ErrCompletionUnsupportedModel
+	}
+	request = c.prepareCompletionRequest(request)
+
+	req, err := c.requestBuilder.Build(ctx, http.MethodPost, c.fullURL(urlSuffix), request)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.sendRequest(req, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response, err
+}
+
+// prepareCompletionRequest returns a copy of request with the privacy policy
+// enforced, leaving the caller's request untouched.
+func (c *Client) prepareCompletionRequest(request *CompletionRequest) *CompletionRequest {
+	prepared := *request
+	prepared.Provider = c.config.Privacy.apply(request.Provider)
+	return &prepared
+}
+
+// CreateCompletionStream — API call to create a completion for the prompt w/ streaming
+// support. The stream is terminated by a data: [DONE] message.
+func (c *Client) CreateCompletionStream(
+	ctx context.Context,
+	request *CompletionRequest,
+) (stream *CompletionStream, err error) {
+	urlSuffix := "/completions"
+	if !checkSupportsModel(request.Model) {
+		err = ErrCompletionUnsupportedModel
+		return
+	}
+	request = c.prepareCompletionRequest(request)
+	request.Stream = true
+	req, err := c.newStreamRequest(ctx, "POST", urlSuffix, request)
+	if err != nil {
+		return
+	}
+
+	resp, err := sendRequestStream[CompletionResponse](c, req)
+	if err != nil {
+		return
+	}
+	stream = &CompletionStream{
+		streamReader: resp,
+	}
+	return
+}
@@ -0,0 +1,92 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestClient_CreateCompletion(t *testing.T) {
+	var (
+		path    string
+		request map[string]any
+	)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		_, _ = io.WriteString(w, `{"id":"cmpl-1","model":"m","choices":[{"text":" world","finish_reason":"stop"}]}`)
+	})
+
+	client.config.Privacy = PrivacyPolicy{DenyDataCollection: true}
+
+	caller := &CompletionRequest{
+		Model:  Gpt4,
+		Prompt: "hello",
+		Stop:   StringOrSlice{"\n"},
+	}
+	resp, err := client.CreateCompletion(context.Background(), caller)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, _ := request["provider"].(map[string]any)
+	if path != "/completions" || request["prompt"] != "hello" || request["stop"] != "\n" ||
+		provider["data_collection"] != DataCollectionDeny {
+		t.Errorf("unexpected request %s %v", path, request)
+	}
+	if caller.Provider != nil {
+		t.Errorf("the request of the caller was modified: %+v", caller)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Text != " world" || resp.Choices[0].FinishReason != FinishReasonStop {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	_, err = client.CreateCompletion(context.Background(), &CompletionRequest{Model: Gpt4, Stream: true})
+	if !errors.Is(err, ErrCompletionStreamNotSupported) {
+		t.Errorf("expected ErrCompletionStreamNotSupported, got %v", err)
+	}
+}
+
+func TestClient_CreateCompletionStream(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var request CompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if !request.Stream || r.URL.Path != "/completions" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"choices\":[{\"text\":\"hel\"}]}\n\n"+
+			"data: {\"choices\":[{\"text\":\"lo\",\"finish_reason\":\"length\"}]}\n\n"+
+			"data: [DONE]\n\n")
+	})
+
+	client.config.Privacy = PrivacyPolicy{RequireZDR: true}
+
+	caller := &CompletionRequest{Model: Gpt4, Prompt: "say"}
+	stream, err := client.CreateCompletionStream(context.Background(), caller)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	if caller.Stream || caller.Provider != nil {
+		t.Errorf("the request of the caller was modified: %+v", caller)
+	}
+
+	var text string
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		text += chunk.Choices[0].Text
+	}
+	if text != "hello" {
+		t.Errorf("got %q, want %q", text, "hello")
+	}
+}
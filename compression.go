@@ -0,0 +1,73 @@
+package openrouter
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// setAcceptEncoding leaves the compression to the transport, which asks for
+// gzip and decompresses transparently, unless compression is disabled: the
+// identity encoding then keeps any transport from asking for gzip.
+func (c *Client) setAcceptEncoding(req *http.Request) {
+	if c.config.DisableCompression {
+		req.Header.Set("Accept-Encoding", "identity")
+	}
+}
+
+// decompressBody replaces the body of a compressed response by its decompressed
+// content. Bodies the transport decompressed have no Content-Encoding left; it
+// handles those compressed anyway, e.g. deflate from a proxy or gzip through a
+// transport with compression disabled.
+func decompressBody(res *http.Response) error {
+	var (
+		reader io.ReadCloser
+		err    error
+	)
+	switch strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding"))) {
+	case "gzip":
+		reader, err = gzip.NewReader(res.Body)
+	case "deflate":
+		reader, err = newDeflateReader(res.Body)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	res.Body = &decompressedBody{ReadCloser: reader, raw: res.Body}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+	return nil
+}
+
+// newDeflateReader reads "deflate" bodies, which servers send either zlib
+// wrapped as specified or as raw deflate data.
+func newDeflateReader(body io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(body)
+	header, err := buffered.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+type decompressedBody struct {
+	io.ReadCloser
+	raw io.ReadCloser
+}
+
+func (b *decompressedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if rawErr := b.raw.Close(); err == nil {
+		err = rawErr
+	}
+	return err
+}
@@ -1,7 +1,10 @@
 package openrouter
 
 import (
+	"log"
 	"net/http"
+
+	utils "github.com/dedlockdave/go-openrouter/internal"
 )
 
 const (
@@ -18,6 +21,121 @@ type ClientConfig struct {
 	BaseURL            string
 	HTTPClient         *http.Client
 	EmptyMessagesLimit uint
+	// SpendTracker, if set, accumulates the cost of chat completions and enforces budgets.
+	SpendTracker *SpendTracker
+	// ResponseCache, if set, serves repeated chat completion requests from a cache.
+	ResponseCache *ResponseCache
+	// DeduplicateRequests collapses concurrent identical chat completion requests
+	// of the same credentials and spend key into a single API call.
+	DeduplicateRequests bool
+	// CircuitBreaker, if set, fails fast or falls back for models that keep failing.
+	CircuitBreaker *CircuitBreaker
+	// FallbackPolicy is the model chain used by CreateChatCompletionWithFallback.
+	FallbackPolicy *FallbackPolicy
+	// Debug dumps every request and response, with credentials redacted, to
+	// Logger. Use WithDebug to enable it for a single request.
+	Debug bool
+	// Logger defaults to log.Default().
+	Logger *log.Logger
+	// Privacy is enforced on the provider preferences of every request.
+	Privacy PrivacyPolicy
+	Retry   RetryConfig
+	// OnRetry, if set, is called after each failed attempt of a non-streaming
+	// request, e.g. to emit retry metrics.
+	OnRetry func(event RetryEvent)
+	// DisableCompression asks for uncompressed responses, e.g. when a
+	// compressing proxy sits in front of the API. Otherwise the transport asks for
+	// gzip and decompresses the responses transparently.
+	DisableCompression bool
+	// ValidateRequests checks chat completion requests before sending them, see
+	// Client.ValidateRequest.
+	ValidateRequests bool
+	// DropUnsupportedParameters strips from chat completion requests the
+	// parameters the model doesn't support, according to the models catalog.
+	DropUnsupportedParameters bool
+	// OnDroppedParameters is notified of the stripped parameters, they are
+	// logged when nil.
+	OnDroppedParameters func(model string, dropped []string)
+	// WarnUnsupportedCapabilities logs the features used by chat completion
+	// requests that the model doesn't support, see CapabilityWarnings.
+	WarnUnsupportedCapabilities bool
+	// KeyPool, if set, replaces the API key of the config by a pool of keys.
+	KeyPool *KeyPool
+	// AuthProvider, if set, supplies the API key of each request in place of
+	// the key and the key pool of the config.
+	AuthProvider AuthProvider
+	// Headers are added to every request, they override the headers set by the
+	// client.
+	Headers http.Header
+	// UserAgent identifies the application, e.g. "my-app/1.2". The library
+	// version is appended to it.
+	UserAgent string
+	// Defaults fill the zero fields of chat completion requests.
+	Defaults RequestDefaults
+	// Continuation, if set, continues chat completions truncated by the token
+	// limit, see ContinuationPolicy.
+	Continuation *ContinuationPolicy
+	// Throttle delays requests when the rate limit is nearly exhausted, see
+	// Client.RateLimitState.
+	Throttle ThrottleConfig
+	// NormalizeSystemPrompts adapts the system messages of chat completion
+	// requests to the model family, see NormalizeSystemMessages.
+	NormalizeSystemPrompts bool
+	// UserIDHasher, if set, transforms the end-user IDs, set with WithUser or on
+	// the requests, before they are sent, see HMACUserID.
+	UserIDHasher UserIDHasher
+	// Sanitizer, if set, rewrites the messages of chat completion requests
+	// before they are sent. The placeholders are restored in non-streaming
+	// responses only.
+	Sanitizer RequestSanitizer
+	// ModelsCachePath, if set, persists the models catalog to this file, so
+	// short-lived processes share it. It is revalidated with its ETag once an
+	// hour old, see DefaultModelsCachePath.
+	ModelsCachePath string
+	// MaxCostPerRequest, if > 0, refuses the chat completion requests whose
+	// estimated maximum cost, from the models catalog pricing and the token
+	// limit, exceeds it, see CostCapExceededError. Variants are priced as their
+	// base model; the requests to models missing from the catalog, e.g.
+	// "openrouter/auto", are refused with ErrCostUnknown.
+	MaxCostPerRequest float64
+	// Shared, if set, replaces the HTTP client, the rate limit state and the
+	// models catalog of the client by the ones of the shared state.
+	Shared *SharedState
+	// Decoding configures the decoding of the responses.
+	Decoding DecodingConfig
+	// CreditsWait, if set, makes the requests failing for insufficient credits
+	// wait for new credits, see CreditsWaitPolicy.
+	CreditsWait *CreditsWaitPolicy
+	// NativeUsage, if set, makes CreateChatCompletion set the native token
+	// counts of the usage of its responses, see NativeUsagePolicy.
+	NativeUsage *NativeUsagePolicy
+	// RequestTransformers adapt the chat completion requests to the quirks of
+	// their model family, keyed by family, see DefaultRequestTransformers.
+	RequestTransformers map[string]RequestTransformer
+
+	// ownedHTTPClient is the HTTP client built by WithTransportConfig, which
+	// the client closes the idle connections of on Close.
+	ownedHTTPClient *http.Client
+}
+
+// DecodingConfig configures the JSON decoding of the responses.
+type DecodingConfig struct {
+	// DisallowUnknownFields fails the responses carrying fields the response
+	// types don't have, e.g. to catch API schema drift in tests. It covers the
+	// fields chat completion responses otherwise keep in ExtraFields.
+	DisallowUnknownFields bool
+	// UseNumber decodes the untyped numbers (maps, any fields) as json.Number
+	// rather than float64, preserving their precision. Typed fields keep their
+	// Go type.
+	UseNumber bool
+}
+
+func (d DecodingConfig) unmarshaler() utils.Unmarshaler {
+	unmarshaler := &utils.JSONUnmarshaler{DisallowUnknownFields: d.DisallowUnknownFields, UseNumber: d.UseNumber}
+	if d.DisallowUnknownFields {
+		return strictUnmarshaler{unmarshaler}
+	}
+	return unmarshaler
 }
 
 func DefaultConfig(auth, xTitle, httpReferer string) (ClientConfig, error) {
@@ -35,3 +153,28 @@ func (c ClientConfig) WithHttpClientConfig(client *http.Client) ClientConfig {
 	c.HTTPClient = client
 	return c
 }
+
+// WithTransportConfig replaces the HTTP client by one using a transport built
+// from tc. The client created with the config owns it: Client.Close closes its
+// idle connections.
+func (c ClientConfig) WithTransportConfig(tc TransportConfig) ClientConfig {
+	c.HTTPClient = &http.Client{Transport: tc.NewTransport()}
+	c.ownedHTTPClient = c.HTTPClient
+	return c
+}
+
+// WithUserAgent sets the application user agent, see ClientConfig.UserAgent.
+func (c ClientConfig) WithUserAgent(userAgent string) ClientConfig {
+	c.UserAgent = userAgent
+	return c
+}
+
+// WithHeader adds a header sent with every request.
+func (c ClientConfig) WithHeader(name, value string) ClientConfig {
+	c.Headers = c.Headers.Clone()
+	if c.Headers == nil {
+		c.Headers = make(http.Header)
+	}
+	c.Headers.Add(name, value)
+	return c
+}
@@ -0,0 +1,89 @@
+package openrouter
+
+import (
+	"context"
+	"time"
+)
+
+const defaultMaxContinuations = 3
+
+// ContinuationPolicy makes CreateChatCompletion continue responses truncated by
+// the token limit: the truncated output is sent back as an assistant prefill
+// and the parts are stitched into a single response.
+type ContinuationPolicy struct {
+	// MaxContinuations bounds the follow-up requests, defaults to 3.
+	MaxContinuations int
+	// MaxTotalTokens bounds the completion tokens of all the parts, zero means
+	// no bound.
+	MaxTotalTokens int
+	// MaxTotalCost bounds the cost of all the parts in credits, zero means no
+	// bound. It requires usage accounting, see UsageConfig.
+	MaxTotalCost float64
+}
+
+// continueTruncated issues follow-up requests while the first choice of
+// response is truncated, and returns the stitched response.
+func (c *Client) continueTruncated(
+	ctx context.Context,
+	request *ChatCompletionRequest,
+	response *ChatCompletionResponse,
+) (*ChatCompletionResponse, error) {
+	policy := c.config.Continuation
+	maxContinuations := policy.MaxContinuations
+	if maxContinuations <= 0 {
+		maxContinuations = defaultMaxContinuations
+	}
+	// follow-ups go through a client without continuation, they are driven here
+	client := c.WithConfig(func(config *ClientConfig) { config.Continuation = nil })
+
+	stitched := *response
+	stitched.Choices = append([]ChatCompletionChoice(nil), response.Choices...)
+	usage := Usage{}
+	usage.Add(response.Usage)
+
+	for i := 0; i < maxContinuations; i++ {
+		choice := &stitched.Choices[0]
+		if !choice.FinishReason.IsTruncated() || len(choice.Message.ToolCalls) > 0 {
+			break
+		}
+		if policy.MaxTotalCost > 0 && usage.Cost >= policy.MaxTotalCost {
+			break
+		}
+		followUp := *request
+		if policy.MaxTotalTokens > 0 {
+			remaining := policy.MaxTotalTokens - usage.CompletionTokens
+			if remaining <= 0 {
+				break
+			}
+			if limit := followUp.maxOutputTokens(); limit == 0 || limit > remaining {
+				followUp.MaxTokens, followUp.MaxCompletionTokens = remaining, 0
+			}
+		}
+		followUp.Messages = append(append([]ChatCompletionMessage(nil), request.Messages...), ChatCompletionMessage{
+			Role:    ChatMessageRoleAssistant,
+			Content: choice.Message.Content,
+		})
+
+		part, err := client.CreateChatCompletion(ctx, &followUp)
+		if err != nil {
+			return nil, err
+		}
+		if len(part.Choices) == 0 {
+			break
+		}
+		usage.Add(part.Usage)
+		next := part.Choices[0]
+		choice.Message.Content += next.Message.Content
+		choice.Message.ToolCalls = next.Message.ToolCalls
+		choice.FinishReason = next.FinishReason
+		choice.NativeFinishReason = next.NativeFinishReason
+	}
+
+	if response.Usage != nil {
+		stitched.Usage = &usage
+	}
+	if response.Timings != nil {
+		stitched.Timings = &Timings{Start: response.Timings.Start, Duration: time.Since(response.Timings.Start)}
+	}
+	return &stitched, nil
+}
@@ -0,0 +1,187 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrEmptyChoices = errors.New("response has no choices")
+)
+
+// ConversationSnapshot is a copy of a conversation state, as handed to a ConversationStore.
+type ConversationSnapshot struct {
+	ID           string                  `json:"id"`
+	SystemPrompt string                  `json:"system_prompt,omitempty"`
+	Messages     []ChatCompletionMessage `json:"messages"`
+	// Request is the request template of the conversation, without messages.
+	Request ChatCompletionRequest `json:"request"`
+}
+
+// ConversationStore persists conversations, e.g. in a database.
+type ConversationStore interface {
+	Save(ctx context.Context, snapshot ConversationSnapshot) error
+	Load(ctx context.Context, id string) (ConversationSnapshot, error)
+}
+
+// Conversation owns the message history of a chat. The system prompt is pinned
+// as the first message of every request, replies are appended automatically and
+// the state is saved to Store (if any) after each exchange. It is safe for
+// concurrent use: the exchanges of Send are serialized, while the other methods
+// don't wait for the model.
+type Conversation struct {
+	ID string
+	// Request is the template of every request (model, sampling parameters...),
+	// its Messages are replaced by the conversation history.
+	Request ChatCompletionRequest
+	Store   ConversationStore
+	// Usage, if set, records the usage of every exchange.
+	Usage *UsageAggregator
+
+	client ChatClient
+	// sendMu serializes the exchanges, mu guards the state.
+	sendMu       sync.Mutex
+	mu           sync.Mutex
+	systemPrompt string
+	messages     []ChatCompletionMessage
+}
+
+func NewConversation(client ChatClient, model string) *Conversation {
+	return &Conversation{
+		client:  client,
+		Request: ChatCompletionRequest{Model: model},
+	}
+}
+
+// LoadConversation restores a conversation from the store.
+func LoadConversation(
+	ctx context.Context,
+	client ChatClient,
+	store ConversationStore,
+	id string,
+) (*Conversation, error) {
+	snapshot, err := store.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	conv := &Conversation{ID: id, Store: store, client: client}
+	conv.Restore(snapshot)
+	return conv, nil
+}
+
+// SetSystemPrompt pins the system prompt, an empty prompt removes it.
+func (c *Conversation) SetSystemPrompt(prompt string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.systemPrompt = prompt
+}
+
+// Append adds messages to the history without calling the model.
+func (c *Conversation) Append(messages ...ChatCompletionMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, messages...)
+}
+
+// Send calls the model with a user message and appends both the message and
+// the reply, tool calls and reasoning included, to the history. On failure the history is
+// left unchanged. The messages appended while the model answers precede the
+// exchange.
+func (c *Conversation) Send(ctx context.Context, content string) (*ChatCompletionResponse, error) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	message := ChatCompletionMessage{Role: ChatMessageRoleUser, Content: content}
+	c.mu.Lock()
+	request := c.Request
+	request.Messages = append(c.requestMessages(), message)
+	c.mu.Unlock()
+
+	resp, err := c.complete(ctx, &request)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.messages = append(c.messages, message, resp.reply())
+	snapshot := c.snapshot()
+	c.mu.Unlock()
+
+	if c.Store != nil {
+		if err = c.Store.Save(ctx, snapshot); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
+
+// Messages returns a copy of the messages sent to the model, system prompt included.
+func (c *Conversation) Messages() []ChatCompletionMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requestMessages()
+}
+
+// Snapshot returns a copy of the conversation state.
+func (c *Conversation) Snapshot() ConversationSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.snapshot()
+}
+
+// Restore replaces the conversation state, request template included, with the
+// snapshot.
+func (c *Conversation) Restore(snapshot ConversationSnapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.systemPrompt = snapshot.SystemPrompt
+	c.messages = append([]ChatCompletionMessage(nil), snapshot.Messages...)
+	c.Request = snapshot.Request
+}
+
+func (c *Conversation) complete(ctx context.Context, request *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	resp, err := c.client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if c.Usage != nil {
+		c.Usage.Record(resp.Model, resp.Usage)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, ErrEmptyChoices
+	}
+	return resp, nil
+}
+
+// reply returns the message of the first choice as an assistant message of the
+// history.
+func (r *ChatCompletionResponse) reply() ChatCompletionMessage {
+	reply := r.Choices[0].Message
+	return ChatCompletionMessage{
+		Role:        ChatMessageRoleAssistant,
+		Content:     reply.Content,
+		ToolCalls:   reply.ToolCalls,
+		Annotations: reply.Annotations,
+		Reasoning:   reply.Reasoning,
+	}
+}
+
+func (c *Conversation) requestMessages() []ChatCompletionMessage {
+	messages := make([]ChatCompletionMessage, 0, len(c.messages)+1)
+	if c.systemPrompt != "" {
+		messages = append(messages, ChatCompletionMessage{Role: ChatMessageRoleSystem, Content: c.systemPrompt})
+	}
+	return append(messages, c.messages...)
+}
+
+func (c *Conversation) snapshot() ConversationSnapshot {
+	request := c.Request
+	request.Messages = nil
+	return ConversationSnapshot{
+		ID:           c.ID,
+		SystemPrompt: c.systemPrompt,
+		Messages:     append([]ChatCompletionMessage(nil), c.messages...),
+		Request:      request,
+	}
+}
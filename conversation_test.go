@@ -0,0 +1,172 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestConversation_Send(t *testing.T) {
+	fake := &FakeClient{
+		Responses: []*ChatCompletionResponse{{
+			Choices: []ChatCompletionChoice{{Message: Index{Role: ChatMessageRoleAssistant, Content: "hi"}}},
+		}},
+		Errors: []error{nil, errors.New("boom")},
+	}
+	conv := NewConversation(fake, Gpt4)
+	conv.SetSystemPrompt("be brief")
+
+	if _, err := conv.Send(context.Background(), "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conv.Send(context.Background(), "again"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	messages := conv.Messages()
+	if len(messages) != 3 {
+		t.Fatalf("got %d messages, want 3: %#v", len(messages), messages)
+	}
+	if messages[0].Role != ChatMessageRoleSystem || messages[2].Content != "hi" {
+		t.Errorf("unexpected history %#v", messages)
+	}
+	if got := fake.Calls[0].Request.Messages; len(got) != 2 || got[0].Content != "be brief" {
+		t.Errorf("unexpected request messages %#v", got)
+	}
+}
+
+func TestConversation_SendToolCalls(t *testing.T) {
+	fake := &FakeClient{Responses: []*ChatCompletionResponse{{
+		Choices: []ChatCompletionChoice{{Message: Index{
+			Role:      ChatMessageRoleAssistant,
+			Reasoning: "the weather needs a lookup",
+			ToolCalls: []ToolCall{{ID: "call_1", Type: ToolTypeFunction, Function: FunctionCall{Name: "weather"}}},
+		}}},
+	}}}
+	conv := NewConversation(fake, Gpt4)
+
+	if _, err := conv.Send(context.Background(), "weather?"); err != nil {
+		t.Fatal(err)
+	}
+	reply := conv.Messages()[1]
+	if len(reply.ToolCalls) != 1 || reply.ToolCalls[0].ID != "call_1" || reply.Reasoning != "the weather needs a lookup" {
+		t.Errorf("expected the tool calls and reasoning in the history, got %#v", reply)
+	}
+}
+
+// blockingClient answers once release is closed, signaling on called.
+type blockingClient struct {
+	FakeClient
+	called, release chan struct{}
+}
+
+func (b *blockingClient) CreateChatCompletion(
+	ctx context.Context,
+	request *ChatCompletionRequest,
+) (*ChatCompletionResponse, error) {
+	close(b.called)
+	<-b.release
+	return b.FakeClient.CreateChatCompletion(ctx, request)
+}
+
+func TestConversation_SendUnlocked(t *testing.T) {
+	client := &blockingClient{
+		FakeClient: FakeClient{Responses: []*ChatCompletionResponse{{
+			Choices: []ChatCompletionChoice{{Message: Index{Role: ChatMessageRoleAssistant, Content: "hi"}}},
+		}}},
+		called:  make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	conv := NewConversation(client, Gpt4)
+
+	done := make(chan error)
+	go func() {
+		_, err := conv.Send(context.Background(), "hello")
+		done <- err
+	}()
+	<-client.called
+	conv.Append(ChatCompletionMessage{Role: ChatMessageRoleUser, Content: "meanwhile"})
+	if messages := conv.Messages(); len(messages) != 1 {
+		t.Errorf("expected the history to be readable while the model answers, got %#v", messages)
+	}
+	close(client.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if messages := conv.Messages(); len(messages) != 3 || messages[0].Content != "meanwhile" || messages[2].Content != "hi" {
+		t.Errorf("unexpected history %#v", messages)
+	}
+}
+
+func TestConversation_Usage(t *testing.T) {
+	fake := &FakeClient{Responses: []*ChatCompletionResponse{{
+		Model:   Gpt4,
+		Choices: []ChatCompletionChoice{{Message: Index{Role: ChatMessageRoleAssistant, Content: "hi"}}},
+		Usage: &Usage{
+			PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, Cost: 0.021,
+			PromptTokensDetails:     &PromptTokensDetails{CachedTokens: 4},
+			CompletionTokensDetails: &CompletionTokensDetails{ReasoningTokens: 3},
+		},
+	}}}
+	conv := NewConversation(fake, Gpt4)
+	conv.Usage = &UsageAggregator{}
+
+	for i := 0; i < 2; i++ {
+		if _, err := conv.Send(context.Background(), "hello"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	total := conv.Usage.Total()
+	if total.TotalTokens != 30 || total.Cost != 0.042 || total.PromptTokensDetails.CachedTokens != 8 ||
+		total.CompletionTokensDetails.ReasoningTokens != 6 {
+		t.Errorf("unexpected total %+v", total)
+	}
+	if records := conv.Usage.Records(); len(records) != 2 || records[1].Model != Gpt4 || records[1].Usage.TotalTokens != 15 {
+		t.Errorf("unexpected records %+v", records)
+	}
+}
+
+type jsonConversationStore map[string][]byte
+
+func (s jsonConversationStore) Save(_ context.Context, snapshot ConversationSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	s[snapshot.ID] = data
+	return err
+}
+
+func (s jsonConversationStore) Load(_ context.Context, id string) (snapshot ConversationSnapshot, err error) {
+	err = json.Unmarshal(s[id], &snapshot)
+	return snapshot, err
+}
+
+func TestConversation_Store(t *testing.T) {
+	fake := &FakeClient{Responses: []*ChatCompletionResponse{{
+		Choices: []ChatCompletionChoice{{Message: Index{Role: ChatMessageRoleAssistant, Content: "hi"}}},
+	}}}
+	store := jsonConversationStore{}
+	conv := NewConversation(fake, Gpt4)
+	conv.ID, conv.Store = "c1", store
+	temperature := float32(0.5)
+	conv.Request.Temperature = &temperature
+	conv.Request.MaxTokens = 64
+	conv.SetSystemPrompt("be brief")
+	if _, err := conv.Send(context.Background(), "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := LoadConversation(context.Background(), fake, store, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Request.Model != Gpt4 || restored.Request.Temperature == nil ||
+		*restored.Request.Temperature != 0.5 || restored.Request.MaxTokens != 64 {
+		t.Errorf("unexpected restored request %+v", restored.Request)
+	}
+	if restored.Request.Messages != nil {
+		t.Errorf("expected the template to have no messages, got %#v", restored.Request.Messages)
+	}
+	if messages := restored.Messages(); len(messages) != 3 || messages[0].Content != "be brief" || messages[2].Content != "hi" {
+		t.Errorf("unexpected restored history %#v", messages)
+	}
+}
@@ -0,0 +1,152 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// costEstimateMargin is the relative uncertainty applied to estimated prompt tokens.
+const costEstimateMargin = 0.25
+
+var (
+	ErrCostCapExceeded = errors.New("request cost cap exceeded")
+	ErrCostUnknown     = errors.New("request cost unknown")
+)
+
+// CostCapExceededError is returned when the estimated maximum cost of a request
+// exceeds ClientConfig.MaxCostPerRequest. It matches ErrCostCapExceeded with
+// errors.Is.
+type CostCapExceededError struct {
+	Cap      float64
+	Estimate CostEstimate
+}
+
+func (e *CostCapExceededError) Error() string {
+	return fmt.Sprintf("%s, model: %s, cap: %f, estimated: %f",
+		ErrCostCapExceeded, e.Estimate.Model, e.Cap, e.Estimate.MaxTotalCost())
+}
+
+func (e *CostCapExceededError) Is(target error) bool {
+	return target == ErrCostCapExceeded
+}
+
+// CostEstimate is the pre-flight cost of a request in USD. The prompt cost is a
+// range since prompt tokens are estimated, the completion cost is the worst case
+// of generating MaxCompletionTokens tokens.
+type CostEstimate struct {
+	Model               string
+	PromptTokens        int
+	MaxCompletionTokens int
+	MinPromptCost       float64
+	MaxPromptCost       float64
+	MaxCompletionCost   float64
+	RequestCost         float64
+}
+
+// MaxTotalCost is the upper bound of the request cost.
+func (e CostEstimate) MaxTotalCost() float64 {
+	return e.MaxPromptCost + e.MaxCompletionCost + e.RequestCost
+}
+
+// PromptPrice returns the USD price of a prompt token.
+func (p ModelPricing) PromptPrice() (float64, error) {
+	return parsePrice(p.Prompt)
+}
+
+// CompletionPrice returns the USD price of a completion token.
+func (p ModelPricing) CompletionPrice() (float64, error) {
+	return parsePrice(p.Completion)
+}
+
+// RequestPrice returns the fixed USD price per request.
+func (p ModelPricing) RequestPrice() (float64, error) {
+	return parsePrice(p.Request)
+}
+
+func parsePrice(price string) (float64, error) {
+	if price == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseFloat(price, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid price %q: %w", price, err)
+	}
+	return value, nil
+}
+
+// EstimateCost estimates the cost of the request from the model pricing of the
+// catalog. A nil estimator uses DefaultTokenEstimator.
+func (c *Client) EstimateCost(
+	ctx context.Context,
+	request *ChatCompletionRequest,
+	estimator *TokenEstimator,
+) (CostEstimate, error) {
+	model, err := c.GetModel(ctx, request.Model)
+	if err != nil {
+		return CostEstimate{}, err
+	}
+	return EstimateModelCost(model, request, estimator)
+}
+
+// EstimateModelCost estimates the cost of the request for the given model.
+func EstimateModelCost(model Model, request *ChatCompletionRequest, estimator *TokenEstimator) (CostEstimate, error) {
+	if estimator == nil {
+		estimator = DefaultTokenEstimator
+	}
+	promptPrice, err := model.Pricing.PromptPrice()
+	if err != nil {
+		return CostEstimate{}, err
+	}
+	completionPrice, err := model.Pricing.CompletionPrice()
+	if err != nil {
+		return CostEstimate{}, err
+	}
+	requestPrice, err := model.Pricing.RequestPrice()
+	if err != nil {
+		return CostEstimate{}, err
+	}
+
+	promptTokens := estimator.EstimateModelMessages(request.Model, model.Architecture.Tokenizer, request.Messages)
+	maxCompletionTokens := request.maxOutputTokens()
+	if maxCompletionTokens == 0 {
+		maxCompletionTokens = model.TopProvider.MaxCompletionTokens
+	}
+	if maxCompletionTokens == 0 {
+		maxCompletionTokens = max(model.ContextLength-promptTokens, 0)
+	}
+
+	return CostEstimate{
+		Model:               model.ID,
+		PromptTokens:        promptTokens,
+		MaxCompletionTokens: maxCompletionTokens,
+		MinPromptCost:       math.Floor(float64(promptTokens)*(1-costEstimateMargin)) * promptPrice,
+		MaxPromptCost:       math.Ceil(float64(promptTokens)*(1+costEstimateMargin)) * promptPrice,
+		MaxCompletionCost:   float64(maxCompletionTokens) * completionPrice,
+		RequestCost:         requestPrice,
+	}, nil
+}
+
+// checkCostCap refuses the requests whose estimated maximum cost exceeds the
+// cost cap of the config. The cap fails closed: the requests to a model missing
+// from the catalog, e.g. "openrouter/auto", are refused with an error matching
+// both ErrCostUnknown and ErrModelNotFound.
+func (c *Client) checkCostCap(ctx context.Context, request *ChatCompletionRequest) error {
+	limit := c.config.MaxCostPerRequest
+	if limit <= 0 {
+		return nil
+	}
+	estimate, err := c.EstimateCost(ctx, request, nil)
+	if errors.Is(err, ErrModelNotFound) {
+		return fmt.Errorf("%w: %w", ErrCostUnknown, err)
+	}
+	if err != nil {
+		return err
+	}
+	if estimate.MaxTotalCost() > limit {
+		return &CostCapExceededError{Cap: limit, Estimate: estimate}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package openrouter
+
+import "testing"
+
+func TestEstimateModelCost(t *testing.T) {
+	model := Model{
+		ID:            "m",
+		ContextLength: 1000,
+		Architecture:  ModelArchitecture{Tokenizer: "Test"},
+		Pricing:       ModelPricing{Prompt: "0.5", Completion: "2", Request: "1"},
+	}
+	// One token per role and content: 3 for the reply and 3+1+1 per message.
+	estimator := &TokenEstimator{Counters: map[string]TokenCounter{
+		"Test": TokenCounterFunc(func(string) int { return 1 }),
+	}}
+	request := &ChatCompletionRequest{
+		Model:     "m",
+		Messages:  []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "hi"}},
+		MaxTokens: 100,
+	}
+
+	estimate, err := EstimateModelCost(model, request, estimator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CostEstimate{
+		Model:               "m",
+		PromptTokens:        8,
+		MaxCompletionTokens: 100,
+		MinPromptCost:       3,
+		MaxPromptCost:       5,
+		MaxCompletionCost:   200,
+		RequestCost:         1,
+	}
+	if estimate != want {
+		t.Errorf("got %+v, want %+v", estimate, want)
+	}
+	if total := estimate.MaxTotalCost(); total != 206 {
+		t.Errorf("got a total of %v, want 206", total)
+	}
+
+	// Without a token limit, the completion is bounded by the top provider or
+	// else by the context left.
+	request.MaxTokens = 0
+	model.TopProvider.MaxCompletionTokens = 50
+	if estimate, err = EstimateModelCost(model, request, estimator); err != nil || estimate.MaxCompletionTokens != 50 {
+		t.Errorf("got %+v, %v", estimate, err)
+	}
+	model.TopProvider.MaxCompletionTokens = 0
+	if estimate, err = EstimateModelCost(model, request, estimator); err != nil || estimate.MaxCompletionTokens != 992 {
+		t.Errorf("got %+v, %v", estimate, err)
+	}
+
+	model.Pricing.Completion = "free"
+	if _, err = EstimateModelCost(model, request, estimator); err == nil {
+		t.Error("expected an error for an invalid price")
+	}
+}
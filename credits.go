@@ -0,0 +1,172 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const defaultCreditsWaitInterval = 30 * time.Second
+
+var (
+	ErrInsufficientCredits = errors.New("insufficient credits")
+)
+
+// InsufficientCreditsError is returned for the requests answered 402 Payment
+// Required. It matches ErrInsufficientCredits with errors.Is and wraps the
+// error of the API.
+type InsufficientCreditsError struct {
+	Err error
+}
+
+func (e *InsufficientCreditsError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrInsufficientCredits, e.Err)
+}
+
+func (e *InsufficientCreditsError) Unwrap() error {
+	return e.Err
+}
+
+func (e *InsufficientCreditsError) Is(target error) bool {
+	return target == ErrInsufficientCredits
+}
+
+// CreditsWaitPolicy makes the requests failing for insufficient credits wait
+// for new credits, e.g. from an auto top-up, rather than failing right away.
+// Without policy, such requests fail without retries, unless the client uses a
+// KeyPool.
+type CreditsWaitPolicy struct {
+	// Interval is the interval between the checks of the credits, defaults to
+	// 30 seconds.
+	Interval time.Duration
+	// MaxWait bounds the wait of a request, the request fails once it elapsed.
+	// Zero means only the deadline of the caller's context applies.
+	MaxWait time.Duration
+}
+
+// waitForCredits waits, following the credits wait policy, until the account
+// has credits left. deadline holds the end of the wait of the request, set on
+// the first wait when the policy bounds it. It returns an error when there is no policy or the wait
+// expired.
+func (c *Client) waitForCredits(ctx context.Context, deadline *time.Time) error {
+	policy := c.config.CreditsWait
+	if policy == nil {
+		return ErrInsufficientCredits
+	}
+	if deadline.IsZero() && policy.MaxWait > 0 {
+		*deadline = time.Now().Add(policy.MaxWait)
+	}
+	interval := policy.Interval
+	if interval <= 0 {
+		interval = defaultCreditsWaitInterval
+	}
+	for {
+		wait := interval
+		if !deadline.IsZero() {
+			if wait = min(wait, time.Until(*deadline)); wait <= 0 {
+				return ErrInsufficientCredits
+			}
+		}
+		c.logger().Printf("Insufficient credits, checking again in %s", wait)
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+		credits, err := c.probe().GetCredits(ctx)
+		if err == nil && credits.Remaining() > 0 {
+			return nil
+		}
+	}
+}
+
+// Credits are the credits purchased and used by the account, in USD.
+type Credits struct {
+	TotalCredits float64 `json:"total_credits"`
+	TotalUsage   float64 `json:"total_usage"`
+}
+
+// Remaining returns the credits left.
+func (c Credits) Remaining() float64 {
+	return c.TotalCredits - c.TotalUsage
+}
+
+// GetCredits — API call to get the credits of the account.
+func (c *Client) GetCredits(ctx context.Context) (credits Credits, err error) {
+	req, err := c.requestBuilder.Build(ctx, http.MethodGet, c.fullURL("/credits"), nil)
+	if err != nil {
+		return
+	}
+
+	var resp struct {
+		Data Credits `json:"data"`
+	}
+	err = c.sendRequest(req, &resp)
+	return resp.Data, err
+}
+
+// KeyInfo describes the API key of the client. Usage and Limit are in credits,
+// Limit is nil for keys without limit.
+type KeyInfo struct {
+	Label          string   `json:"label"`
+	Usage          float64  `json:"usage"`
+	Limit          *float64 `json:"limit"`
+	LimitRemaining *float64 `json:"limit_remaining"`
+	IsFreeTier     bool     `json:"is_free_tier"`
+	RateLimit      struct {
+		Requests int    `json:"requests"`
+		Interval string `json:"interval"`
+	} `json:"rate_limit"`
+}
+
+// GetKeyInfo — API call to get the usage and limits of the API key.
+func (c *Client) GetKeyInfo(ctx context.Context) (info KeyInfo, err error) {
+	req, err := c.requestBuilder.Build(ctx, http.MethodGet, c.fullURL("/key"), nil)
+	if err != nil {
+		return
+	}
+
+	var resp struct {
+		Data KeyInfo `json:"data"`
+	}
+	err = c.sendRequest(req, &resp)
+	return resp.Data, err
+}
+
+// Generation is the metadata of a generation: native token counts, cost and
+// timings, as reported after the request completed.
+type Generation struct {
+	ID                     string  `json:"id"`
+	Model                  string  `json:"model"`
+	ProviderName           string  `json:"provider_name"`
+	CreatedAt              string  `json:"created_at"`
+	Streamed               bool    `json:"streamed"`
+	Cancelled              bool    `json:"cancelled"`
+	FinishReason           string  `json:"finish_reason"`
+	NativeFinishReason     string  `json:"native_finish_reason"`
+	TotalCost              float64 `json:"total_cost"`
+	Latency                int     `json:"latency"`
+	GenerationTime         int     `json:"generation_time"`
+	TokensPrompt           int     `json:"tokens_prompt"`
+	TokensCompletion       int     `json:"tokens_completion"`
+	NativeTokensPrompt     int     `json:"native_tokens_prompt"`
+	NativeTokensCompletion int     `json:"native_tokens_completion"`
+	NativeTokensReasoning  int     `json:"native_tokens_reasoning"`
+}
+
+// GetGeneration — API call to get the metadata of a generation, id is the ID
+// of a chat completion response.
+func (c *Client) GetGeneration(ctx context.Context, id string) (generation Generation, err error) {
+	path := "/generation?" + url.Values{"id": {id}}.Encode()
+	req, err := c.requestBuilder.Build(ctx, http.MethodGet, c.fullURL(path), nil)
+	if err != nil {
+		return
+	}
+
+	var resp struct {
+		Data Generation `json:"data"`
+	}
+	err = c.sendRequest(req, &resp)
+	return resp.Data, err
+}
@@ -0,0 +1,114 @@
+package openrouter
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// redactedHeaders are masked in debug dumps.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+type (
+	debugCtxKey       struct{}
+	fingerprintCtxKey struct{}
+)
+
+// WithDebug enables debug dumps for the requests made with ctx, regardless of
+// ClientConfig.Debug.
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugCtxKey{}, true)
+}
+
+func (c *Client) debugEnabled(ctx context.Context) bool {
+	if c.config.Debug {
+		return true
+	}
+	enabled, _ := ctx.Value(debugCtxKey{}).(bool)
+	return enabled
+}
+
+// withFingerprint records the hash of request in ctx for the debug dumps, when
+// debugging is on.
+func (c *Client) withFingerprint(ctx context.Context, request *ChatCompletionRequest) context.Context {
+	if !c.debugEnabled(ctx) {
+		return ctx
+	}
+	return context.WithValue(ctx, fingerprintCtxKey{}, request.Hash())
+}
+
+// logPrefix returns the prefix of the debug dumps made with ctx.
+func logPrefix(ctx context.Context) string {
+	if fingerprint, _ := ctx.Value(fingerprintCtxKey{}).(string); fingerprint != "" {
+		return "openrouter: [" + fingerprint + "] "
+	}
+	return "openrouter: "
+}
+
+func (c *Client) logger() *log.Logger {
+	if c.config.Logger != nil {
+		return c.config.Logger
+	}
+	return log.Default()
+}
+
+// dumpRequest logs the request line, redacted headers and JSON body.
+func (c *Client) dumpRequest(req *http.Request) {
+	if !c.debugEnabled(req.Context()) {
+		return
+	}
+	var body []byte
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			body, _ = io.ReadAll(rc)
+			rc.Close()
+		}
+	}
+	c.logger().Printf("%srequest %s %s\n%s\n%s",
+		logPrefix(req.Context()), req.Method, req.URL, formatHeaders(req.Header), body)
+}
+
+// dumpResponse logs the response status, redacted headers and raw body.
+func (c *Client) dumpResponse(res *http.Response, body []byte) {
+	ctx := context.Background()
+	if res.Request != nil {
+		ctx = res.Request.Context()
+	}
+	if !c.debugEnabled(ctx) {
+		return
+	}
+	c.logger().Printf("%sresponse %s\n%s\n%s", logPrefix(ctx), res.Status, formatHeaders(res.Header), body)
+}
+
+// streamLogger returns the logger used to dump SSE lines, nil when debugging is off.
+func (c *Client) streamLogger(ctx context.Context) *log.Logger {
+	if !c.debugEnabled(ctx) {
+		return nil
+	}
+	return c.logger()
+}
+
+func formatHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		value := strings.Join(header[name], ", ")
+		for _, sensitive := range redactedHeaders {
+			if http.CanonicalHeaderKey(name) == sensitive {
+				value = redacted
+			}
+		}
+		b.WriteString(name + ": " + value + "\n")
+	}
+	return b.String()
+}
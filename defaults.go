@@ -0,0 +1,39 @@
+package openrouter
+
+// RequestDefaults are applied to the chat completion requests leaving the
+// corresponding fields zero.
+type RequestDefaults struct {
+	Model       string
+	Temperature *float32
+	MaxTokens   int
+	Provider    *ProviderPreferences
+	// Metadata is merged into the request metadata, request values win.
+	Metadata map[string]string
+}
+
+func (d RequestDefaults) apply(request *ChatCompletionRequest) {
+	if request.Model == "" {
+		request.Model = d.Model
+	}
+	if request.Temperature == nil && d.Temperature != nil {
+		temperature := *d.Temperature
+		request.Temperature = &temperature
+	}
+	if request.MaxTokens == 0 {
+		request.MaxTokens = d.MaxTokens
+	}
+	if request.Provider == nil && d.Provider != nil {
+		provider := *d.Provider
+		request.Provider = &provider
+	}
+	if len(d.Metadata) > 0 {
+		metadata := make(map[string]string, len(d.Metadata)+len(request.Metadata))
+		for key, value := range d.Metadata {
+			metadata[key] = value
+		}
+		for key, value := range request.Metadata {
+			metadata[key] = value
+		}
+		request.Metadata = metadata
+	}
+}
@@ -0,0 +1,15 @@
+package openrouter
+
+import "context"
+
+// Do — sends a request to any API path (e.g. "/beta/endpoint") with the auth
+// headers, retries and error handling of the typed methods. body is marshaled
+// to JSON unless nil, the response is decoded into out: a pointer to a struct,
+// a *string for the raw body, or nil to discard it.
+func (c *Client) Do(ctx context.Context, method, path string, body, out any) error {
+	req, err := c.requestBuilder.Build(ctx, method, c.fullURL(path), body)
+	if err != nil {
+		return err
+	}
+	return c.sendRequest(req, out)
+}
@@ -1,16 +1,53 @@
 package openrouter
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
+	"net/http"
+	"strings"
 )
 
 // APIError provides error information returned by the OpenAI API.
 type APIError struct {
-	Code           any    `json:"code,omitempty"`
-	Message        string `json:"message"`
-	Details        any    `json:"details"`
-	HTTPStatusCode int    `json:"-"`
+	Code    any    `json:"code,omitempty"`
+	Message string `json:"message"`
+	Details any    `json:"details"`
+	// Metadata carries the OpenRouter error details, e.g. the failing provider.
+	Metadata       map[string]any `json:"metadata,omitempty"`
+	HTTPStatusCode int            `json:"-"`
+}
+
+// APIWarning is an error reported by the API in a successful response that
+// still carries choices, e.g. a provider error after a partial generation.
+type APIWarning struct {
+	Code    any
+	Message string
+}
+
+func (w *APIWarning) String() string {
+	return w.Message
+}
+
+// attachWarning sets the warning of the decoded response v, it reports false if
+// v isn't a response or has no choices.
+func attachWarning(v any, apiErr *APIError) bool {
+	warning := &APIWarning{Code: apiErr.Code, Message: apiErr.Message}
+	switch r := v.(type) {
+	case **ChatCompletionResponse:
+		if *r != nil && len((*r).Choices) > 0 {
+			(*r).Warning = warning
+			return true
+		}
+	case **CompletionResponse:
+		if *r != nil && len((*r).Choices) > 0 {
+			(*r).Warning = warning
+			return true
+		}
+	}
+	return false
 }
 
 // RequestError provides informations about generic request errors.
@@ -19,6 +56,60 @@ type RequestError struct {
 	Err            error
 }
 
+// maxBodySnippet is the length of the body kept by UnexpectedResponseError.
+const maxBodySnippet = 512
+
+// UnexpectedResponseError is returned when a response body isn't the expected
+// JSON, e.g. the HTML error page of a proxy. Body is truncated.
+type UnexpectedResponseError struct {
+	HTTPStatusCode int
+	ContentType    string
+	Body           string
+	Err            error
+}
+
+func newUnexpectedResponseError(res *http.Response, body []byte, err error) *UnexpectedResponseError {
+	if len(body) > maxBodySnippet {
+		body = append(body[:maxBodySnippet:maxBodySnippet], "..."...)
+	}
+	return &UnexpectedResponseError{
+		HTTPStatusCode: res.StatusCode,
+		ContentType:    res.Header.Get("Content-Type"),
+		Body:           string(body),
+		Err:            err,
+	}
+}
+
+func (e *UnexpectedResponseError) Error() string {
+	msg := fmt.Sprintf("unexpected response, status code: %d, content type: %q", e.HTTPStatusCode, e.ContentType)
+	if e.Err != nil {
+		msg += ", error: " + e.Err.Error()
+	}
+	if e.Body == "" {
+		return msg + ", empty body"
+	}
+	return msg + ", body: " + e.Body
+}
+
+func (e *UnexpectedResponseError) Unwrap() error {
+	return e.Err
+}
+
+// isJSONResponse reports whether a response body of the given content type is
+// JSON. A body starting as a JSON object or array is accepted whatever the
+// content type, as some servers and proxies label JSON as text/plain.
+func isJSONResponse(contentType string, body []byte) bool {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return false
+	}
+	if body[0] == '{' || body[0] == '[' {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
 type ErrorResponse struct {
 	Error *APIError `json:"error,omitempty"`
 }
@@ -43,6 +134,13 @@ func (e *APIError) UnmarshalJSON(data []byte) (err error) {
 		return
 	}
 
+	if rawMetadata, ok := rawMap["metadata"]; ok {
+		err = json.Unmarshal(rawMetadata, &e.Metadata)
+		if err != nil {
+			return
+		}
+	}
+
 	if _, ok := rawMap["code"]; !ok {
 		return nil
 	}
@@ -59,6 +157,12 @@ func (e *APIError) UnmarshalJSON(data []byte) (err error) {
 	return json.Unmarshal(rawMap["code"], &e.Code)
 }
 
+// ProviderName returns the upstream provider reported in the error metadata.
+func (e *APIError) ProviderName() string {
+	name, _ := e.Metadata["provider_name"].(string)
+	return name
+}
+
 func (e *RequestError) Error() string {
 	return fmt.Sprintf("error, status code: %d, message: %s", e.HTTPStatusCode, e.Err)
 }
@@ -66,3 +170,72 @@ func (e *RequestError) Error() string {
 func (e *RequestError) Unwrap() error {
 	return e.Err
 }
+
+// errorStatusCode returns the HTTP status code carried by err, or 0.
+func errorStatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode
+	}
+	var unexpectedErr *UnexpectedResponseError
+	if errors.As(err, &unexpectedErr) {
+		return unexpectedErr.HTTPStatusCode
+	}
+	return 0
+}
+
+// requestIDHeaders are the response headers identifying a request for support.
+var requestIDHeaders = []string{"X-Request-Id", "Cf-Ray"}
+
+// Attempt describes a failed attempt of a request.
+type Attempt struct {
+	Number     int
+	StatusCode int
+	Err        error
+	// Provider is the upstream provider reported by the API, if any.
+	Provider string
+	// RequestIDs holds the request identification headers of the response.
+	RequestIDs map[string]string
+}
+
+func newAttempt(number int, res *http.Response, err error) Attempt {
+	attempt := Attempt{Number: number, Err: err}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		attempt.Provider = apiErr.ProviderName()
+	}
+	if res == nil {
+		return attempt
+	}
+	attempt.StatusCode = res.StatusCode
+	for _, name := range requestIDHeaders {
+		if value := res.Header.Get(name); value != "" {
+			if attempt.RequestIDs == nil {
+				attempt.RequestIDs = make(map[string]string)
+			}
+			attempt.RequestIDs[name] = value
+		}
+	}
+	return attempt
+}
+
+// MultiAttemptError is returned when every attempt of a request failed. It
+// unwraps to the error of the last attempt.
+type MultiAttemptError struct {
+	Attempts []Attempt
+}
+
+func (e *MultiAttemptError) Error() string {
+	return fmt.Sprintf("all %d retry attempts failed, last error: %v", len(e.Attempts), e.Unwrap())
+}
+
+func (e *MultiAttemptError) Unwrap() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
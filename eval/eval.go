@@ -0,0 +1,173 @@
+// Package eval runs a suite of prompts across several models and grades the
+// answers, reporting the accuracy, latency and cost of each model.
+package eval
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	openrouter "github.com/dedlockdave/go-openrouter"
+)
+
+const defaultWorkers = 4
+
+var (
+	ErrNoGrader = errors.New("case has no grader")
+)
+
+// Case is a prompt of a suite. Expected is the reference answer used by the
+// graders comparing outputs, Grader overrides the grader of the suite.
+type Case struct {
+	Name     string
+	Messages []openrouter.ChatCompletionMessage
+	Expected string
+	Grader   Grader
+}
+
+// Suite is a set of cases graded by Grader unless they carry their own.
+type Suite struct {
+	Cases  []Case
+	Grader Grader
+	// Request is the template of every request (sampling parameters, provider
+	// preferences...), its Model and Messages are replaced.
+	Request openrouter.ChatCompletionRequest
+	// Workers is the number of concurrent requests, defaults to 4.
+	Workers int
+}
+
+// Result is the outcome of a case on a model. Err is set when the request or
+// the grading failed.
+type Result struct {
+	Model   string
+	Case    string
+	Output  string
+	Grade   Grade
+	Latency time.Duration
+	Usage   *openrouter.Usage
+	Err     error
+}
+
+// ModelReport aggregates the results of a model, in the order of the cases.
+type ModelReport struct {
+	Model       string
+	Results     []Result
+	Passed      int
+	Errors      int
+	Accuracy    float64
+	MeanLatency time.Duration
+	// TotalCost is the cost in credits reported by the usage accounting.
+	TotalCost float64
+}
+
+// Report holds the reports of the models, in the order they were given.
+type Report struct {
+	Models []ModelReport
+}
+
+// Run runs every case of suite on every model with client and grades the
+// outputs. Failed cases are reported in the results, Run only fails when ctx
+// is done.
+func Run(ctx context.Context, client openrouter.ChatClient, suite Suite, models ...string) (*Report, error) {
+	workers := suite.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
+	results := make([][]Result, len(models))
+	for i := range results {
+		results[i] = make([]Result, len(suite.Cases))
+	}
+	type job struct{ model, kase int }
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	for range min(workers, len(models)*len(suite.Cases)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results[j.model][j.kase] = runCase(ctx, client, suite, models[j.model], suite.Cases[j.kase])
+			}
+		}()
+	}
+
+feed:
+	for m := range models {
+		for c := range suite.Cases {
+			select {
+			case jobs <- job{m, c}:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &Report{Models: make([]ModelReport, len(models))}
+	for i, model := range models {
+		report.Models[i] = newModelReport(model, results[i])
+	}
+	return report, nil
+}
+
+func runCase(ctx context.Context, client openrouter.ChatClient, suite Suite, model string, c Case) Result {
+	result := Result{Model: model, Case: c.Name}
+	grader := c.Grader
+	if grader == nil {
+		grader = suite.Grader
+	}
+	if grader == nil {
+		result.Err = ErrNoGrader
+		return result
+	}
+
+	request := suite.Request
+	request.Model = model
+	request.Messages = c.Messages
+	if request.Usage == nil {
+		request.Usage = &openrouter.UsageConfig{Include: true}
+	}
+
+	start := time.Now()
+	resp, err := client.CreateChatCompletion(ctx, &request)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Usage = resp.Usage
+	if len(resp.Choices) == 0 {
+		result.Err = openrouter.ErrEmptyChoices
+		return result
+	}
+	result.Output = resp.Choices[0].Message.Content
+	result.Grade, result.Err = grader.Grade(ctx, c, result.Output)
+	return result
+}
+
+func newModelReport(model string, results []Result) ModelReport {
+	report := ModelReport{Model: model, Results: results}
+	var latency time.Duration
+	for _, result := range results {
+		latency += result.Latency
+		if result.Usage != nil {
+			report.TotalCost += result.Usage.Cost
+		}
+		switch {
+		case result.Err != nil:
+			report.Errors++
+		case result.Grade.Pass:
+			report.Passed++
+		}
+	}
+	if len(results) > 0 {
+		report.Accuracy = float64(report.Passed) / float64(len(results))
+		report.MeanLatency = latency / time.Duration(len(results))
+	}
+	return report
+}
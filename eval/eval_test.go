@@ -0,0 +1,56 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	openrouter "github.com/dedlockdave/go-openrouter"
+)
+
+// modelClient answers with the output configured for the model of the request.
+type modelClient struct {
+	openrouter.FakeClient
+	outputs map[string]string
+}
+
+func (c *modelClient) CreateChatCompletion(
+	_ context.Context,
+	request *openrouter.ChatCompletionRequest,
+) (*openrouter.ChatCompletionResponse, error) {
+	output, ok := c.outputs[request.Model]
+	if !ok {
+		return nil, errors.New("unknown model")
+	}
+	return &openrouter.ChatCompletionResponse{
+		Choices: []openrouter.ChatCompletionChoice{{Message: openrouter.Index{Content: output}}},
+		Usage:   &openrouter.Usage{Cost: 0.5},
+	}, nil
+}
+
+func TestRun(t *testing.T) {
+	client := &modelClient{outputs: map[string]string{"good": "Paris", "bad": "Lyon"}}
+	suite := Suite{
+		Grader: ExactMatch(),
+		Cases: []Case{
+			{Name: "capital", Expected: "Paris"},
+			{Name: "pattern", Grader: Regex(regexp.MustCompile(`^P`))},
+		},
+	}
+
+	report, err := Run(context.Background(), client, suite, "good", "bad", "missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	good, bad, missing := report.Models[0], report.Models[1], report.Models[2]
+	if good.Accuracy != 1 || good.TotalCost != 1 {
+		t.Errorf("unexpected good report %+v", good)
+	}
+	if bad.Passed != 0 || bad.Results[0].Grade.Reason == "" {
+		t.Errorf("unexpected bad report %+v", bad)
+	}
+	if missing.Errors != 2 {
+		t.Errorf("unexpected missing report %+v", missing)
+	}
+}
@@ -0,0 +1,102 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	openrouter "github.com/dedlockdave/go-openrouter"
+	"github.com/dedlockdave/go-openrouter/jsonschema"
+)
+
+// Grade is the verdict of a grader. Score is in [0, 1].
+type Grade struct {
+	Pass   bool
+	Score  float64
+	Reason string
+}
+
+// Grader grades the output of a model for a case.
+type Grader interface {
+	Grade(ctx context.Context, c Case, output string) (Grade, error)
+}
+
+// GraderFunc adapts a function to the Grader interface.
+type GraderFunc func(ctx context.Context, c Case, output string) (Grade, error)
+
+func (f GraderFunc) Grade(ctx context.Context, c Case, output string) (Grade, error) {
+	return f(ctx, c, output)
+}
+
+func verdict(pass bool, reason string) Grade {
+	if pass {
+		return Grade{Pass: true, Score: 1}
+	}
+	return Grade{Reason: reason}
+}
+
+// ExactMatch passes outputs equal to the expected answer, ignoring the leading
+// and trailing whitespace.
+func ExactMatch() Grader {
+	return GraderFunc(func(_ context.Context, c Case, output string) (Grade, error) {
+		pass := strings.TrimSpace(output) == strings.TrimSpace(c.Expected)
+		return verdict(pass, fmt.Sprintf("expected %q", c.Expected)), nil
+	})
+}
+
+// Regex passes outputs matching re.
+func Regex(re *regexp.Regexp) Grader {
+	return GraderFunc(func(_ context.Context, _ Case, output string) (Grade, error) {
+		return verdict(re.MatchString(output), fmt.Sprintf("does not match %s", re)), nil
+	})
+}
+
+// JSONSchema passes outputs that are JSON documents valid against schema.
+func JSONSchema(schema *jsonschema.Definition) Grader {
+	return GraderFunc(func(_ context.Context, _ Case, output string) (Grade, error) {
+		if err := jsonschema.Validate(schema, []byte(output)); err != nil {
+			return Grade{Reason: err.Error()}, nil
+		}
+		return verdict(true, ""), nil
+	})
+}
+
+const judgePrompt = `You are grading the answer of an AI assistant.
+
+Grading criteria:
+%s
+
+Reference answer (may be empty):
+%s
+
+Answer to grade:
+%s
+
+Reply with PASS or FAIL on the first line, followed by a short justification.`
+
+// LLMJudge asks model, through client, whether the output meets the criteria
+// given the expected answer of the case.
+func LLMJudge(client openrouter.ChatClient, model, criteria string) Grader {
+	return GraderFunc(func(ctx context.Context, c Case, output string) (Grade, error) {
+		resp, err := client.CreateChatCompletion(ctx, &openrouter.ChatCompletionRequest{
+			Model: model,
+			Messages: []openrouter.ChatCompletionMessage{{
+				Role:    openrouter.ChatMessageRoleUser,
+				Content: fmt.Sprintf(judgePrompt, criteria, c.Expected, output),
+			}},
+		})
+		if err != nil {
+			return Grade{}, fmt.Errorf("judge: %w", err)
+		}
+		if len(resp.Choices) == 0 {
+			return Grade{}, fmt.Errorf("judge: %w", openrouter.ErrEmptyChoices)
+		}
+		answer := strings.TrimSpace(resp.Choices[0].Message.Content)
+		first, reason, _ := strings.Cut(answer, "\n")
+		pass := strings.HasPrefix(strings.ToUpper(strings.TrimSpace(first)), "PASS")
+		grade := verdict(pass, "")
+		grade.Reason = strings.TrimSpace(reason)
+		return grade, nil
+	})
+}
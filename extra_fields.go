@@ -0,0 +1,169 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	utils "github.com/dedlockdave/go-openrouter/internal"
+)
+
+// knownFields caches the JSON field names of the types using extra fields.
+var knownFields sync.Map
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	if names, ok := knownFields.Load(t); ok {
+		return names.(map[string]bool)
+	}
+	names := make(map[string]bool, t.NumField())
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	knownFields.Store(t, names)
+	return names
+}
+
+// marshalWithExtraFields marshals v and merges extra into the resulting object.
+// Extra fields override the typed fields of the same name.
+func marshalWithExtraFields(v any, extra map[string]any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range extra {
+		if fields[key], err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// unknownFields returns the fields of the JSON object data that aren't fields of t.
+func unknownFields(data []byte, t reflect.Type) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	known := jsonFieldNames(t)
+	for key := range fields {
+		if known[key] {
+			delete(fields, key)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+type chatCompletionRequest ChatCompletionRequest
+
+func (r ChatCompletionRequest) MarshalJSON() ([]byte, error) {
+	return marshalWithExtraFields(chatCompletionRequest(r), r.ExtraFields)
+}
+
+type chatCompletionResponse ChatCompletionResponse
+
+func (r *ChatCompletionResponse) UnmarshalJSON(data []byte) error {
+	var resp chatCompletionResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	extra, err := unknownFields(data, reflect.TypeOf(resp))
+	if err != nil {
+		return err
+	}
+	resp.ExtraFields = extra
+	*r = ChatCompletionResponse(resp)
+	return nil
+}
+
+func (r ChatCompletionResponse) MarshalJSON() ([]byte, error) {
+	extra := make(map[string]any, len(r.ExtraFields))
+	for key, value := range r.ExtraFields {
+		extra[key] = value
+	}
+	return marshalWithExtraFields(chatCompletionResponse(r), extra)
+}
+
+// strictUnmarshaler extends the strict decoding to the chat completion
+// responses, whose custom decoding keeps their unknown fields in ExtraFields
+// and ignores those of their choices, out of sight of the JSON decoder.
+type strictUnmarshaler struct {
+	*utils.JSONUnmarshaler
+}
+
+func (s strictUnmarshaler) Unmarshal(data []byte, v any) error {
+	if err := s.JSONUnmarshaler.Unmarshal(data, v); err != nil {
+		return err
+	}
+	switch v.(type) {
+	case *ChatCompletionResponse, **ChatCompletionResponse:
+	default:
+		return nil
+	}
+	unknown, err := unknownResponseFields(data)
+	if err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("json: unknown fields %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// unknownResponseFields returns the paths of the fields of the chat completion
+// response data unknown to the response, choice and message types, sorted.
+func unknownResponseFields(data []byte) ([]string, error) {
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+	var unknown []string
+	responseFields := jsonFieldNames(reflect.TypeOf(chatCompletionResponse{}))
+	for key := range response {
+		if !responseFields[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	var choices []map[string]json.RawMessage
+	if raw, ok := response["choices"]; ok {
+		if err := json.Unmarshal(raw, &choices); err != nil {
+			return nil, err
+		}
+	}
+	choiceFields := jsonFieldNames(reflect.TypeOf(ChatCompletionChoice{}))
+	messageFields := jsonFieldNames(reflect.TypeOf(Index{}))
+	for i, choice := range choices {
+		for key, value := range choice {
+			if !choiceFields[key] {
+				unknown = append(unknown, fmt.Sprintf("choices[%d].%s", i, key))
+				continue
+			}
+			if key != "message" && key != "delta" {
+				continue
+			}
+			var message map[string]json.RawMessage
+			if json.Unmarshal(value, &message) != nil {
+				continue
+			}
+			for name := range message {
+				if !messageFields[name] {
+					unknown = append(unknown, fmt.Sprintf("choices[%d].%s.%s", i, key, name))
+				}
+			}
+		}
+	}
+	slices.Sort(unknown)
+	return unknown, nil
+}
@@ -0,0 +1,134 @@
+package openrouter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	utils "github.com/dedlockdave/go-openrouter/internal"
+)
+
+// FakeCall records a call made to a FakeClient.
+type FakeCall struct {
+	Method  string
+	Request *ChatCompletionRequest
+}
+
+// FakeClient is an in-memory ChatClient for unit tests. Responses and
+// StreamChunks are consumed in order, the last element being repeated once the
+// script is exhausted. Errors are consumed one per call, a nil entry lets the
+// call succeed. All calls are recorded in Calls.
+type FakeClient struct {
+	mu sync.Mutex
+
+	Responses    []*ChatCompletionResponse
+	StreamChunks [][]ChatCompletionResponse
+	Models       ModelsList
+	Errors       []error
+
+	Calls []FakeCall
+
+	responseIdx int
+	streamIdx   int
+}
+
+var _ ChatClient = (*FakeClient)(nil)
+
+// FakeDeltas builds stream chunks carrying the given text deltas.
+func FakeDeltas(deltas ...string) []ChatCompletionResponse {
+	chunks := make([]ChatCompletionResponse, 0, len(deltas))
+	for _, delta := range deltas {
+		chunks = append(chunks, ChatCompletionResponse{
+			Object: "chat.completion.chunk",
+			Choices: []ChatCompletionChoice{{
+				Delta: Index{Role: ChatMessageRoleAssistant, Content: delta},
+			}},
+		})
+	}
+	return chunks
+}
+
+func (f *FakeClient) CreateChatCompletion(
+	_ context.Context,
+	request *ChatCompletionRequest,
+) (*ChatCompletionResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("CreateChatCompletion", request); err != nil {
+		return nil, err
+	}
+	if len(f.Responses) == 0 {
+		return &ChatCompletionResponse{Model: request.Model}, nil
+	}
+	resp := f.Responses[min(f.responseIdx, len(f.Responses)-1)]
+	f.responseIdx++
+	return resp, nil
+}
+
+func (f *FakeClient) CreateChatCompletionStream(
+	_ context.Context,
+	request *ChatCompletionRequest,
+) (*ChatCompletionStream, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("CreateChatCompletionStream", request); err != nil {
+		return nil, err
+	}
+	var chunks []ChatCompletionResponse
+	if len(f.StreamChunks) > 0 {
+		chunks = f.StreamChunks[min(f.streamIdx, len(f.StreamChunks)-1)]
+		f.streamIdx++
+	}
+
+	var body bytes.Buffer
+	for _, chunk := range chunks {
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			return nil, err
+		}
+		body.WriteString("data: ")
+		body.Write(data)
+		body.WriteString("\n\n")
+	}
+	body.WriteString("data: [DONE]\n\n")
+
+	return &ChatCompletionStream{
+		streamReader: newFakeStreamReader[ChatCompletionResponse](&body),
+	}, nil
+}
+
+func (f *FakeClient) ListModels(_ context.Context) (ModelsList, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.record("ListModels", nil); err != nil {
+		return ModelsList{}, err
+	}
+	return f.Models, nil
+}
+
+func (f *FakeClient) record(method string, request *ChatCompletionRequest) error {
+	f.Calls = append(f.Calls, FakeCall{Method: method, Request: request})
+	if len(f.Errors) == 0 {
+		return nil
+	}
+	err := f.Errors[0]
+	f.Errors = f.Errors[1:]
+	return err
+}
+
+func newFakeStreamReader[T streamable](body io.Reader) *streamReader[T] {
+	return &streamReader[T]{
+		emptyMessagesLimit: defaultEmptyMessagesLimit,
+		reader:             bufio.NewReader(body),
+		response:           &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(body)},
+		errAccumulator:     utils.NewErrorAccumulator(),
+		unmarshaler:        &utils.JSONUnmarshaler{},
+	}
+}
@@ -0,0 +1,52 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestFakeClient_Stream(t *testing.T) {
+	fake := &FakeClient{StreamChunks: [][]ChatCompletionResponse{FakeDeltas("Hel", "lo")}}
+
+	stream, err := fake.CreateChatCompletionStream(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var text string
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		text += resp.Choices[0].Delta.Content
+	}
+	if text != "Hello" {
+		t.Errorf("got %q, want %q", text, "Hello")
+	}
+	if len(fake.Calls) != 1 || fake.Calls[0].Method != "CreateChatCompletionStream" {
+		t.Errorf("unexpected calls %#v", fake.Calls)
+	}
+}
+
+func TestFakeClient_Errors(t *testing.T) {
+	injected := errors.New("boom")
+	fake := &FakeClient{
+		Responses: []*ChatCompletionResponse{{ID: "1"}},
+		Errors:    []error{injected, nil},
+	}
+
+	if _, err := fake.CreateChatCompletion(context.Background(), &ChatCompletionRequest{}); !errors.Is(err, injected) {
+		t.Errorf("expected injected error, got %v", err)
+	}
+	resp, err := fake.CreateChatCompletion(context.Background(), &ChatCompletionRequest{})
+	if err != nil || resp.ID != "1" {
+		t.Errorf("unexpected result %v, %v", resp, err)
+	}
+}
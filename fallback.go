@@ -0,0 +1,123 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FallbackModel is a model of a fallback chain with its request overrides.
+type FallbackModel struct {
+	Model string
+	// MaxTokens overrides the request max_tokens when > 0.
+	MaxTokens int
+	// Provider overrides the request provider preferences when set.
+	Provider *ProviderPreferences
+}
+
+// FallbackPolicy is a client-side fallback chain: models are tried in order
+// until one answers or ShouldFallback rejects the error.
+type FallbackPolicy struct {
+	Models []FallbackModel
+	// ShouldFallback decides whether an error moves on to the next model,
+	// defaults to DefaultShouldFallback.
+	ShouldFallback func(err error) bool
+}
+
+// DefaultShouldFallback falls back on any error but context cancellations and
+// exceeded budgets.
+func DefaultShouldFallback(err error) bool {
+	return !errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded) &&
+		!errors.Is(err, ErrBudgetExceeded)
+}
+
+// FallbackAttempt is a failed attempt of a fallback chain.
+type FallbackAttempt struct {
+	Model string
+	Err   error
+}
+
+// FallbackError is returned when every model of the chain failed.
+type FallbackError struct {
+	Attempts []FallbackAttempt
+}
+
+func (e *FallbackError) Error() string {
+	msgs := make([]string, 0, len(e.Attempts))
+	for _, attempt := range e.Attempts {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", attempt.Model, attempt.Err))
+	}
+	return "all fallback models failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *FallbackError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Attempts))
+	for _, attempt := range e.Attempts {
+		errs = append(errs, attempt.Err)
+	}
+	return errs
+}
+
+// CreateChatCompletionWithFallback — creates a chat completion trying
+// request.Model first, then the models of ClientConfig.FallbackPolicy. It returns
+// the model that answered, as reported by the response: the model served for
+// a router model like "openrouter/auto", or the candidate if none is reported.
+func (c *Client) CreateChatCompletionWithFallback(
+	ctx context.Context,
+	request *ChatCompletionRequest,
+) (response *ChatCompletionResponse, model string, err error) {
+	policy := c.config.FallbackPolicy
+	if policy == nil {
+		if response, err = c.CreateChatCompletion(ctx, request); err != nil {
+			return nil, "", err
+		}
+		return response, servedModel(response, request.Model), nil
+	}
+	shouldFallback := policy.ShouldFallback
+	if shouldFallback == nil {
+		shouldFallback = DefaultShouldFallback
+	}
+
+	chain := policy.Models
+	if request.Model != "" {
+		chain = append([]FallbackModel{{Model: request.Model}}, chain...)
+	}
+
+	fallbackErr := &FallbackError{}
+	tried := make(map[string]bool, len(chain))
+	for _, candidate := range chain {
+		if tried[candidate.Model] {
+			continue
+		}
+		tried[candidate.Model] = true
+
+		attempt := *request
+		attempt.Model = candidate.Model
+		if candidate.MaxTokens > 0 {
+			attempt.MaxTokens = candidate.MaxTokens
+		}
+		if candidate.Provider != nil {
+			attempt.Provider = candidate.Provider
+		}
+
+		response, err = c.CreateChatCompletion(ctx, &attempt)
+		if err == nil {
+			return response, servedModel(response, candidate.Model), nil
+		}
+		fallbackErr.Attempts = append(fallbackErr.Attempts, FallbackAttempt{Model: candidate.Model, Err: err})
+		if !shouldFallback(err) {
+			return nil, "", err
+		}
+	}
+	return nil, "", fallbackErr
+}
+
+// servedModel returns the model of response, or requested if it isn't reported.
+func servedModel(response *ChatCompletionResponse, requested string) string {
+	if response.Model != "" {
+		return response.Model
+	}
+	return requested
+}
@@ -0,0 +1,92 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestClient_FallbackPolicy(t *testing.T) {
+	var (
+		models    []string
+		maxTokens []int
+	)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var request ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		models, maxTokens = append(models, request.Model), append(maxTokens, request.MaxTokens)
+		if request.Model != "b/answering" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"error":{"code":400,"message":"unavailable"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	})
+	routed := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"model":"b/served","choices":[{"message":{"content":"ok"}}]}`)
+	})
+	client.config.FallbackPolicy = &FallbackPolicy{Models: []FallbackModel{
+		{Model: "a/failing"},
+		{Model: "a/failing", MaxTokens: 10},
+		{Model: "b/answering", MaxTokens: 50},
+	}}
+
+	request := &ChatCompletionRequest{Model: "a/primary", MaxTokens: 100}
+	_, model, err := client.CreateChatCompletionWithFallback(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model != "b/answering" || request.Model != "a/primary" {
+		t.Errorf("unexpected model %q, request model %q", model, request.Model)
+	}
+	if len(models) != 3 || models[1] != "a/failing" || maxTokens[0] != 100 || maxTokens[2] != 50 {
+		t.Errorf("expected the duplicate to be skipped and the overrides applied, got %v %v", models, maxTokens)
+	}
+
+	client.config.FallbackPolicy.Models = client.config.FallbackPolicy.Models[:2]
+	var fallbackErr *FallbackError
+	_, _, err = client.CreateChatCompletionWithFallback(context.Background(), request)
+	if !errors.As(err, &fallbackErr) || len(fallbackErr.Attempts) != 2 || fallbackErr.Attempts[1].Model != "a/failing" {
+		t.Fatalf("expected a FallbackError, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "unavailable" {
+		t.Errorf("expected the attempts to unwrap to their APIError, got %v", err)
+	}
+
+	models = nil
+	client.config.FallbackPolicy.ShouldFallback = func(error) bool { return false }
+	if _, _, err = client.CreateChatCompletionWithFallback(context.Background(), request); !errors.As(err, &apiErr) ||
+		errors.As(err, &fallbackErr) {
+		t.Errorf("expected the first error as is, got %v", err)
+	}
+	if len(models) != 1 {
+		t.Errorf("expected the chain to stop at the first model, got %v", models)
+	}
+
+	routed.config.FallbackPolicy = &FallbackPolicy{}
+	request.Model = "openrouter/auto"
+	if _, model, err = routed.CreateChatCompletionWithFallback(context.Background(), request); err != nil || model != "b/served" {
+		t.Errorf("expected the served model, got %q, %v", model, err)
+	}
+}
+
+func TestDefaultShouldFallback(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&APIError{Code: 503}, true},
+		{context.Canceled, false},
+		{context.DeadlineExceeded, false},
+		{&BudgetExceededError{}, false},
+	}
+	for _, test := range tests {
+		if got := DefaultShouldFallback(test.err); got != test.want {
+			t.Errorf("DefaultShouldFallback(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
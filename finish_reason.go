@@ -0,0 +1,84 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// FinishReason is the reason the model stopped generating, normalized to the
+// OpenAI set. The value sent by the provider is kept in NativeFinishReason.
+type FinishReason string
+
+const (
+	FinishReasonStop          FinishReason = "stop"
+	FinishReasonLength        FinishReason = "length"
+	FinishReasonToolCalls     FinishReason = "tool_calls"
+	FinishReasonContentFilter FinishReason = "content_filter"
+	FinishReasonError         FinishReason = "error"
+)
+
+// finishReasonVariants maps the provider specific finish reasons, lower cased,
+// to the standard set.
+var finishReasonVariants = map[string]FinishReason{
+	"stop":               FinishReasonStop,
+	"end_turn":           FinishReasonStop,
+	"stop_sequence":      FinishReasonStop,
+	"eos":                FinishReasonStop,
+	"complete":           FinishReasonStop,
+	"length":             FinishReasonLength,
+	"max_tokens":         FinishReasonLength,
+	"model_length":       FinishReasonLength,
+	"tool_calls":         FinishReasonToolCalls,
+	"tool_use":           FinishReasonToolCalls,
+	"function_call":      FinishReasonToolCalls,
+	"content_filter":     FinishReasonContentFilter,
+	"safety":             FinishReasonContentFilter,
+	"recitation":         FinishReasonContentFilter,
+	"blocklist":          FinishReasonContentFilter,
+	"prohibited_content": FinishReasonContentFilter,
+	"spii":               FinishReasonContentFilter,
+	"refusal":            FinishReasonContentFilter,
+	"error":              FinishReasonError,
+}
+
+// NormalizeFinishReason maps a provider finish reason (e.g. "end_turn",
+// "MAX_TOKENS") to the standard set. Unknown reasons are returned unchanged.
+func NormalizeFinishReason(reason string) FinishReason {
+	if normalized, ok := finishReasonVariants[strings.ToLower(reason)]; ok {
+		return normalized
+	}
+	return FinishReason(reason)
+}
+
+func (r *FinishReason) UnmarshalJSON(data []byte) error {
+	var reason *string
+	if err := json.Unmarshal(data, &reason); err != nil {
+		return err
+	}
+	if reason == nil {
+		*r = ""
+		return nil
+	}
+	*r = NormalizeFinishReason(*reason)
+	return nil
+}
+
+// IsTruncated reports whether the generation was cut by the token limit.
+func (r FinishReason) IsTruncated() bool {
+	return r == FinishReasonLength
+}
+
+// IsToolCalls reports whether the model stopped to call tools.
+func (r FinishReason) IsToolCalls() bool {
+	return r == FinishReasonToolCalls
+}
+
+// IsFiltered reports whether the generation was stopped by a content filter.
+func (r FinishReason) IsFiltered() bool {
+	return r == FinishReasonContentFilter
+}
+
+// IsError reports whether the generation failed.
+func (r FinishReason) IsError() bool {
+	return r == FinishReasonError
+}
@@ -0,0 +1,50 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNormalizeFinishReason(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   FinishReason
+	}{
+		{"stop", FinishReasonStop},
+		{"end_turn", FinishReasonStop},
+		{"STOP", FinishReasonStop},
+		{"MAX_TOKENS", FinishReasonLength},
+		{"tool_use", FinishReasonToolCalls},
+		{"SAFETY", FinishReasonContentFilter},
+		{"error", FinishReasonError},
+		{"something_new", "something_new"},
+	}
+	for _, test := range tests {
+		if got := NormalizeFinishReason(test.reason); got != test.want {
+			t.Errorf("NormalizeFinishReason(%q) = %q, want %q", test.reason, got, test.want)
+		}
+	}
+}
+
+func TestFinishReason_UnmarshalJSON(t *testing.T) {
+	var resp ChatCompletionResponse
+	body := `{"choices":[{"finish_reason":"max_tokens","native_finish_reason":"MAX_TOKENS"},{"finish_reason":null},` +
+		`{"finish_reason":"tool_use"},{"finish_reason":"recitation"}]}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	truncated := resp.Choices[0]
+	if !truncated.FinishReason.IsTruncated() || truncated.NativeFinishReason != "MAX_TOKENS" {
+		t.Errorf("unexpected choice %+v", truncated)
+	}
+	if resp.Choices[1].FinishReason != "" {
+		t.Errorf("expected a null finish reason to be empty, got %q", resp.Choices[1].FinishReason)
+	}
+	if !resp.Choices[2].FinishReason.IsToolCalls() || !resp.Choices[3].FinishReason.IsFiltered() {
+		t.Errorf("unexpected finish reasons %+v", resp.Choices)
+	}
+	if resp.Choices[2].FinishReason.IsError() || FinishReasonError.IsTruncated() || !FinishReasonError.IsError() {
+		t.Error("unexpected predicates")
+	}
+}
@@ -0,0 +1,40 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrModelUnavailable = errors.New("model has no live endpoint")
+)
+
+// Ping checks that the API is reachable and the API key valid with a cheap
+// authenticated call, for readiness checks. It isn't retried.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.probe().GetKeyInfo(ctx)
+	return err
+}
+
+// CheckModel checks that model, as "author/slug", currently has at least one
+// live endpoint, endpoints with a negative status being down. It isn't
+// retried, and returns an error wrapping ErrModelUnavailable if every endpoint
+// is down.
+func (c *Client) CheckModel(ctx context.Context, model string) error {
+	endpoints, err := c.probe().ListModelEndpoints(ctx, model)
+	if err != nil {
+		return err
+	}
+	for _, endpoint := range endpoints.Endpoints {
+		if endpoint.Status >= 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrModelUnavailable, model)
+}
+
+// probe returns a client failing fast, without retries.
+func (c *Client) probe() *Client {
+	return c.WithConfig(func(config *ClientConfig) { config.Retry.MaxRetries = -1 })
+}
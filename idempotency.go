@@ -0,0 +1,111 @@
+package openrouter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyKeyCtxKey struct{}
+
+// WithIdempotencyKey attaches an idempotency key to the requests made with ctx.
+// It is sent in the Idempotency-Key header and, when request deduplication is
+// enabled, used as the deduplication key.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtxKey{}, key)
+}
+
+func idempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyCtxKey{}).(string)
+	return key
+}
+
+func setIdempotencyHeader(req *http.Request) {
+	if key := idempotencyKey(req.Context()); key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+}
+
+// tenant identifies whom a request is made for: its credentials and its spend
+// key. The requests of a key pool share the identity of the pool.
+func (c *Client) tenant(ctx context.Context) string {
+	var credentials string
+	switch {
+	case c.config.AuthProvider != nil:
+		credentials, _ = c.config.AuthProvider.Token(ctx)
+	case c.config.KeyPool != nil:
+		credentials = fmt.Sprintf("pool:%p", c.config.KeyPool)
+	default:
+		credentials = c.config.authToken
+	}
+	return credentials + "\x00" + spendKey(ctx)
+}
+
+// tenantKey scopes key to the tenant of ctx, so that the deduplication and the
+// response cache never share a response across credentials or spend keys.
+func (c *Client) tenantKey(ctx context.Context, key string) string {
+	sum := sha256.Sum256([]byte(c.tenant(ctx) + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// deduplicate runs send once for concurrent identical requests when
+// ClientConfig.DeduplicateRequests is set. Requests are identical when they
+// share an idempotency key or, without key, the same request hash, and are
+// made for the same tenant (see tenantKey). The shared call runs with the
+// context of the first caller, which alone goes through the bookkeeping of
+// send (spend, circuit, cache); the other callers get a deep copy of its
+// response.
+func (c *Client) deduplicate(
+	ctx context.Context,
+	request *ChatCompletionRequest,
+	send func() (*ChatCompletionResponse, error),
+) (*ChatCompletionResponse, error) {
+	if !c.config.DeduplicateRequests {
+		return send()
+	}
+	key := idempotencyKey(ctx)
+	if key == "" {
+		hash, err := requestHash(request)
+		if err != nil {
+			return send()
+		}
+		key = hash
+	}
+
+	v, err, shared := c.inflight.Do(c.tenantKey(ctx, key), func() (any, error) {
+		return send()
+	})
+	response, _ := v.(*ChatCompletionResponse)
+	if shared && response != nil {
+		return cloneResponse(response)
+	}
+	return response, err
+}
+
+// cloneResponse returns a deep copy of response, the fields not marshaled
+// included.
+func cloneResponse(response *ChatCompletionResponse) (*ChatCompletionResponse, error) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	var clone ChatCompletionResponse
+	if err = json.Unmarshal(data, &clone); err != nil {
+		return nil, err
+	}
+	if response.Warning != nil {
+		warning := *response.Warning
+		clone.Warning = &warning
+	}
+	if response.Timings != nil {
+		timings := *response.Timings
+		clone.Timings = &timings
+	}
+	clone.RouterArm = response.RouterArm
+	return &clone, nil
+}
@@ -0,0 +1,49 @@
+package openrouter
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidDataURL = errors.New("invalid base64 data URL")
+)
+
+// DecodeDataURL decodes a base64 data URL ("data:image/png;base64,...") and
+// returns the raw bytes together with its media type.
+func DecodeDataURL(dataURL string) (data []byte, mediaType string, err error) {
+	rest, ok := strings.CutPrefix(dataURL, "data:")
+	if !ok {
+		return nil, "", ErrInvalidDataURL
+	}
+	meta, payload, ok := strings.Cut(rest, ",")
+	if !ok {
+		return nil, "", ErrInvalidDataURL
+	}
+	mediaType, ok = strings.CutSuffix(meta, ";base64")
+	if !ok {
+		return nil, "", ErrInvalidDataURL
+	}
+	data, err = base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, mediaType, nil
+}
+
+// DecodeImages decodes the generated images of an assistant message.
+func (m Index) DecodeImages() ([][]byte, error) {
+	images := make([][]byte, 0, len(m.Images))
+	for _, part := range m.Images {
+		if part.ImageURL == nil {
+			continue
+		}
+		data, _, err := DecodeDataURL(part.ImageURL.URL)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, data)
+	}
+	return images, nil
+}
@@ -0,0 +1,48 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestDecodeDataURL(t *testing.T) {
+	data, mediaType, err := DecodeDataURL(ImageDataURL([]byte("png"), "image/png"))
+	if err != nil || string(data) != "png" || mediaType != "image/png" {
+		t.Errorf("got %q, %q, %v", data, mediaType, err)
+	}
+	for _, dataURL := range []string{"https://example.com/a.png", "data:image/png,raw", "data:image/png;base64"} {
+		if _, _, err = DecodeDataURL(dataURL); !errors.Is(err, ErrInvalidDataURL) {
+			t.Errorf("DecodeDataURL(%q): expected ErrInvalidDataURL, got %v", dataURL, err)
+		}
+	}
+}
+
+func TestClient_ImageOutput(t *testing.T) {
+	var modalities []Modality
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var request ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		modalities = request.Modalities
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"here",`+
+			`"images":[{"type":"image_url","image_url":{"url":"data:image/png;base64,cG5n"}}]}}]}`)
+	})
+
+	resp, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{
+		Model:      "google/gemini-2.5-flash-image-preview",
+		Modalities: []Modality{ModalityImage, ModalityText},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(modalities) != 2 || modalities[0] != ModalityImage {
+		t.Errorf("unexpected modalities %v", modalities)
+	}
+	images, err := resp.Choices[0].Message.DecodeImages()
+	if err != nil || len(images) != 1 || string(images[0]) != "png" {
+		t.Errorf("got %q, %v", images, err)
+	}
+}
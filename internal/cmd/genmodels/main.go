@@ -0,0 +1,167 @@
+// Command genmodels generates the model ID constants and the model metadata of
+// the OpenRouter catalog. It is run by go generate from the repository root:
+//
+//	go generate ./...
+//
+// The catalog is fetched from the models API, or read from a file with -input.
+// The committed models_gen.go is generated from the snapshot of testdata:
+//
+//	go run ./internal/cmd/genmodels -input internal/cmd/genmodels/testdata/catalog.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const modelsURL = "https://openrouter.ai/api/v1/models"
+
+type catalog struct {
+	Data []model `json:"data"`
+}
+
+type model struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	ContextLength int    `json:"context_length"`
+	Pricing       struct {
+		Prompt     string `json:"prompt"`
+		Completion string `json:"completion"`
+	} `json:"pricing"`
+	Architecture struct {
+		Tokenizer string `json:"tokenizer"`
+	} `json:"architecture"`
+	SupportedParameters []string `json:"supported_parameters"`
+}
+
+func main() {
+	output := flag.String("o", "models_gen.go", "output file")
+	input := flag.String("input", "", "read the catalog from this file instead of the API")
+	flag.Parse()
+
+	data, err := readCatalog(*input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var models catalog
+	if err = json.Unmarshal(data, &models); err != nil {
+		log.Fatalf("failed to decode catalog: %v", err)
+	}
+
+	src, err := generate(models.Data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(*output, src, 0o644); err != nil { //nolint:gosec // generated source file
+		log.Fatal(err)
+	}
+}
+
+func readCatalog(input string) ([]byte, error) {
+	if input != "" {
+		return os.ReadFile(input)
+	}
+	resp, err := http.Get(modelsURL) //nolint:noctx // one-shot generator
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("models API returned %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func generate(models []model) ([]byte, error) {
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	models = slices.CompactFunc(models, func(a, b model) bool { return a.ID == b.ID })
+
+	byVendor := make(map[string][]model)
+	var vendors []string
+	for _, m := range models {
+		vendor, _, _ := strings.Cut(m.ID, "/")
+		if _, ok := byVendor[vendor]; !ok {
+			vendors = append(vendors, vendor)
+		}
+		byVendor[vendor] = append(byVendor[vendor], m)
+	}
+
+	names := constantNames(models)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by internal/cmd/genmodels; DO NOT EDIT.\n\n")
+	b.WriteString("package openrouter\n\n")
+	for _, vendor := range vendors {
+		fmt.Fprintf(&b, "// Models by %s.\nconst (\n", vendor)
+		for _, m := range byVendor[vendor] {
+			fmt.Fprintf(&b, "\t%s = %q\n", names[m.ID], m.ID)
+		}
+		b.WriteString(")\n\n")
+	}
+
+	b.WriteString("// ModelCatalog is the snapshot of the models catalog taken at generation time.\n")
+	b.WriteString("var ModelCatalog = map[string]Model{\n")
+	for _, m := range models {
+		fmt.Fprintf(&b, "\t%s: {\n", names[m.ID])
+		fmt.Fprintf(&b, "\t\tID: %q,\n\t\tName: %q,\n\t\tContextLength: %d,\n", m.ID, m.Name, m.ContextLength)
+		fmt.Fprintf(&b, "\t\tArchitecture: ModelArchitecture{Tokenizer: %q},\n", m.Architecture.Tokenizer)
+		fmt.Fprintf(&b, "\t\tPricing: ModelPricing{Prompt: %q, Completion: %q},\n", m.Pricing.Prompt, m.Pricing.Completion)
+		if len(m.SupportedParameters) > 0 {
+			fmt.Fprintf(&b, "\t\tSupportedParameters: %#v,\n", m.SupportedParameters)
+		}
+		b.WriteString("\t},\n")
+	}
+	b.WriteString("}\n")
+
+	return format.Source(b.Bytes())
+}
+
+// constantNames returns the constant name of each model ID of models, sorted
+// by ID. IDs sanitized to the same identifier get a numeric suffix.
+func constantNames(models []model) map[string]string {
+	names := make(map[string]string, len(models))
+	used := make(map[string]bool, len(models))
+	for _, m := range models {
+		if _, ok := names[m.ID]; ok {
+			continue
+		}
+		name := identifier(m.ID)
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s%d", identifier(m.ID), i)
+		}
+		used[name] = true
+		names[m.ID] = name
+	}
+	return names
+}
+
+// identifier turns a model ID into a Go identifier: "openai/gpt-4o-mini" becomes
+// "ModelOpenaiGpt4oMini".
+func identifier(id string) string {
+	var b strings.Builder
+	b.WriteString("Model")
+	upper := true
+	for _, r := range id {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
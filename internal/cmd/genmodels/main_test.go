@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConstantNames(t *testing.T) {
+	names := constantNames([]model{
+		{ID: "openai/gpt-4o-mini"},
+		{ID: "meta-llama/llama-3.1-8b-instruct:free"},
+		{ID: "x-ai/grok-2"},
+		{ID: "x-ai/grok.2"},
+		{ID: "x-ai/grok-2"},
+	})
+	want := map[string]string{
+		"openai/gpt-4o-mini":                    "ModelOpenaiGpt4oMini",
+		"meta-llama/llama-3.1-8b-instruct:free": "ModelMetaLlamaLlama318bInstructFree",
+		"x-ai/grok-2":                           "ModelXAiGrok2",
+		"x-ai/grok.2":                           "ModelXAiGrok22",
+	}
+	if len(names) != len(want) {
+		t.Errorf("unexpected names %v", names)
+	}
+	for id, name := range want {
+		if names[id] != name {
+			t.Errorf("got %s for %s, want %s", names[id], id, name)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := generate([]model{{ID: "openai/gpt-4"}, {ID: "openai/gpt-4"}, {ID: "anthropic/claude-3.5-sonnet"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(src), `= "openai/gpt-4"`) != 1 || !strings.Contains(string(src), "map[string]Model{") {
+		t.Errorf("unexpected source\n%s", src)
+	}
+}
+
+// TestGenerate_Snapshot checks that models_gen.go is up to date with the
+// catalog snapshot of testdata.
+func TestGenerate_Snapshot(t *testing.T) {
+	data, err := os.ReadFile("testdata/catalog.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var models catalog
+	if err = json.Unmarshal(data, &models); err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(models.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := os.ReadFile("../../../models_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != string(committed) {
+		t.Error("models_gen.go is out of date with testdata/catalog.json")
+	}
+}
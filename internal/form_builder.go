@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FormFile is a file of a multipart form. ContentType is detected from the
+// file name extension, then from the content, when empty.
+type FormFile struct {
+	FieldName   string
+	FileName    string
+	ContentType string
+	Reader      io.Reader
+}
+
+// MultipartForm is the body of a multipart/form-data request.
+type MultipartForm struct {
+	Fields map[string]string
+	Files  []FormFile
+}
+
+// BuildMultipart encodes form once. The body of the returned request can be
+// replayed with its GetBody, like the body of Build.
+func (b *HTTPRequestBuilder) BuildMultipart(
+	ctx context.Context,
+	method, url string,
+	form MultipartForm,
+) (*http.Request, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	names := make([]string, 0, len(form.Fields))
+	for name := range form.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writer.WriteField(name, form.Fields[name]); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range form.Files {
+		if err := writeFormFile(writer, file); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req, nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func writeFormFile(writer *multipart.Writer, file FormFile) error {
+	reader := bufio.NewReader(file.Reader)
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(file.FileName))
+	}
+	if contentType == "" {
+		// Peek returns the available bytes along with io.EOF for short files
+		head, _ := reader.Peek(512)
+		contentType = http.DetectContentType(head)
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(file.FieldName), quoteEscaper.Replace(file.FileName)))
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, reader)
+	return err
+}
@@ -3,11 +3,13 @@ package internal
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 )
 
 type RequestBuilder interface {
 	Build(ctx context.Context, method, url string, request any) (*http.Request, error)
+	BuildMultipart(ctx context.Context, method, url string, form MultipartForm) (*http.Request, error)
 }
 
 type HTTPRequestBuilder struct {
@@ -20,20 +22,25 @@ func NewRequestBuilder() *HTTPRequestBuilder {
 	}
 }
 
+// Build marshals request once, a json.RawMessage being sent as is. The body of
+// the returned request can be replayed with its GetBody, e.g. to send it again
+// on retries.
 func (b *HTTPRequestBuilder) Build(ctx context.Context, method, url string, request any) (*http.Request, error) {
 	if request == nil {
 		return http.NewRequestWithContext(ctx, method, url, nil)
 	}
 
-	var reqBytes []byte
-	reqBytes, err := b.marshaller.Marshal(request)
-	if err != nil {
-		return nil, err
+	reqBytes, ok := request.(json.RawMessage)
+	if !ok {
+		var err error
+		if reqBytes, err = b.marshaller.Marshal(request); err != nil {
+			return nil, err
+		}
 	}
 	return http.NewRequestWithContext(
 		ctx,
 		method,
 		url,
-		bytes.NewBuffer(reqBytes),
+		bytes.NewReader(reqBytes),
 	)
 }
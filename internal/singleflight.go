@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"fmt"
+	"sync"
+)
+
+type call struct {
+	done chan struct{}
+	val  any
+	err  error
+}
+
+// SingleFlight collapses concurrent calls sharing a key into a single execution.
+type SingleFlight struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// Do executes fn once for all the concurrent callers of key and hands them its
+// result. shared reports whether the caller got the result of another caller's
+// execution. A panic of fn is returned as an error to all the callers.
+func (g *SingleFlight) Do(key string, fn func() (any, error)) (v any, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-c.done
+		return c.val, c.err, true
+	}
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			c.val, c.err = nil, fmt.Errorf("singleflight: call panicked: %v", r)
+		}
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+		v, err, shared = c.val, c.err, false
+	}()
+	c.val, c.err = fn()
+	return c.val, c.err, false
+}
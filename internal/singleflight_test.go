@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSingleFlight_Panic(t *testing.T) {
+	var g SingleFlight
+	started, release := make(chan struct{}), make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err, _ := g.Do("key", func() (any, error) {
+			close(started)
+			<-release
+			panic("boom")
+		}); err == nil {
+			t.Error("expected the panic as error")
+		}
+	}()
+	<-started
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err, shared := g.Do("key", func() (any, error) { return "fresh", nil })
+		if !shared {
+			err = nil
+		}
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if err := <-errs; err == nil {
+		t.Error("expected the waiter to get the panic as error")
+	}
+	wg.Wait()
+
+	if v, err, _ := g.Do("key", func() (any, error) { return "fresh", nil }); err != nil || v != "fresh" {
+		t.Errorf("expected the key to be released, got %v, %v", v, err)
+	}
+}
@@ -1,15 +1,39 @@
 package internal
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 )
 
 type Unmarshaler interface {
 	Unmarshal(data []byte, v any) error
 }
 
-type JSONUnmarshaler struct{}
+// JSONUnmarshaler decodes JSON, optionally rejecting the fields unknown to v
+// and decoding the untyped numbers as json.Number rather than float64.
+type JSONUnmarshaler struct {
+	DisallowUnknownFields bool
+	UseNumber             bool
+}
 
 func (jm *JSONUnmarshaler) Unmarshal(data []byte, v any) error {
-	return json.Unmarshal(data, v)
+	if !jm.DisallowUnknownFields && !jm.UseNumber {
+		return json.Unmarshal(data, v)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if jm.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if jm.UseNumber {
+		decoder.UseNumber()
+	}
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
 }
@@ -0,0 +1,281 @@
+package openrouter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJobWorkers      = 4
+	defaultJobQueueSize    = 1024
+	defaultJobPollInterval = 2 * time.Second
+	defaultJobMaxPolls     = 30
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+)
+
+// JobStatus is the state of a Job.
+type JobStatus string
+
+const (
+	// JobPending jobs wait for a worker.
+	JobPending JobStatus = "pending"
+	// JobRunning jobs have their request in flight.
+	JobRunning JobStatus = "running"
+	// JobCompleted jobs have their response, their generation stats are polled.
+	JobCompleted JobStatus = "completed"
+	// JobReconciled jobs have their response and generation stats.
+	JobReconciled JobStatus = "reconciled"
+	// JobFailed jobs failed, see Job.Error.
+	JobFailed JobStatus = "failed"
+)
+
+// Job is a chat completion submitted to a JobManager.
+type Job struct {
+	ID      string                 `json:"id"`
+	Request *ChatCompletionRequest `json:"request"`
+	Status  JobStatus              `json:"status"`
+	// GenerationID is the ID of the response, used to fetch the generation stats.
+	GenerationID string                  `json:"generation_id,omitempty"`
+	Response     *ChatCompletionResponse `json:"response,omitempty"`
+	Generation   *Generation             `json:"generation,omitempty"`
+	Error        string                  `json:"error,omitempty"`
+	CreatedAt    time.Time               `json:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+}
+
+// JobStore persists jobs so that a JobManager can resume them after a restart.
+type JobStore interface {
+	Save(ctx context.Context, job *Job) error
+	// Load returns ErrJobNotFound for unknown jobs.
+	Load(ctx context.Context, id string) (*Job, error)
+	// List returns the jobs with one of the statuses, or all the jobs if none.
+	List(ctx context.Context, statuses ...JobStatus) ([]*Job, error)
+}
+
+// MemoryJobStore is an in-memory JobStore, jobs don't survive the process.
+type MemoryJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{jobs: make(map[string]Job)}
+}
+
+func (s *MemoryJobStore) Save(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+func (s *MemoryJobStore) Load(_ context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	return &job, nil
+}
+
+func (s *MemoryJobStore) List(_ context.Context, statuses ...JobStatus) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []*Job
+	for _, job := range s.jobs {
+		if len(statuses) == 0 || slices.Contains(statuses, job.Status) {
+			jobs = append(jobs, &job)
+		}
+	}
+	return jobs, nil
+}
+
+// JobManager runs chat completions in the background: submitted jobs are saved
+// to Store, sent by a pool of workers, then reconciled with their generation
+// stats (native token counts, cost) once available. Jobs interrupted by a
+// restart are resumed by Start; a job whose request was in flight is sent
+// again, with its ID as idempotency key.
+type JobManager struct {
+	Store JobStore
+	// Workers is the number of concurrent requests, defaults to 4.
+	Workers int
+	// PollInterval is the interval between the polls of the generation stats,
+	// which are available shortly after the response, defaults to 2 seconds.
+	PollInterval time.Duration
+	// MaxPolls bounds the polls of a job, defaults to 30. A job still without
+	// stats stays completed and is polled again by the next Start.
+	MaxPolls int
+	// OnUpdate, if set, is called after each change of a job. It may be called
+	// concurrently.
+	OnUpdate func(job Job)
+
+	client *Client
+	queue  chan *Job
+	wg     sync.WaitGroup
+}
+
+func NewJobManager(client *Client, store JobStore) *JobManager {
+	return &JobManager{client: client, Store: store, queue: make(chan *Job, defaultJobQueueSize)}
+}
+
+// Start resumes the unfinished jobs of the store and runs the workers until
+// ctx is done, see Wait.
+func (m *JobManager) Start(ctx context.Context) error {
+	jobs, err := m.Store.List(ctx, JobPending, JobRunning, JobCompleted)
+	if err != nil {
+		return err
+	}
+	workers := m.Workers
+	if workers <= 0 {
+		workers = defaultJobWorkers
+	}
+	for range workers {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-m.queue:
+					m.run(ctx, job)
+				}
+			}
+		}()
+	}
+	for _, job := range jobs {
+		if err = m.enqueue(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Wait waits for the workers to return once the context of Start is done.
+func (m *JobManager) Wait() {
+	m.wg.Wait()
+}
+
+// Submit saves a pending job for request and queues it. id identifies the job,
+// a random one is generated if empty. Submit blocks while the queue is full.
+// The returned job is a snapshot, see Get for its later states.
+func (m *JobManager) Submit(ctx context.Context, id string, request *ChatCompletionRequest) (*Job, error) {
+	if id == "" {
+		id = newJobID()
+	}
+	now := time.Now()
+	job := &Job{ID: id, Request: request, Status: JobPending, CreatedAt: now, UpdatedAt: now}
+	if err := m.save(ctx, job); err != nil {
+		return nil, err
+	}
+	snapshot := *job
+	if err := m.enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// Get returns a snapshot of the current state of a job, as saved to the store.
+func (m *JobManager) Get(ctx context.Context, id string) (*Job, error) {
+	return m.Store.Load(ctx, id)
+}
+
+func (m *JobManager) enqueue(ctx context.Context, job *Job) error {
+	select {
+	case m.queue <- job:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *JobManager) run(ctx context.Context, job *Job) {
+	if job.Status != JobCompleted {
+		job.Status = JobRunning
+		if m.save(ctx, job) != nil {
+			return
+		}
+		request := *job.Request
+		response, err := m.client.CreateChatCompletion(WithIdempotencyKey(ctx, job.ID), &request)
+		if ctx.Err() != nil {
+			// interrupted, the job stays running and is resumed by the next Start
+			return
+		}
+		if err != nil {
+			job.Status, job.Error = JobFailed, err.Error()
+			_ = m.save(ctx, job)
+			return
+		}
+		job.Status, job.Response, job.GenerationID = JobCompleted, response, response.ID
+		if m.save(ctx, job) != nil {
+			return
+		}
+	}
+	m.reconcile(ctx, job)
+}
+
+// reconcile polls the generation stats of a completed job.
+func (m *JobManager) reconcile(ctx context.Context, job *Job) {
+	if job.GenerationID == "" {
+		return
+	}
+	interval := m.PollInterval
+	if interval <= 0 {
+		interval = defaultJobPollInterval
+	}
+	maxPolls := m.MaxPolls
+	if maxPolls <= 0 {
+		maxPolls = defaultJobMaxPolls
+	}
+	// stats are not ready right away, and a missing generation answers 404:
+	// poll without the retries of the client
+	client := m.client.probe()
+	for range maxPolls {
+		if sleepContext(ctx, interval) != nil {
+			return
+		}
+		generation, err := client.GetGeneration(ctx, job.GenerationID)
+		if err == nil {
+			job.Status, job.Generation, job.Error = JobReconciled, &generation, ""
+			if job.Response != nil && job.Response.Usage != nil {
+				// the response is shared with the snapshots returned so far
+				response, usage := *job.Response, *job.Response.Usage
+				usage.Native = generation.NativeUsage()
+				response.Usage = &usage
+				job.Response = &response
+			}
+			_ = m.save(ctx, job)
+			return
+		}
+		job.Error = err.Error()
+	}
+	_ = m.save(ctx, job)
+}
+
+func (m *JobManager) save(ctx context.Context, job *Job) error {
+	job.UpdatedAt = time.Now()
+	if err := m.Store.Save(ctx, job); err != nil {
+		m.client.logger().Printf("Failed to save job %s: %v", job.ID, err)
+		return err
+	}
+	if m.OnUpdate != nil {
+		m.OnUpdate(*job)
+	}
+	return nil
+}
+
+func newJobID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,101 @@
+package openrouter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJobManager(t *testing.T) {
+	var generationPolls atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chat/completions":
+			if r.Header.Get("Idempotency-Key") != "job-1" {
+				t.Errorf("unexpected idempotency key %q", r.Header.Get("Idempotency-Key"))
+			}
+			_, _ = io.WriteString(w, `{"id":"gen-1","choices":[{"message":{"content":"ok"}}],`+
+				`"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}`)
+		case "/generation":
+			if generationPolls.Add(1) == 1 {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = io.WriteString(w, `{"error":{"code":404,"message":"Generation not found"}}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"data":{"id":"gen-1","total_cost":0.002,"native_tokens_completion":3}}`)
+		}
+	})
+	store := NewMemoryJobStore()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	manager := NewJobManager(client, store)
+	manager.PollInterval = time.Millisecond
+	reconciled := make(chan Job, 1)
+	manager.OnUpdate = func(job Job) {
+		if job.Status == JobReconciled {
+			reconciled <- job
+		}
+	}
+	if err := manager.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	submitted, err := manager.Submit(ctx, "job-1", &ChatCompletionRequest{Model: Gpt4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the snapshot can be read while the job runs
+	if submitted.Status != JobPending || submitted.Response != nil {
+		t.Errorf("unexpected submitted job %+v", submitted)
+	}
+
+	select {
+	case job := <-reconciled:
+		if job.GenerationID != "gen-1" || job.Generation.TotalCost != 0.002 ||
+			job.Response.Choices[0].Message.Content != "ok" || job.Response.Usage.Native.CompletionTokens != 3 {
+			t.Errorf("unexpected job %+v", job)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("job not reconciled")
+	}
+	cancel()
+	manager.Wait()
+
+	job, err := manager.Get(context.Background(), "job-1")
+	if err != nil || job.Status != JobReconciled || job.Error != "" {
+		t.Errorf("unexpected stored job %+v, %v", job, err)
+	}
+}
+
+func TestJobManager_ResumesCompletedJobs(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/generation" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		_, _ = io.WriteString(w, `{"data":{"id":"gen-2","total_cost":0.001}}`)
+	})
+	store := NewMemoryJobStore()
+	_ = store.Save(context.Background(), &Job{
+		ID: "job-2", Request: &ChatCompletionRequest{}, Status: JobCompleted, GenerationID: "gen-2",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	manager := NewJobManager(client, store)
+	manager.PollInterval = time.Millisecond
+	reconciled := make(chan Job, 1)
+	manager.OnUpdate = func(job Job) { reconciled <- job }
+	if err := manager.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case job := <-reconciled:
+		if job.Status != JobReconciled || job.Generation.TotalCost != 0.001 {
+			t.Errorf("unexpected job %+v", job)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("job not resumed")
+	}
+}
@@ -0,0 +1,35 @@
+// Package jsonschema builds the JSON schemas used by tool definitions and
+// structured outputs, and validates JSON documents against them.
+package jsonschema
+
+type DataType string
+
+const (
+	Object  DataType = "object"
+	Number  DataType = "number"
+	Integer DataType = "integer"
+	String  DataType = "string"
+	Array   DataType = "array"
+	Boolean DataType = "boolean"
+	Null    DataType = "null"
+)
+
+// Definition is a JSON schema. It covers the subset of the specification
+// supported by the models.
+type Definition struct {
+	Type        DataType               `json:"type,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Format      string                 `json:"format,omitempty"`
+	Enum        []string               `json:"enum,omitempty"`
+	Properties  map[string]*Definition `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Items       *Definition            `json:"items,omitempty"`
+	Minimum     *float64               `json:"minimum,omitempty"`
+	Maximum     *float64               `json:"maximum,omitempty"`
+	// ContentEncoding is "base64" for the strings encoding []byte values.
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	// AdditionalProperties is a bool or a *Definition.
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+	// AnyOf is matched by the values matching at least one of its schemas.
+	AnyOf []*Definition `json:"anyOf,omitempty"`
+}
@@ -0,0 +1,236 @@
+package jsonschema
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+// Reflect generates the schema of the Go value v, typically a struct of
+// parameters. Properties are named after the json tags and the fields of
+// embedded structs are promoted, as encoding/json does. A field is required
+// unless it is a pointer or tagged omitempty, or is tagged jsonschema:"required";
+// pointer fields also accept null. []byte values are base64 strings.
+// The jsonschema tag also accepts description=..., enum=... (repeatable),
+// minimum=... and maximum=..., separated by commas; escape commas inside
+// values as \,.
+func Reflect(v any) (*Definition, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("jsonschema: can't reflect nil")
+	}
+	return reflectType(t, map[reflect.Type]bool{})
+}
+
+func reflectType(t reflect.Type, visiting map[reflect.Type]bool) (*Definition, error) {
+	t = derefType(t)
+	if t == timeType {
+		return &Definition{Type: String, Format: "date-time"}, nil
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return &Definition{Type: String, ContentEncoding: "base64"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Definition{Type: String}, nil
+	case reflect.Bool:
+		return &Definition{Type: Boolean}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Definition{Type: Integer}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Definition{Type: Number}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := reflectType(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &Definition{Type: Array, Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("jsonschema: unsupported map key type %s", t.Key())
+		}
+		values, err := reflectType(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &Definition{Type: Object, AdditionalProperties: values}, nil
+	case reflect.Interface:
+		return &Definition{}, nil
+	case reflect.Struct:
+		return reflectStruct(t, visiting)
+	default:
+		return nil, fmt.Errorf("jsonschema: unsupported type %s", t)
+	}
+}
+
+func reflectStruct(t reflect.Type, visiting map[reflect.Type]bool) (*Definition, error) {
+	def := &Definition{
+		Type:                 Object,
+		Properties:           make(map[string]*Definition),
+		AdditionalProperties: false,
+	}
+	if err := reflectFields(def, t, false, visiting); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// reflectFields adds the properties of the fields of the struct t to def. The
+// fields of embedded structs are added after the fields of t, which shadow
+// them. The promoted fields of an embedded pointer are optional.
+func reflectFields(def *Definition, t reflect.Type, optional bool, visiting map[reflect.Type]bool) error {
+	if visiting[t] {
+		return fmt.Errorf("jsonschema: recursive type %s", t)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	var embedded []reflect.StructField
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			if embeddedType := derefType(field.Type); embeddedType.Kind() == reflect.Struct {
+				embedded = append(embedded, field)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name, omitempty, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		prop, err := reflectType(field.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("jsonschema: field %s: %w", field.Name, err)
+		}
+		required := !optional && !omitempty && field.Type.Kind() != reflect.Pointer
+		if required, err = applyTag(prop, field.Tag.Get("jsonschema"), required); err != nil {
+			return fmt.Errorf("jsonschema: field %s: %w", field.Name, err)
+		}
+		if field.Type.Kind() == reflect.Pointer {
+			prop = nullable(prop)
+		}
+
+		def.Properties[name] = prop
+		if required {
+			def.Required = append(def.Required, name)
+		}
+	}
+
+	for _, field := range embedded {
+		promoted := &Definition{Properties: make(map[string]*Definition)}
+		pointer := field.Type.Kind() == reflect.Pointer
+		if err := reflectFields(promoted, derefType(field.Type), optional || pointer, visiting); err != nil {
+			return fmt.Errorf("jsonschema: field %s: %w", field.Name, err)
+		}
+		for name, prop := range promoted.Properties {
+			if _, ok := def.Properties[name]; !ok {
+				def.Properties[name] = prop
+			}
+		}
+		for _, name := range promoted.Required {
+			if !slices.Contains(def.Required, name) && def.Properties[name] == promoted.Properties[name] {
+				def.Required = append(def.Required, name)
+			}
+		}
+	}
+	return nil
+}
+
+// nullable returns a schema accepting null or the values of def, which keeps
+// its description.
+func nullable(def *Definition) *Definition {
+	wrapped := &Definition{Description: def.Description, AnyOf: []*Definition{def, {Type: Null}}}
+	def.Description = ""
+	return wrapped
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func jsonName(field reflect.StructField) (name string, omitempty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
+
+func applyTag(def *Definition, tag string, required bool) (bool, error) {
+	for _, opt := range splitTag(tag) {
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "":
+		case "required":
+			required = true
+		case "optional":
+			required = false
+		case "description":
+			def.Description = value
+		case "enum":
+			def.Enum = append(def.Enum, value)
+		case "format":
+			def.Format = value
+		case "minimum", "maximum":
+			bound, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return required, fmt.Errorf("invalid %s %q", key, value)
+			}
+			if key == "minimum" {
+				def.Minimum = &bound
+			} else {
+				def.Maximum = &bound
+			}
+		default:
+			return required, fmt.Errorf("unknown jsonschema tag option %q", key)
+		}
+	}
+	return required, nil
+}
+
+// splitTag splits a tag on commas, honoring \, escapes.
+func splitTag(tag string) []string {
+	var (
+		opts    []string
+		current strings.Builder
+	)
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			current.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			opts = append(opts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(tag[i])
+		}
+	}
+	return append(opts, current.String())
+}
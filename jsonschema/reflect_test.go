@@ -0,0 +1,103 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type Base struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type audit struct {
+	Author string `json:"author"`
+}
+
+type Extra struct {
+	Note string `json:"note"`
+}
+
+func TestReflect(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{
+			name: "embedded structs are flattened",
+			value: struct {
+				Base
+				audit
+				Name string `json:"name,omitempty"`
+			}{},
+			want: `{"type":"object","properties":{"author":{"type":"string"},"id":{"type":"string"},"name":{"type":"string"}},"required":["id","author"],"additionalProperties":false}`,
+		},
+		{
+			name: "embedded pointer fields are optional",
+			value: struct {
+				*Extra
+			}{},
+			want: `{"type":"object","properties":{"note":{"type":"string"}},"additionalProperties":false}`,
+		},
+		{
+			name: "tagged embedded struct is a property",
+			value: struct {
+				Base `json:"base"`
+			}{},
+			want: `{"type":"object","properties":{"base":{"type":"object","properties":{"id":{"type":"string"},"name":{"type":"string"}},"required":["id","name"],"additionalProperties":false}},"required":["base"],"additionalProperties":false}`,
+		},
+		{
+			name: "bytes are base64 strings",
+			value: struct {
+				Data []byte `json:"data"`
+			}{},
+			want: `{"type":"object","properties":{"data":{"type":"string","contentEncoding":"base64"}},"required":["data"],"additionalProperties":false}`,
+		},
+		{
+			name: "pointer fields accept null",
+			value: struct {
+				Limit *int `json:"limit" jsonschema:"description=max results,minimum=1"`
+			}{},
+			want: `{"type":"object","properties":{"limit":{"description":"max results","anyOf":[{"type":"integer","minimum":1},{"type":"null"}]}},"additionalProperties":false}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def, err := Reflect(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.Marshal(def)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_Nullable(t *testing.T) {
+	def, err := Reflect(struct {
+		Limit *int `json:"limit,omitempty"`
+	}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		doc   string
+		valid bool
+	}{
+		{`{}`, true},
+		{`{"limit":null}`, true},
+		{`{"limit":3}`, true},
+		{`{"limit":"3"}`, false},
+	}
+	for _, tt := range tests {
+		if err := Validate(def, []byte(tt.doc)); (err == nil) != tt.valid {
+			t.Errorf("Validate(%s) = %v, want valid %v", tt.doc, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// ValidationError lists the violations of a document, each prefixed with the
+// path of the offending value.
+type ValidationError struct {
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return "jsonschema: " + strings.Join(e.Violations, "; ")
+}
+
+// Validate checks the JSON document data against the schema. It returns a
+// *ValidationError listing every violation, or the decoding error if data
+// isn't valid JSON.
+func Validate(schema *Definition, data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	var violations []string
+	validate(schema, value, "$", &violations)
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func validate(schema *Definition, value any, path string, violations *[]string) {
+	if schema == nil {
+		return
+	}
+	fail := func(format string, args ...any) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(schema.AnyOf) > 0 && !matchesAny(schema.AnyOf, value, path) {
+		fail("matches none of the anyOf schemas")
+	}
+
+	switch schema.Type {
+	case "":
+	case Object:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			fail("expected object")
+			return
+		}
+		validateObject(schema, obj, path, violations)
+	case Array:
+		arr, ok := value.([]any)
+		if !ok {
+			fail("expected array")
+			return
+		}
+		for i, item := range arr {
+			validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), violations)
+		}
+	case String:
+		str, ok := value.(string)
+		if !ok {
+			fail("expected string")
+			return
+		}
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, str) {
+			fail("%q is not one of %s", str, strings.Join(schema.Enum, ", "))
+		}
+	case Number, Integer:
+		num, ok := value.(float64)
+		if !ok {
+			fail("expected %s", schema.Type)
+			return
+		}
+		if schema.Type == Integer && num != math.Trunc(num) {
+			fail("expected integer")
+		}
+		if schema.Minimum != nil && num < *schema.Minimum {
+			fail("%v is less than the minimum %v", num, *schema.Minimum)
+		}
+		if schema.Maximum != nil && num > *schema.Maximum {
+			fail("%v is greater than the maximum %v", num, *schema.Maximum)
+		}
+	case Boolean:
+		if _, ok := value.(bool); !ok {
+			fail("expected boolean")
+		}
+	case Null:
+		if value != nil {
+			fail("expected null")
+		}
+	}
+}
+
+func validateObject(schema *Definition, obj map[string]any, path string, violations *[]string) {
+	for _, name := range schema.Required {
+		if _, ok := obj[name]; !ok {
+			*violations = append(*violations, fmt.Sprintf("%s: missing required property %q", path, name))
+		}
+	}
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		value := obj[name]
+		if prop, ok := schema.Properties[name]; ok {
+			validate(prop, value, path+"."+name, violations)
+			continue
+		}
+		switch additional := schema.AdditionalProperties.(type) {
+		case bool:
+			if !additional {
+				*violations = append(*violations, fmt.Sprintf("%s: unexpected property %q", path, name))
+			}
+		case *Definition:
+			validate(additional, value, path+"."+name, violations)
+		}
+	}
+}
+
+func matchesAny(schemas []*Definition, value any, path string) bool {
+	for _, schema := range schemas {
+		var violations []string
+		validate(schema, value, path, &violations)
+		if len(violations) == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultKeyCooldown = time.Minute
+
+var (
+	ErrNoKeyAvailable = errors.New("no API key available in the pool")
+)
+
+type KeyStrategy int
+
+const (
+	// KeyStrategyRoundRobin rotates through the available keys.
+	KeyStrategyRoundRobin KeyStrategy = iota
+	// KeyStrategyLeastRecentlyRateLimited picks the key that was rate limited
+	// the longest time ago, never limited keys first.
+	KeyStrategyLeastRecentlyRateLimited
+)
+
+// KeyPool spreads requests across several API keys. A key answering 429 or 402
+// is set aside for Cooldown, a key answering 401 or 403 is disabled; the
+// failed request is retried with another key by the retry loop of the client.
+type KeyPool struct {
+	Strategy KeyStrategy
+	// Cooldown defaults to one minute.
+	Cooldown time.Duration
+
+	mu     sync.Mutex
+	keys   []*pooledKey
+	cursor int
+}
+
+type pooledKey struct {
+	key         string
+	limitedAt   time.Time
+	limitedTill time.Time
+	disabled    bool
+}
+
+func NewKeyPool(strategy KeyStrategy, keys ...string) *KeyPool {
+	pool := &KeyPool{Strategy: strategy}
+	for _, key := range keys {
+		pool.keys = append(pool.keys, &pooledKey{key: key})
+	}
+	return pool
+}
+
+// Next returns the key to use for the next request. When every enabled key is
+// cooling down, the one rate limited the longest time ago is returned.
+func (p *KeyPool) Next() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var (
+		chosen   *pooledKey
+		fallback *pooledKey
+	)
+	for i := range p.keys {
+		k := p.keys[(p.cursor+i)%len(p.keys)]
+		if k.disabled {
+			continue
+		}
+		if fallback == nil || k.limitedAt.Before(fallback.limitedAt) {
+			fallback = k
+		}
+		if now.Before(k.limitedTill) {
+			continue
+		}
+		if chosen == nil {
+			chosen = k
+			if p.Strategy == KeyStrategyRoundRobin {
+				break
+			}
+		} else if k.limitedAt.Before(chosen.limitedAt) {
+			chosen = k
+		}
+	}
+	if chosen == nil {
+		chosen = fallback
+	}
+	if chosen == nil {
+		return "", ErrNoKeyAvailable
+	}
+	p.cursor = (p.indexOf(chosen) + 1) % len(p.keys)
+	return chosen.key, nil
+}
+
+// Token returns Next, making the pool an AuthProvider.
+func (p *KeyPool) Token(context.Context) (string, error) {
+	return p.Next()
+}
+
+// Report records the response status received with key.
+func (p *KeyPool) Report(key string, status int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, k := range p.keys {
+		if k.key != key {
+			continue
+		}
+		switch status {
+		case http.StatusTooManyRequests, http.StatusPaymentRequired:
+			k.limitedAt = time.Now()
+			k.limitedTill = k.limitedAt.Add(p.cooldown())
+		case http.StatusUnauthorized, http.StatusForbidden:
+			k.disabled = true
+		}
+	}
+}
+
+// Enable puts a disabled key back into rotation.
+func (p *KeyPool) Enable(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, k := range p.keys {
+		if k.key == key {
+			k.disabled = false
+		}
+	}
+}
+
+func (p *KeyPool) cooldown() time.Duration {
+	if p.Cooldown > 0 {
+		return p.Cooldown
+	}
+	return defaultKeyCooldown
+}
+
+func (p *KeyPool) indexOf(key *pooledKey) int {
+	for i, k := range p.keys {
+		if k == key {
+			return i
+		}
+	}
+	return 0
+}
+
+// reportKey feeds the response status back to the key pool.
+func (c *Client) reportKey(req *http.Request, res *http.Response) {
+	if c.config.KeyPool == nil || res == nil {
+		return
+	}
+	key := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	c.config.KeyPool.Report(key, res.StatusCode)
+}
@@ -0,0 +1,104 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func nextKeys(t *testing.T, pool *KeyPool, n int) []string {
+	t.Helper()
+	keys := make([]string, n)
+	for i := range keys {
+		key, err := pool.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+func TestKeyPool_RoundRobin(t *testing.T) {
+	pool := NewKeyPool(KeyStrategyRoundRobin, "a", "b", "c")
+	if got := strings.Join(nextKeys(t, pool, 4), ","); got != "a,b,c,a" {
+		t.Errorf("unexpected rotation %s", got)
+	}
+
+	pool.Report("b", http.StatusTooManyRequests)
+	pool.Report("c", http.StatusUnauthorized)
+	if got := strings.Join(nextKeys(t, pool, 2), ","); got != "a,a" {
+		t.Errorf("expected the limited and disabled keys to be skipped, got %s", got)
+	}
+
+	pool.Enable("c")
+	if got := strings.Join(nextKeys(t, pool, 2), ","); got != "c,a" {
+		t.Errorf("expected the enabled key back in rotation, got %s", got)
+	}
+}
+
+func TestKeyPool_Cooldown(t *testing.T) {
+	pool := NewKeyPool(KeyStrategyLeastRecentlyRateLimited, "a", "b")
+	pool.Cooldown = time.Hour
+
+	pool.Report("a", http.StatusPaymentRequired)
+	time.Sleep(time.Millisecond)
+	pool.Report("b", http.StatusTooManyRequests)
+	if got := strings.Join(nextKeys(t, pool, 2), ","); got != "a,a" {
+		t.Errorf("expected the key limited the longest time ago while all cool down, got %s", got)
+	}
+
+	recovered := NewKeyPool(KeyStrategyLeastRecentlyRateLimited, "a", "b", "c")
+	recovered.Cooldown = time.Nanosecond
+	recovered.Report("b", http.StatusTooManyRequests)
+	time.Sleep(time.Millisecond)
+	recovered.Report("a", http.StatusTooManyRequests)
+	time.Sleep(time.Millisecond)
+	if got := strings.Join(nextKeys(t, recovered, 3), ","); got != "c,c,c" {
+		t.Errorf("expected the never limited key first, got %s", got)
+	}
+	recovered.Report("c", http.StatusTooManyRequests)
+	time.Sleep(time.Millisecond)
+	if got := strings.Join(nextKeys(t, recovered, 2), ","); got != "b,b" {
+		t.Errorf("expected the key limited the longest time ago first, got %s", got)
+	}
+
+	pool.Report("a", http.StatusForbidden)
+	pool.Report("b", http.StatusUnauthorized)
+	if _, err := pool.Next(); !errors.Is(err, ErrNoKeyAvailable) {
+		t.Errorf("expected ErrNoKeyAvailable, got %v", err)
+	}
+}
+
+func TestClient_KeyPoolFailover(t *testing.T) {
+	var keys []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		keys = append(keys, key)
+		switch key {
+		case "limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = io.WriteString(w, `{"error":{"code":429,"message":"rate limited"}}`)
+		case "revoked":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"error":{"code":401,"message":"invalid key"}}`)
+		default:
+			_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+		}
+	})
+	client.config.KeyPool = NewKeyPool(KeyStrategyRoundRobin, "limited", "revoked", "valid")
+	client.config.Retry.InitialBackoff = time.Millisecond
+
+	for range 2 {
+		if _, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := strings.Join(keys, ","); got != "limited,revoked,valid,valid" {
+		t.Errorf("unexpected keys %s", got)
+	}
+}
@@ -0,0 +1,107 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrClientClosed = errors.New("client is closed")
+)
+
+// lifecycle tracks the in-flight requests and streams of a client, for Close to
+// drain them. The lifecycle of a derived client is a child of the lifecycle of
+// its parent: its requests are tracked by both, so closing the parent closes
+// the derived clients while closing a derived client leaves the others open.
+type lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
+	// abort is canceled when Close gives up waiting.
+	abort  context.Context
+	cancel context.CancelFunc
+	parent *lifecycle
+}
+
+func newLifecycle() *lifecycle {
+	abort, cancel := context.WithCancel(context.Background())
+	return &lifecycle{abort: abort, cancel: cancel}
+}
+
+// child returns the lifecycle of a client derived from the client of l.
+func (l *lifecycle) child() *lifecycle {
+	child := newLifecycle()
+	child.parent = l
+	return child
+}
+
+// acquire registers a request. The returned context is canceled by release,
+// which must be called once the request is complete, or when Close aborts the
+// in-flight requests.
+func (l *lifecycle) acquire(ctx context.Context) (context.Context, func(), error) {
+	releaseParent := func() {}
+	if l.parent != nil {
+		var err error
+		if ctx, releaseParent, err = l.parent.acquire(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		releaseParent()
+		return nil, nil, ErrClientClosed
+	}
+	l.inflight.Add(1)
+	l.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			cancel()
+			l.inflight.Done()
+			releaseParent()
+		})
+	}
+	stop := context.AfterFunc(l.abort, release)
+	return ctx, func() {
+		stop()
+		release()
+	}, nil
+}
+
+// Close stops accepting new requests and waits for the in-flight requests and
+// streams to complete. When ctx is done first, they are canceled and ctx.Err()
+// is returned. The clients derived from c (see WithConfig) are closed with c,
+// closing a derived client leaves its parent and siblings open. The idle
+// connections of the HTTP client are closed when c owns it, see
+// ClientConfig.WithTransportConfig. A given or shared HTTP client is left
+// untouched, as other clients may use it: close its idle connections, if
+// needed, once all its clients are closed.
+func (c *Client) Close(ctx context.Context) error {
+	l := c.lifecycle
+	l.mu.Lock()
+	l.closed = true
+	l.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		l.inflight.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		l.cancel()
+		<-done
+		err = ctx.Err()
+	}
+	if c.ownsHTTPClient {
+		c.config.HTTPClient.CloseIdleConnections()
+	}
+	return err
+}
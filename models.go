@@ -0,0 +1,214 @@
+package openrouter
+
+//go:generate go run ./internal/cmd/genmodels -o models_gen.go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const modelCatalogTTL = time.Hour
+
+var (
+	ErrModelNotFound  = errors.New("model not found in the catalog")
+	ErrInvalidModelID = errors.New("model ID must be of the form author/slug")
+)
+
+// Model describes a model of the OpenRouter catalog. Prices are USD per token,
+// encoded as strings by the API.
+type Model struct {
+	ID                  string            `json:"id"`
+	CanonicalSlug       string            `json:"canonical_slug,omitempty"`
+	Name                string            `json:"name"`
+	Created             int64             `json:"created"`
+	Description         string            `json:"description"`
+	ContextLength       int               `json:"context_length"`
+	Architecture        ModelArchitecture `json:"architecture"`
+	Pricing             ModelPricing      `json:"pricing"`
+	TopProvider         ModelTopProvider  `json:"top_provider"`
+	PerRequestLimits    map[string]any    `json:"per_request_limits,omitempty"`
+	SupportedParameters []string          `json:"supported_parameters,omitempty"`
+}
+
+type ModelArchitecture struct {
+	Modality         string   `json:"modality"`
+	InputModalities  []string `json:"input_modalities,omitempty"`
+	OutputModalities []string `json:"output_modalities,omitempty"`
+	Tokenizer        string   `json:"tokenizer"`
+	InstructType     string   `json:"instruct_type,omitempty"`
+}
+
+type ModelPricing struct {
+	Prompt            string `json:"prompt"`
+	Completion        string `json:"completion"`
+	Request           string `json:"request,omitempty"`
+	Image             string `json:"image,omitempty"`
+	WebSearch         string `json:"web_search,omitempty"`
+	InternalReasoning string `json:"internal_reasoning,omitempty"`
+	InputCacheRead    string `json:"input_cache_read,omitempty"`
+	InputCacheWrite   string `json:"input_cache_write,omitempty"`
+}
+
+type ModelTopProvider struct {
+	ContextLength       int  `json:"context_length,omitempty"`
+	MaxCompletionTokens int  `json:"max_completion_tokens,omitempty"`
+	IsModerated         bool `json:"is_moderated"`
+}
+
+// ModelsList is the response of the models API.
+type ModelsList struct {
+	Data []Model `json:"data"`
+}
+
+// ListModels — API call to list the models available through OpenRouter.
+func (c *Client) ListModels(ctx context.Context) (models ModelsList, err error) {
+	req, err := c.requestBuilder.Build(ctx, http.MethodGet, c.fullURL("/models"), nil)
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &models)
+	return
+}
+
+// Find returns the model with the given ID.
+func (l ModelsList) Find(id string) (Model, bool) {
+	for _, model := range l.Data {
+		if model.ID == id {
+			return model, true
+		}
+	}
+	return Model{}, false
+}
+
+// GetModel returns a model of the catalog. The catalog is fetched once and kept
+// in memory, and on disk if ModelsCachePath is set, for modelCatalogTTL. A
+// variant not listed in the catalog, e.g. "openai/gpt-4o:online", resolves to
+// its base model.
+func (c *Client) GetModel(ctx context.Context, id string) (Model, error) {
+	catalog, err := c.modelCatalog(ctx)
+	if err != nil {
+		return Model{}, err
+	}
+	model, ok := catalog.Find(id)
+	if base, variant, found := strings.Cut(id, ":"); !ok && found && variant != "" {
+		model, ok = catalog.Find(base)
+	}
+	if !ok {
+		return Model{}, fmt.Errorf("%w: %s", ErrModelNotFound, id)
+	}
+	return model, nil
+}
+
+func (c *Client) modelCatalog(ctx context.Context) (ModelsList, error) {
+	c.catalog.mu.Lock()
+	models, fetchedAt := c.catalog.models, c.catalog.fetchedAt
+	c.catalog.mu.Unlock()
+	if !fetchedAt.IsZero() && time.Since(fetchedAt) <= modelCatalogTTL {
+		return models, nil
+	}
+
+	// fetched without the lock, concurrent misses may fetch twice
+	models, err := c.fetchModelCatalog(ctx)
+	if err != nil {
+		return ModelsList{}, err
+	}
+	c.catalog.mu.Lock()
+	c.catalog.models = models
+	c.catalog.fetchedAt = time.Now()
+	c.catalog.mu.Unlock()
+	return models, nil
+}
+
+type modelCatalog struct {
+	mu        sync.Mutex
+	models    ModelsList
+	fetchedAt time.Time
+	endpoints map[string]cachedModelEndpoints
+}
+
+type cachedModelEndpoints struct {
+	endpoints ModelEndpoints
+	fetchedAt time.Time
+}
+
+// modelEndpoints returns the endpoints of model, kept in memory for
+// modelCatalogTTL.
+func (c *Client) modelEndpoints(ctx context.Context, model string) (ModelEndpoints, error) {
+	c.catalog.mu.Lock()
+	cached, ok := c.catalog.endpoints[model]
+	c.catalog.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) <= modelCatalogTTL {
+		return cached.endpoints, nil
+	}
+
+	// fetched without the lock, concurrent misses may fetch twice
+	endpoints, err := c.ListModelEndpoints(ctx, model)
+	if err != nil {
+		return ModelEndpoints{}, err
+	}
+	c.catalog.mu.Lock()
+	defer c.catalog.mu.Unlock()
+	if c.catalog.endpoints == nil {
+		c.catalog.endpoints = make(map[string]cachedModelEndpoints)
+	}
+	c.catalog.endpoints[model] = cachedModelEndpoints{endpoints: endpoints, fetchedAt: time.Now()}
+	return endpoints, nil
+}
+
+// ModelEndpoint is a provider endpoint serving a model.
+type ModelEndpoint struct {
+	Name                string       `json:"name"`
+	ProviderName        string       `json:"provider_name"`
+	Tag                 string       `json:"tag,omitempty"`
+	ContextLength       int          `json:"context_length"`
+	Pricing             ModelPricing `json:"pricing"`
+	Quantization        string       `json:"quantization,omitempty"`
+	MaxCompletionTokens int          `json:"max_completion_tokens,omitempty"`
+	MaxPromptTokens     int          `json:"max_prompt_tokens,omitempty"`
+	SupportedParameters []string     `json:"supported_parameters,omitempty"`
+	Status              int          `json:"status"`
+	// UptimeLast30m is the uptime percentage over the last 30 minutes, nil when unknown.
+	UptimeLast30m *float64 `json:"uptime_last_30m,omitempty"`
+}
+
+// ModelEndpoints lists the endpoints of a model.
+type ModelEndpoints struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Created      int64             `json:"created"`
+	Description  string            `json:"description"`
+	Architecture ModelArchitecture `json:"architecture"`
+	Endpoints    []ModelEndpoint   `json:"endpoints"`
+}
+
+// ListModelEndpoints — API call to list the provider endpoints of a model,
+// identified as "author/slug".
+func (c *Client) ListModelEndpoints(ctx context.Context, model string) (endpoints ModelEndpoints, err error) {
+	author, slug, ok := strings.Cut(model, "/")
+	if !ok || author == "" || slug == "" {
+		return endpoints, fmt.Errorf("%w: %s", ErrInvalidModelID, model)
+	}
+
+	req, err := c.requestBuilder.Build(
+		ctx,
+		http.MethodGet,
+		c.fullURL(fmt.Sprintf("/models/%s/%s/endpoints", url.PathEscape(author), url.PathEscape(slug))),
+		nil,
+	)
+	if err != nil {
+		return
+	}
+
+	var resp struct {
+		Data ModelEndpoints `json:"data"`
+	}
+	err = c.sendRequest(req, &resp)
+	return resp.Data, err
+}
@@ -0,0 +1,117 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// modelsCacheFile is the content of the models catalog disk cache.
+type modelsCacheFile struct {
+	ETag         string     `json:"etag,omitempty"`
+	LastModified string     `json:"last_modified,omitempty"`
+	FetchedAt    time.Time  `json:"fetched_at"`
+	Models       ModelsList `json:"models"`
+}
+
+// DefaultModelsCachePath returns the models catalog cache file in the user
+// cache directory, see ClientConfig.ModelsCachePath.
+func DefaultModelsCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-openrouter", "models.json"), nil
+}
+
+// Models returns the models catalog, from memory or the disk cache when they
+// are less than an hour old. Unlike ListModels, it only calls the API when the
+// catalog is stale.
+func (c *Client) Models(ctx context.Context) (ModelsList, error) {
+	return c.modelCatalog(ctx)
+}
+
+// fetchModelCatalog lists the models, going through the disk cache if enabled:
+// a cached catalog older than modelCatalogTTL is revalidated with its ETag and
+// Last-Modified date.
+func (c *Client) fetchModelCatalog(ctx context.Context) (ModelsList, error) {
+	path := c.config.ModelsCachePath
+	if path == "" {
+		return c.ListModels(ctx)
+	}
+
+	cached, ok := readModelsCache(path)
+	if ok && time.Since(cached.FetchedAt) <= modelCatalogTTL {
+		return cached.Models, nil
+	}
+
+	req, err := c.requestBuilder.Build(ctx, http.MethodGet, c.fullURL("/models"), nil)
+	if err != nil {
+		return ModelsList{}, err
+	}
+	if ok {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	var models ModelsList
+	res, err := c.doRequestWithRetry(req, &models, nil)
+	switch {
+	case ok && res != nil && res.StatusCode == http.StatusNotModified:
+		cached.FetchedAt = time.Now()
+	case err != nil:
+		return ModelsList{}, err
+	default:
+		cached = modelsCacheFile{
+			ETag:         res.Header.Get("ETag"),
+			LastModified: res.Header.Get("Last-Modified"),
+			FetchedAt:    time.Now(),
+			Models:       models,
+		}
+	}
+	if err = writeModelsCache(path, cached); err != nil {
+		c.logger().Printf("Failed to write the models cache: %v", err)
+	}
+	return cached.Models, nil
+}
+
+func readModelsCache(path string) (modelsCacheFile, bool) {
+	var cached modelsCacheFile
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &cached) != nil {
+		return modelsCacheFile{}, false
+	}
+	return cached, true
+}
+
+// writeModelsCache replaces the cache file atomically, concurrent processes
+// never read a partial file.
+func writeModelsCache(path string, cached modelsCacheFile) error {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
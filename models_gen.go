@@ -0,0 +1,87 @@
+// Code generated by internal/cmd/genmodels; DO NOT EDIT.
+
+package openrouter
+
+// Models by anthropic.
+const (
+	ModelAnthropicClaude35Sonnet = "anthropic/claude-3.5-sonnet"
+)
+
+// Models by google.
+const (
+	ModelGoogleGemini25Pro = "google/gemini-2.5-pro"
+)
+
+// Models by meta-llama.
+const (
+	ModelMetaLlamaLlama370bInstruct = "meta-llama/llama-3-70b-instruct"
+)
+
+// Models by openai.
+const (
+	ModelOpenaiGpt35Turbo = "openai/gpt-3.5-turbo"
+	ModelOpenaiGpt4       = "openai/gpt-4"
+	ModelOpenaiGpt4o      = "openai/gpt-4o"
+	ModelOpenaiGpt4oMini  = "openai/gpt-4o-mini"
+	ModelOpenaiO3Mini     = "openai/o3-mini"
+)
+
+// ModelCatalog is the snapshot of the models catalog taken at generation time.
+var ModelCatalog = map[string]Model{
+	ModelAnthropicClaude35Sonnet: {
+		ID:            "anthropic/claude-3.5-sonnet",
+		Name:          "Anthropic: Claude 3.5 Sonnet",
+		ContextLength: 200000,
+		Architecture:  ModelArchitecture{Tokenizer: "Claude"},
+		Pricing:       ModelPricing{Prompt: "0.000003", Completion: "0.000015"},
+	},
+	ModelGoogleGemini25Pro: {
+		ID:            "google/gemini-2.5-pro",
+		Name:          "Google: Gemini 2.5 Pro",
+		ContextLength: 1048576,
+		Architecture:  ModelArchitecture{Tokenizer: "Gemini"},
+		Pricing:       ModelPricing{Prompt: "0.00000125", Completion: "0.00001"},
+	},
+	ModelMetaLlamaLlama370bInstruct: {
+		ID:            "meta-llama/llama-3-70b-instruct",
+		Name:          "Meta: Llama 3 70B Instruct",
+		ContextLength: 8192,
+		Architecture:  ModelArchitecture{Tokenizer: "Llama3"},
+		Pricing:       ModelPricing{Prompt: "0.0000003", Completion: "0.0000004"},
+	},
+	ModelOpenaiGpt35Turbo: {
+		ID:            "openai/gpt-3.5-turbo",
+		Name:          "OpenAI: GPT-3.5 Turbo",
+		ContextLength: 16385,
+		Architecture:  ModelArchitecture{Tokenizer: "GPT"},
+		Pricing:       ModelPricing{Prompt: "0.0000005", Completion: "0.0000015"},
+	},
+	ModelOpenaiGpt4: {
+		ID:            "openai/gpt-4",
+		Name:          "OpenAI: GPT-4",
+		ContextLength: 8191,
+		Architecture:  ModelArchitecture{Tokenizer: "GPT"},
+		Pricing:       ModelPricing{Prompt: "0.00003", Completion: "0.00006"},
+	},
+	ModelOpenaiGpt4o: {
+		ID:            "openai/gpt-4o",
+		Name:          "OpenAI: GPT-4o",
+		ContextLength: 128000,
+		Architecture:  ModelArchitecture{Tokenizer: "GPT"},
+		Pricing:       ModelPricing{Prompt: "0.0000025", Completion: "0.00001"},
+	},
+	ModelOpenaiGpt4oMini: {
+		ID:            "openai/gpt-4o-mini",
+		Name:          "OpenAI: GPT-4o-mini",
+		ContextLength: 128000,
+		Architecture:  ModelArchitecture{Tokenizer: "GPT"},
+		Pricing:       ModelPricing{Prompt: "0.00000015", Completion: "0.0000006"},
+	},
+	ModelOpenaiO3Mini: {
+		ID:            "openai/o3-mini",
+		Name:          "OpenAI: o3 Mini",
+		ContextLength: 200000,
+		Architecture:  ModelArchitecture{Tokenizer: "GPT"},
+		Pricing:       ModelPricing{Prompt: "0.0000011", Completion: "0.0000044"},
+	},
+}
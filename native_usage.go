@@ -0,0 +1,83 @@
+package openrouter
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultNativeUsagePollInterval = 500 * time.Millisecond
+	defaultNativeUsageMaxPolls     = 10
+)
+
+// NativeUsage are the token counts of the tokenizer of the model, as billed by
+// the provider. The counts of Usage are normalized with the GPT tokenizer so
+// that they compare across models; the native counts are only reported by the
+// generation endpoint, see Generation.NativeUsage and ClientConfig.NativeUsage.
+type NativeUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	// ReasoningTokens are the completion tokens spent on reasoning.
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+}
+
+// TotalTokens returns the sum of the prompt and completion tokens.
+func (n NativeUsage) TotalTokens() int {
+	return n.PromptTokens + n.CompletionTokens
+}
+
+// NativeUsage returns the native token counts of the generation.
+func (g Generation) NativeUsage() *NativeUsage {
+	return &NativeUsage{
+		PromptTokens:     g.NativeTokensPrompt,
+		CompletionTokens: g.NativeTokensCompletion,
+		ReasoningTokens:  g.NativeTokensReasoning,
+	}
+}
+
+// NativeUsagePolicy makes CreateChatCompletion enrich the usage of its
+// responses with the native token counts. The generation stats are only
+// available shortly after the response, the endpoint is polled until they are:
+// the enrichment adds this delay to each request.
+type NativeUsagePolicy struct {
+	// PollInterval is the interval between the polls, defaults to 500ms.
+	PollInterval time.Duration
+	// MaxPolls bounds the polls of a response, defaults to 10. The response is
+	// returned without native counts once they are exhausted.
+	MaxPolls int
+}
+
+// enrichNativeUsage sets the native counts of the usage of response, following
+// the native usage policy. Failures are logged, the response stays valid.
+func (c *Client) enrichNativeUsage(ctx context.Context, response *ChatCompletionResponse) {
+	policy := c.config.NativeUsage
+	if policy == nil || response == nil || response.ID == "" {
+		return
+	}
+	interval := policy.PollInterval
+	if interval <= 0 {
+		interval = defaultNativeUsagePollInterval
+	}
+	maxPolls := policy.MaxPolls
+	if maxPolls <= 0 {
+		maxPolls = defaultNativeUsageMaxPolls
+	}
+	// a generation not ready yet answers 404: poll without the retries of the
+	// client
+	client := c.probe()
+	var err error
+	for range maxPolls {
+		if err = sleepContext(ctx, interval); err != nil {
+			break
+		}
+		var generation Generation
+		if generation, err = client.GetGeneration(ctx, response.ID); err == nil {
+			if response.Usage == nil {
+				response.Usage = &Usage{}
+			}
+			response.Usage.Native = generation.NativeUsage()
+			return
+		}
+	}
+	c.logger().Printf("Failed to get the native usage of %s: %v", response.ID, err)
+}
@@ -0,0 +1,159 @@
+package openrouter
+
+import (
+	"context"
+	"slices"
+)
+
+// requestParameter is an optional request parameter, named as in the
+// supported_parameters of the models catalog.
+type requestParameter struct {
+	name  string
+	set   func(r *ChatCompletionRequest) bool
+	clear func(r *ChatCompletionRequest)
+}
+
+var requestParameters = []requestParameter{
+	{
+		name:  "tools",
+		set:   func(r *ChatCompletionRequest) bool { return len(r.Tools) > 0 },
+		clear: func(r *ChatCompletionRequest) { r.Tools, r.ToolChoice = nil, nil },
+	},
+	{
+		name:  "tool_choice",
+		set:   func(r *ChatCompletionRequest) bool { return r.ToolChoice != nil },
+		clear: func(r *ChatCompletionRequest) { r.ToolChoice = nil },
+	},
+	{
+		name:  "response_format",
+		set:   func(r *ChatCompletionRequest) bool { return r.ResponseFormat != nil },
+		clear: func(r *ChatCompletionRequest) { r.ResponseFormat = nil },
+	},
+	{
+		name: "structured_outputs",
+		set: func(r *ChatCompletionRequest) bool {
+			return r.ResponseFormat != nil && r.ResponseFormat.Type == ResponseFormatTypeJSONSchema
+		},
+		clear: func(r *ChatCompletionRequest) { r.ResponseFormat = nil },
+	},
+	{
+		name:  "logprobs",
+		set:   func(r *ChatCompletionRequest) bool { return r.Logprobs },
+		clear: func(r *ChatCompletionRequest) { r.Logprobs, r.TopLogprobs = false, 0 },
+	},
+	{
+		name:  "top_logprobs",
+		set:   func(r *ChatCompletionRequest) bool { return r.TopLogprobs > 0 },
+		clear: func(r *ChatCompletionRequest) { r.TopLogprobs = 0 },
+	},
+	{
+		name:  "logit_bias",
+		set:   func(r *ChatCompletionRequest) bool { return len(r.LogitBias) > 0 },
+		clear: func(r *ChatCompletionRequest) { r.LogitBias = nil },
+	},
+	{
+		name:  "seed",
+		set:   func(r *ChatCompletionRequest) bool { return r.Seed != nil },
+		clear: func(r *ChatCompletionRequest) { r.Seed = nil },
+	},
+	{
+		name:  "stop",
+		set:   func(r *ChatCompletionRequest) bool { return len(r.Stop) > 0 },
+		clear: func(r *ChatCompletionRequest) { r.Stop = nil },
+	},
+	{
+		name:  "top_k",
+		set:   func(r *ChatCompletionRequest) bool { return r.TopK != nil },
+		clear: func(r *ChatCompletionRequest) { r.TopK = nil },
+	},
+	{
+		name:  "min_p",
+		set:   func(r *ChatCompletionRequest) bool { return r.MinP != nil },
+		clear: func(r *ChatCompletionRequest) { r.MinP = nil },
+	},
+	{
+		name:  "top_a",
+		set:   func(r *ChatCompletionRequest) bool { return r.TopA != nil },
+		clear: func(r *ChatCompletionRequest) { r.TopA = nil },
+	},
+	{
+		name:  "frequency_penalty",
+		set:   func(r *ChatCompletionRequest) bool { return r.FrequencyPenalty != nil },
+		clear: func(r *ChatCompletionRequest) { r.FrequencyPenalty = nil },
+	},
+	{
+		name:  "presence_penalty",
+		set:   func(r *ChatCompletionRequest) bool { return r.PresencePenalty != nil },
+		clear: func(r *ChatCompletionRequest) { r.PresencePenalty = nil },
+	},
+	{
+		name:  "verbosity",
+		set:   func(r *ChatCompletionRequest) bool { return r.Verbosity != "" },
+		clear: func(r *ChatCompletionRequest) { r.Verbosity = "" },
+	},
+	{
+		name:  "repetition_penalty",
+		set:   func(r *ChatCompletionRequest) bool { return r.RepetitionPenalty != nil },
+		clear: func(r *ChatCompletionRequest) { r.RepetitionPenalty = nil },
+	},
+}
+
+// usedParameters returns the names of the optional parameters set on the request.
+func (r *ChatCompletionRequest) usedParameters() []string {
+	var params []string
+	for _, param := range requestParameters {
+		if param.set(r) {
+			params = append(params, param.name)
+		}
+	}
+	return params
+}
+
+// StripUnsupportedParameters clears the parameters of the request the model
+// doesn't list in its supported_parameters and returns their names. Nothing is
+// stripped when the model doesn't report its supported parameters.
+func StripUnsupportedParameters(request *ChatCompletionRequest, model Model) []string {
+	if len(model.SupportedParameters) == 0 {
+		return nil
+	}
+	var dropped []string
+	for _, param := range requestParameters {
+		if param.set(request) && !slices.Contains(model.SupportedParameters, param.name) {
+			param.clear(request)
+			dropped = append(dropped, param.name)
+		}
+	}
+	return dropped
+}
+
+// filterChatRequest logs the capability warnings of the request when
+// ClientConfig.WarnUnsupportedCapabilities is set, then strips the parameters
+// unsupported by the model when ClientConfig.DropUnsupportedParameters is set,
+// reporting them to OnDroppedParameters or the logger. It is a no-op if the
+// catalog can't be fetched.
+func (c *Client) filterChatRequest(ctx context.Context, request *ChatCompletionRequest) {
+	if !c.config.DropUnsupportedParameters && !c.config.WarnUnsupportedCapabilities {
+		return
+	}
+	model, err := c.GetModel(ctx, request.Model)
+	if err != nil {
+		return
+	}
+	if c.config.WarnUnsupportedCapabilities {
+		for _, warning := range CapabilityWarnings(request, model) {
+			c.logger().Printf("openrouter: %s", warning)
+		}
+	}
+	if !c.config.DropUnsupportedParameters {
+		return
+	}
+	dropped := StripUnsupportedParameters(request, model)
+	if len(dropped) == 0 {
+		return
+	}
+	if c.config.OnDroppedParameters != nil {
+		c.config.OnDroppedParameters(request.Model, dropped)
+		return
+	}
+	c.logger().Printf("openrouter: dropped parameters unsupported by %s: %v", request.Model, dropped)
+}
@@ -0,0 +1,136 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// CompletePartialJSON returns a valid JSON document from the truncated JSON
+// partial, by closing the open strings, objects and arrays and dropping the
+// trailing incomplete tokens (keys without value, partial literals...). It
+// returns false when nothing valid can be recovered yet.
+func CompletePartialJSON(partial string) (string, bool) {
+	partial = strings.TrimSpace(partial)
+	for len(partial) > 0 {
+		completed := partial + jsonClosers(partial)
+		if json.Valid([]byte(completed)) {
+			return completed, true
+		}
+		partial = partial[:len(partial)-1]
+	}
+	return "", false
+}
+
+// jsonClosers returns the characters closing the string, arrays and objects
+// left open by partial.
+func jsonClosers(partial string) string {
+	var stack []byte
+	inString, escaped := false, false
+	for i := 0; i < len(partial); i++ {
+		ch := partial[i]
+		switch {
+		case inString && escaped:
+			escaped = false
+		case inString && ch == '\\':
+			escaped = true
+		case inString && ch == '"':
+			inString = false
+		case inString:
+		case ch == '"':
+			inString = true
+		case ch == '{':
+			stack = append(stack, '}')
+		case ch == '[':
+			stack = append(stack, ']')
+		case (ch == '}' || ch == ']') && len(stack) > 0:
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	var closers strings.Builder
+	if inString {
+		closers.WriteByte('"')
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		closers.WriteByte(stack[i])
+	}
+	return closers.String()
+}
+
+// PartialJSON accumulates the content deltas of a streamed structured output
+// and exposes the best-effort completion of the document received so far.
+type PartialJSON struct {
+	buf  strings.Builder
+	last string
+}
+
+// Write appends delta and returns the completed document when it changed
+// since the previous call.
+func (p *PartialJSON) Write(delta string) (json.RawMessage, bool) {
+	p.buf.WriteString(delta)
+	completed, ok := CompletePartialJSON(p.buf.String())
+	if !ok || completed == p.last {
+		return nil, false
+	}
+	p.last = completed
+	return json.RawMessage(completed), true
+}
+
+// String returns the raw content received so far.
+func (p *PartialJSON) String() string {
+	return p.buf.String()
+}
+
+// Unmarshal decodes the completed document received so far into v.
+func (p *PartialJSON) Unmarshal(v any) error {
+	if p.last == "" {
+		return io.ErrUnexpectedEOF
+	}
+	return json.Unmarshal([]byte(p.last), v)
+}
+
+// StreamJSON runs a streaming chat completion, typically with a JSON schema
+// response format, and calls onPartial with the progressively completed
+// document of the first choice as the deltas arrive. It returns the complete
+// assistant message and the usage reported at the end of the stream, if any.
+func (c *Client) StreamJSON(
+	ctx context.Context,
+	request *ChatCompletionRequest,
+	onPartial func(partial json.RawMessage) error,
+) (message ChatCompletionMessage, usage *Usage, err error) {
+	stream, err := c.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		return
+	}
+	defer stream.Close()
+
+	var doc PartialJSON
+	for {
+		var chunk *ChatCompletionResponse
+		chunk, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			err = nil
+			break
+		}
+		if err != nil {
+			return
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		if partial, changed := doc.Write(chunk.Choices[0].Delta.Content); changed {
+			if err = onPartial(partial); err != nil {
+				return
+			}
+		}
+	}
+
+	message = ChatCompletionMessage{Role: ChatMessageRoleAssistant, Content: doc.String()}
+	return
+}
@@ -0,0 +1,49 @@
+package openrouter
+
+import "testing"
+
+func TestCompletePartialJSON(t *testing.T) {
+	tests := []struct {
+		partial string
+		want    string
+		ok      bool
+	}{
+		{``, ``, false},
+		{`{`, `{}`, true},
+		{`{"name": "Ada Lov`, `{"name": "Ada Lov"}`, true},
+		{`{"name": "Ada", "ag`, `{"name": "Ada"}`, true},
+		{`{"name": "Ada", "age":`, `{"name": "Ada"}`, true},
+		{`{"age": 36.`, `{"age": 36}`, true},
+		{`{"tags": ["a", "b`, `{"tags": ["a", "b"]}`, true},
+		{`{"tags": ["a",`, `{"tags": ["a"]}`, true},
+		{`{"ok": tr`, `{}`, true},
+		{`{"quote": "say \`, `{"quote": "say "}`, true},
+		{`{"nested": {"a": [1, {"b": "c`, `{"nested": {"a": [1, {"b": "c"}]}}`, true},
+	}
+	for _, test := range tests {
+		got, ok := CompletePartialJSON(test.partial)
+		if got != test.want || ok != test.ok {
+			t.Errorf("CompletePartialJSON(%q) = %q, %v, want %q, %v", test.partial, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestPartialJSON_Write(t *testing.T) {
+	var doc PartialJSON
+	var updates int
+	for _, delta := range []string{`{"a"`, `: 1`, `, "b`, `": [`, `2]}`} {
+		if _, changed := doc.Write(delta); changed {
+			updates++
+		}
+	}
+	var v struct {
+		A int   `json:"a"`
+		B []int `json:"b"`
+	}
+	if err := doc.Unmarshal(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != 1 || len(v.B) != 1 || v.B[0] != 2 || updates != 4 {
+		t.Errorf("got %+v after %d updates", v, updates)
+	}
+}
@@ -0,0 +1,77 @@
+package openrouter
+
+import "strings"
+
+type PluginID string
+
+const (
+	PluginIDWeb        PluginID = "web"
+	PluginIDFileParser PluginID = "file-parser"
+)
+
+type PDFEngine string
+
+const (
+	PDFEngineMistralOCR PDFEngine = "mistral-ocr"
+	PDFEnginePDFText    PDFEngine = "pdf-text"
+	PDFEngineNative     PDFEngine = "native"
+)
+
+const onlineModelSuffix = ":online"
+
+// ChatCompletionPlugin enables an OpenRouter plugin for the request.
+// MaxResults and SearchPrompt apply to the web plugin, PDF to the file-parser plugin.
+type ChatCompletionPlugin struct {
+	ID           PluginID   `json:"id"`
+	MaxResults   *int       `json:"max_results,omitempty"`
+	SearchPrompt string     `json:"search_prompt,omitempty"`
+	PDF          *PDFConfig `json:"pdf,omitempty"`
+}
+
+// PDFConfig selects the engine used by the file-parser plugin to parse PDFs.
+type PDFConfig struct {
+	Engine PDFEngine `json:"engine"`
+}
+
+// WebPlugin returns a web search plugin configuration. maxResults <= 0 uses the API default.
+func WebPlugin(maxResults int, searchPrompt string) ChatCompletionPlugin {
+	plugin := ChatCompletionPlugin{ID: PluginIDWeb, SearchPrompt: searchPrompt}
+	if maxResults > 0 {
+		plugin.MaxResults = &maxResults
+	}
+	return plugin
+}
+
+// FileParserPlugin returns a file-parser plugin configuration using the given PDF engine.
+func FileParserPlugin(engine PDFEngine) ChatCompletionPlugin {
+	return ChatCompletionPlugin{ID: PluginIDFileParser, PDF: &PDFConfig{Engine: engine}}
+}
+
+// OnlineModel returns the model name with the ":online" suffix, a shortcut
+// for enabling the web plugin with default settings.
+func OnlineModel(model string) string {
+	if strings.HasSuffix(model, onlineModelSuffix) {
+		return model
+	}
+	return model + onlineModelSuffix
+}
+
+type AnnotationType string
+
+const (
+	AnnotationTypeURLCitation AnnotationType = "url_citation"
+)
+
+// Annotation is attached to assistant messages, e.g. the sources of a web search.
+type Annotation struct {
+	Type        AnnotationType `json:"type"`
+	URLCitation *URLCitation   `json:"url_citation,omitempty"`
+}
+
+type URLCitation struct {
+	URL        string `json:"url"`
+	Title      string `json:"title,omitempty"`
+	Content    string `json:"content,omitempty"`
+	StartIndex int    `json:"start_index"`
+	EndIndex   int    `json:"end_index"`
+}
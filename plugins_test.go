@@ -0,0 +1,84 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestWebPlugin(t *testing.T) {
+	b, err := json.Marshal([]ChatCompletionPlugin{WebPlugin(3, "Sources:"), WebPlugin(0, "")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"id":"web","max_results":3,"search_prompt":"Sources:"},{"id":"web"}]`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	if got := OnlineModel(OnlineModel(Gpt4)); got != Gpt4+":online" {
+		t.Errorf("unexpected online model %q", got)
+	}
+}
+
+func TestClient_WebSearchAnnotations(t *testing.T) {
+	var plugins []ChatCompletionPlugin
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var request ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		plugins = request.Plugins
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"Go 1.23 is out.",`+
+			`"annotations":[{"type":"url_citation","url_citation":{"url":"https://go.dev/blog","title":"Go blog",`+
+			`"start_index":0,"end_index":15}}]}}]}`)
+	})
+
+	resp, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{
+		Model:   Gpt4,
+		Plugins: []ChatCompletionPlugin{WebPlugin(2, "")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plugins) != 1 || plugins[0].ID != PluginIDWeb || *plugins[0].MaxResults != 2 {
+		t.Errorf("unexpected plugins %+v", plugins)
+	}
+	annotations := resp.Choices[0].Message.Annotations
+	if len(annotations) != 1 || annotations[0].Type != AnnotationTypeURLCitation ||
+		annotations[0].URLCitation.Title != "Go blog" || annotations[0].URLCitation.EndIndex != 15 {
+		t.Errorf("unexpected annotations %+v", annotations)
+	}
+}
+
+func TestFileParserPlugin(t *testing.T) {
+	request := ChatCompletionRequest{
+		Model: Gpt4,
+		Messages: []ChatCompletionMessage{{
+			Role:         ChatMessageRoleUser,
+			MultiContent: []ChatMessagePart{TextPart("summarize"), FilePart("doc.pdf", PDFDataURL([]byte("%PDF")))},
+		}},
+		Plugins: []ChatCompletionPlugin{FileParserPlugin(PDFEngineMistralOCR)},
+	}
+	b, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body struct {
+		Messages []struct {
+			Content []map[string]any `json:"content"`
+		} `json:"messages"`
+		Plugins []map[string]any `json:"plugins"`
+	}
+	if err = json.Unmarshal(b, &body); err != nil {
+		t.Fatal(err)
+	}
+	file := body.Messages[0].Content[1]["file"].(map[string]any)
+	if file["filename"] != "doc.pdf" || file["file_data"] != "data:application/pdf;base64,JVBERg==" {
+		t.Errorf("unexpected file part %v", file)
+	}
+	if len(body.Plugins) != 1 || body.Plugins[0]["id"] != "file-parser" ||
+		body.Plugins[0]["pdf"].(map[string]any)["engine"] != "mistral-ocr" {
+		t.Errorf("unexpected plugins %v", body.Plugins)
+	}
+}
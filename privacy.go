@@ -0,0 +1,73 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	DataCollectionAllow = "allow"
+	DataCollectionDeny  = "deny"
+)
+
+// PrivacyPolicy is enforced on every request of the client, whatever the
+// provider preferences set at the call site.
+type PrivacyPolicy struct {
+	// DenyDataCollection restricts routing to providers that don't store or
+	// train on the data (provider.data_collection "deny").
+	DenyDataCollection bool
+	// RequireZDR restricts routing to zero-data-retention endpoints.
+	RequireZDR bool
+}
+
+// apply returns the provider preferences with the policy enforced.
+func (p PrivacyPolicy) apply(prefs *ProviderPreferences) *ProviderPreferences {
+	if !p.DenyDataCollection && !p.RequireZDR {
+		return prefs
+	}
+	enforced := ProviderPreferences{}
+	if prefs != nil {
+		enforced = *prefs
+	}
+	if p.DenyDataCollection {
+		enforced.DataCollection = DataCollectionDeny
+	}
+	if p.RequireZDR {
+		zdr := true
+		enforced.ZDR = &zdr
+	}
+	return &enforced
+}
+
+// applyRaw returns the pre-marshaled request body with the policy enforced on
+// its provider preferences, the other fields left as is.
+func (p PrivacyPolicy) applyRaw(body json.RawMessage) (json.RawMessage, error) {
+	if !p.DenyDataCollection && !p.RequireZDR {
+		return body, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("enforcing the privacy policy: %w", err)
+	}
+	var prefs map[string]json.RawMessage
+	if provider, ok := fields["provider"]; ok {
+		if err := json.Unmarshal(provider, &prefs); err != nil {
+			return nil, fmt.Errorf("enforcing the privacy policy: %w", err)
+		}
+	}
+	if prefs == nil {
+		prefs = make(map[string]json.RawMessage)
+	}
+	if p.DenyDataCollection {
+		prefs["data_collection"] = json.RawMessage(`"` + DataCollectionDeny + `"`)
+	}
+	if p.RequireZDR {
+		prefs["zdr"] = json.RawMessage("true")
+	}
+	provider, err := json.Marshal(prefs)
+	if err != nil {
+		return nil, err
+	}
+	fields["provider"] = provider
+	return json.Marshal(fields)
+}
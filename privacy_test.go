@@ -0,0 +1,71 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestPrivacyPolicy_Apply(t *testing.T) {
+	prefs := &ProviderPreferences{Order: []string{"OpenAI"}, DataCollection: DataCollectionAllow}
+	if got := (PrivacyPolicy{}).apply(prefs); got != prefs {
+		t.Errorf("expected the preferences to be kept without a policy, got %+v", got)
+	}
+
+	enforced := PrivacyPolicy{DenyDataCollection: true, RequireZDR: true}.apply(prefs)
+	if enforced == prefs || enforced.DataCollection != DataCollectionDeny || enforced.ZDR == nil || !*enforced.ZDR ||
+		len(enforced.Order) != 1 {
+		t.Errorf("expected the policy to be merged into a copy of the preferences, got %+v", enforced)
+	}
+	if prefs.DataCollection != DataCollectionAllow || prefs.ZDR != nil {
+		t.Errorf("the preferences of the caller were modified: %+v", prefs)
+	}
+
+	enforced = PrivacyPolicy{RequireZDR: true}.apply(nil)
+	if enforced == nil || enforced.DataCollection != "" || enforced.ZDR == nil || !*enforced.ZDR {
+		t.Errorf("expected preferences enforcing the policy, got %+v", enforced)
+	}
+}
+
+func TestPrivacyPolicy_ApplyRaw(t *testing.T) {
+	body := json.RawMessage(`{"model":"m","provider":{"order":["OpenAI"],"data_collection":"allow"},"x_vendor":1}`)
+	if got, err := (PrivacyPolicy{}).applyRaw(body); err != nil || string(got) != string(body) {
+		t.Errorf("expected the body to be sent as is without a policy, got %s, %v", got, err)
+	}
+
+	policy := PrivacyPolicy{DenyDataCollection: true, RequireZDR: true}
+	got, err := policy.applyRaw(body)
+	want := `{"model":"m","provider":{"data_collection":"deny","order":["OpenAI"],"zdr":true},"x_vendor":1}`
+	if err != nil || string(got) != want {
+		t.Errorf("got %s, %v, want %s", got, err, want)
+	}
+	if got, err = policy.applyRaw(json.RawMessage(`{"model":"m"}`)); err != nil ||
+		string(got) != `{"model":"m","provider":{"data_collection":"deny","zdr":true}}` {
+		t.Errorf("unexpected body %s, %v", got, err)
+	}
+	if _, err = policy.applyRaw(json.RawMessage(`[]`)); err == nil {
+		t.Error("expected an error for a body that isn't an object")
+	}
+}
+
+func TestClient_PrivacyRaw(t *testing.T) {
+	var provider ProviderPreferences
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Provider ProviderPreferences `json:"provider"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		provider = body.Provider
+		_, _ = io.WriteString(w, `{"id":"gen-1"}`)
+	})
+	client.config.Privacy = PrivacyPolicy{DenyDataCollection: true}
+
+	if _, err := client.CreateChatCompletionRaw(context.Background(), json.RawMessage(`{"model":"m"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if provider.DataCollection != DataCollectionDeny {
+		t.Errorf("expected the raw request to deny data collection, got %+v", provider)
+	}
+}
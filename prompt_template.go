@@ -0,0 +1,147 @@
+package openrouter
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+var (
+	ErrMissingVariables = errors.New("missing prompt template variables")
+)
+
+// MissingVariablesError lists the placeholders of a template without value. It
+// matches ErrMissingVariables with errors.Is.
+type MissingVariablesError struct {
+	Names []string
+}
+
+func (e *MissingVariablesError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrMissingVariables, strings.Join(e.Names, ", "))
+}
+
+func (e *MissingVariablesError) Is(target error) bool {
+	return target == ErrMissingVariables
+}
+
+// PromptTemplate renders chat messages from variables. The content of each
+// message is a text/template referencing the variables as {{.name}}.
+type PromptTemplate struct {
+	roles     []string
+	templates []*template.Template
+	variables []string
+}
+
+// NewPromptTemplate parses the contents of messages as templates.
+func NewPromptTemplate(messages ...ChatCompletionMessage) (*PromptTemplate, error) {
+	t := &PromptTemplate{}
+	seen := make(map[string]bool)
+	for i, message := range messages {
+		tmpl, err := template.New(fmt.Sprintf("%s#%d", message.Role, i)).
+			Option("missingkey=error").
+			Parse(message.Content)
+		if err != nil {
+			return nil, err
+		}
+		t.roles = append(t.roles, message.Role)
+		t.templates = append(t.templates, tmpl)
+		if tmpl.Tree != nil {
+			collectVariables(tmpl.Tree.Root, seen)
+		}
+	}
+	for name := range seen {
+		t.variables = append(t.variables, name)
+	}
+	sort.Strings(t.variables)
+	return t, nil
+}
+
+// MustPromptTemplate is like NewPromptTemplate but panics on parse errors.
+func MustPromptTemplate(messages ...ChatCompletionMessage) *PromptTemplate {
+	t, err := NewPromptTemplate(messages...)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Variables returns the sorted names of the variables used by the template.
+func (t *PromptTemplate) Variables() []string {
+	return append([]string(nil), t.variables...)
+}
+
+// Render returns the messages rendered with vars. Every variable of the template
+// must be supplied, otherwise a *MissingVariablesError is returned.
+func (t *PromptTemplate) Render(vars map[string]any) ([]ChatCompletionMessage, error) {
+	var missing []string
+	for _, name := range t.variables {
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &MissingVariablesError{Names: missing}
+	}
+
+	messages := make([]ChatCompletionMessage, 0, len(t.templates))
+	for i, tmpl := range t.templates {
+		var content strings.Builder
+		if err := tmpl.Execute(&content, vars); err != nil {
+			return nil, err
+		}
+		messages = append(messages, ChatCompletionMessage{Role: t.roles[i], Content: content.String()})
+	}
+	return messages, nil
+}
+
+// Apply appends the messages rendered with vars to the request messages.
+func (t *PromptTemplate) Apply(request *ChatCompletionRequest, vars map[string]any) error {
+	messages, err := t.Render(vars)
+	if err != nil {
+		return err
+	}
+	request.Messages = append(request.Messages, messages...)
+	return nil
+}
+
+// collectVariables adds to seen the top-level fields referenced by node. The
+// fields inside range and with blocks are relative to another dot and skipped.
+func collectVariables(node parse.Node, seen map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectVariables(child, seen)
+		}
+	case *parse.ActionNode:
+		collectVariables(n.Pipe, seen)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			for _, arg := range cmd.Args {
+				collectVariables(arg, seen)
+			}
+		}
+	case *parse.FieldNode:
+		seen[n.Ident[0]] = true
+	case *parse.ChainNode:
+		collectVariables(n.Node, seen)
+	case *parse.IfNode:
+		collectVariables(n.Pipe, seen)
+		collectVariables(n.List, seen)
+		collectVariables(n.ElseList, seen)
+	case *parse.RangeNode:
+		collectVariables(n.Pipe, seen)
+		collectVariables(n.ElseList, seen)
+	case *parse.WithNode:
+		collectVariables(n.Pipe, seen)
+		collectVariables(n.ElseList, seen)
+	}
+}
@@ -0,0 +1,35 @@
+package openrouter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPromptTemplate(t *testing.T) {
+	tmpl := MustPromptTemplate(
+		ChatCompletionMessage{Role: ChatMessageRoleSystem, Content: "You are a {{.persona}}."},
+		ChatCompletionMessage{Role: ChatMessageRoleUser, Content: "{{range .items}}- {{.}}\n{{end}}{{if .note}}{{.note}}{{end}}"},
+	)
+	if got := tmpl.Variables(); !reflect.DeepEqual(got, []string{"items", "note", "persona"}) {
+		t.Errorf("got variables %q", got)
+	}
+
+	_, err := tmpl.Render(map[string]any{"persona": "chef"})
+	var missing *MissingVariablesError
+	if !errors.As(err, &missing) || !errors.Is(err, ErrMissingVariables) ||
+		!reflect.DeepEqual(missing.Names, []string{"items", "note"}) {
+		t.Errorf("expected missing items and note, got %v", err)
+	}
+
+	request := ChatCompletionRequest{Model: Gpt4}
+	err = tmpl.Apply(&request, map[string]any{"persona": "chef", "items": []string{"eggs"}, "note": "Be quick."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(request.Messages) != 2 ||
+		request.Messages[0].Content != "You are a chef." ||
+		request.Messages[1].Content != "- eggs\nBe quick." {
+		t.Errorf("unexpected messages %#v", request.Messages)
+	}
+}
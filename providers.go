@@ -0,0 +1,106 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Provider is a provider of the OpenRouter catalog. Slug is the identifier used
+// in ProviderPreferences.
+type Provider struct {
+	Name              string `json:"name"`
+	Slug              string `json:"slug"`
+	PrivacyPolicyURL  string `json:"privacy_policy_url,omitempty"`
+	TermsOfServiceURL string `json:"terms_of_service_url,omitempty"`
+	StatusPageURL     string `json:"status_page_url,omitempty"`
+}
+
+// ProvidersList is the response of the providers API.
+type ProvidersList struct {
+	Data []Provider `json:"data"`
+}
+
+// ListProviders — API call to list the providers available through OpenRouter.
+func (c *Client) ListProviders(ctx context.Context) (providers ProvidersList, err error) {
+	req, err := c.requestBuilder.Build(ctx, http.MethodGet, c.fullURL("/providers"), nil)
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &providers)
+	return
+}
+
+// Quantizations of the endpoints, see ProviderPreferences.Quantizations.
+const (
+	QuantizationInt4    = "int4"
+	QuantizationInt8    = "int8"
+	QuantizationFP4     = "fp4"
+	QuantizationFP6     = "fp6"
+	QuantizationFP8     = "fp8"
+	QuantizationFP16    = "fp16"
+	QuantizationBF16    = "bf16"
+	QuantizationFP32    = "fp32"
+	QuantizationUnknown = "unknown"
+)
+
+var (
+	ErrNoEligibleEndpoint = errors.New("no endpoint of the model meets the provider preferences")
+)
+
+// EligibleEndpoints returns the endpoints of model allowed by prefs: their
+// context length, quantization and provider are checked against it.
+func EligibleEndpoints(endpoints ModelEndpoints, prefs ProviderPreferences) []ModelEndpoint {
+	var eligible []ModelEndpoint
+	for _, endpoint := range endpoints.Endpoints {
+		provider := endpoint.providerSlug()
+		if endpoint.ContextLength < prefs.MinContextLength ||
+			len(prefs.Quantizations) > 0 && !slices.Contains(prefs.Quantizations, endpoint.Quantization) ||
+			len(prefs.Only) > 0 && !slices.Contains(prefs.Only, provider) ||
+			slices.Contains(prefs.Ignore, provider) {
+			continue
+		}
+		eligible = append(eligible, endpoint)
+	}
+	return eligible
+}
+
+// providerSlug identifies the endpoint in the provider preferences: its tag,
+// else its lowercased provider name.
+func (e ModelEndpoint) providerSlug() string {
+	if e.Tag != "" {
+		return e.Tag
+	}
+	return strings.ToLower(e.ProviderName)
+}
+
+// constrainEndpoints enforces the MinContextLength of the provider preferences
+// by restricting a copy of the request to the eligible endpoints. It runs once
+// the model is final, after the circuit breaker routing.
+func (c *Client) constrainEndpoints(ctx context.Context, request *ChatCompletionRequest) (*ChatCompletionRequest, error) {
+	if request.Provider == nil || request.Provider.MinContextLength <= 0 {
+		return request, nil
+	}
+	endpoints, err := c.modelEndpoints(ctx, request.Model)
+	if err != nil {
+		return nil, err
+	}
+	eligible := EligibleEndpoints(endpoints, *request.Provider)
+	if len(eligible) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoEligibleEndpoint, request.Model)
+	}
+	prefs := *request.Provider
+	prefs.Only = make([]string, 0, len(eligible))
+	for _, endpoint := range eligible {
+		if slug := endpoint.providerSlug(); !slices.Contains(prefs.Only, slug) {
+			prefs.Only = append(prefs.Only, slug)
+		}
+	}
+	constrained := *request
+	constrained.Provider = &prefs
+	return &constrained, nil
+}
@@ -0,0 +1,105 @@
+package openrouter
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitState is the rate limit reported by the X-RateLimit headers of the
+// last response carrying them.
+type RateLimitState struct {
+	Limit     int
+	Remaining int
+	// Reset is when the quota is replenished.
+	Reset time.Time
+	// UpdatedAt is when the state was received, zero if never.
+	UpdatedAt time.Time
+}
+
+// ThrottleConfig delays outgoing requests while the remaining quota is low,
+// rather than running into 429 responses.
+type ThrottleConfig struct {
+	// MinRemaining is the remaining quota at or below which requests wait for
+	// the reset, zero disables throttling.
+	MinRemaining int
+	// MaxWait bounds the delay of a request, zero means waiting until the reset.
+	MaxWait time.Duration
+}
+
+type rateLimiter struct {
+	mu    sync.Mutex
+	state RateLimitState
+}
+
+// RateLimitState returns the last rate limit reported by the API.
+func (c *Client) RateLimitState() RateLimitState {
+	c.rateLimit.mu.Lock()
+	defer c.rateLimit.mu.Unlock()
+	return c.rateLimit.state
+}
+
+// recordRateLimit updates the rate limit state from the headers of res.
+func (c *Client) recordRateLimit(res *http.Response) {
+	if res == nil {
+		return
+	}
+	remaining, err := strconv.Atoi(res.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	state := RateLimitState{Remaining: remaining, UpdatedAt: now}
+	state.Limit, _ = strconv.Atoi(res.Header.Get("X-RateLimit-Limit"))
+	state.Reset = parseRateLimitReset(res.Header.Get("X-RateLimit-Reset"), now)
+
+	c.rateLimit.mu.Lock()
+	defer c.rateLimit.mu.Unlock()
+	c.rateLimit.state = state
+}
+
+// parseRateLimitReset parses a reset given as a Unix timestamp in milliseconds
+// or seconds, a number of seconds or a duration ("1m30s").
+func parseRateLimitReset(value string, now time.Time) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		switch {
+		case n >= 1e12:
+			return time.UnixMilli(int64(n))
+		case n >= 1e9:
+			return time.Unix(int64(n), 0)
+		default:
+			return now.Add(time.Duration(n * float64(time.Second)))
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(d)
+	}
+	return time.Time{}
+}
+
+// throttle waits for the rate limit reset when the remaining quota is at or
+// below the throttle threshold.
+func (c *Client) throttle(ctx context.Context) error {
+	throttle := c.config.Throttle
+	if throttle.MinRemaining <= 0 {
+		return nil
+	}
+	state := c.RateLimitState()
+	if state.UpdatedAt.IsZero() || state.Remaining > throttle.MinRemaining {
+		return nil
+	}
+	wait := time.Until(state.Reset)
+	if wait <= 0 {
+		return nil
+	}
+	if throttle.MaxWait > 0 && wait > throttle.MaxWait {
+		wait = throttle.MaxWait
+	}
+	c.logger().Printf("Rate limit nearly exhausted (%d remaining), waiting %s", state.Remaining, wait)
+	return sleepContext(ctx, wait)
+}
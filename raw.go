@@ -0,0 +1,63 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// CreateChatCompletionRaw — API call to create a chat completion from a
+// pre-marshaled OpenAI-format body, sent as is, e.g. by a gateway relaying its
+// clients' payloads. It returns the raw response body. The authentication,
+// retries, error handling and privacy policy of the client apply, the request
+// level features (defaults, validation, cache, budgets...) don't.
+func (c *Client) CreateChatCompletionRaw(ctx context.Context, body json.RawMessage) ([]byte, error) {
+	body, err := c.config.Privacy.applyRaw(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.requestBuilder.Build(ctx, http.MethodPost, c.fullURL("/chat/completions"), body)
+	if err != nil {
+		return nil, err
+	}
+	var response string
+	if err = c.sendRequest(req, &response); err != nil {
+		return nil, err
+	}
+	return []byte(response), nil
+}
+
+// CreateChatCompletionRawStream — API call to create a streaming chat
+// completion from a pre-marshaled body with "stream": true, see
+// CreateChatCompletionRaw. It returns the raw server-sent events body, to be
+// closed by the caller. Like the other streams, it isn't retried.
+func (c *Client) CreateChatCompletionRawStream(ctx context.Context, body json.RawMessage) (io.ReadCloser, error) {
+	body, err := c.config.Privacy.applyRaw(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newStreamRequest(ctx, http.MethodPost, "/chat/completions", body)
+	if err != nil {
+		return nil, err
+	}
+	resp, release, _, err := c.openStream(req)
+	if err != nil {
+		return nil, err
+	}
+	return &rawStreamBody{ReadCloser: resp.Body, release: release}, nil
+}
+
+// rawStreamBody releases the stream from the client lifecycle once closed.
+type rawStreamBody struct {
+	io.ReadCloser
+	release   func()
+	closeOnce sync.Once
+}
+
+func (b *rawStreamBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.closeOnce.Do(b.release)
+	return err
+}
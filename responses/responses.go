@@ -0,0 +1,279 @@
+// Package responses is a beta client of the OpenRouter Responses API, the
+// OpenAI Responses-compatible endpoint. Its surface may change as the endpoint
+// evolves.
+package responses
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	openrouter "github.com/dedlockdave/go-openrouter"
+)
+
+const defaultPollInterval = time.Second
+
+var (
+	ErrResponseFailed = errors.New("response failed")
+)
+
+// Status is the lifecycle status of a response.
+type Status string
+
+const (
+	StatusQueued     Status = "queued"
+	StatusInProgress Status = "in_progress"
+	StatusCompleted  Status = "completed"
+	StatusIncomplete Status = "incomplete"
+	StatusFailed     Status = "failed"
+	StatusCancelled  Status = "cancelled"
+)
+
+// Done reports whether the response reached a final status.
+func (s Status) Done() bool {
+	switch s {
+	case StatusCompleted, StatusIncomplete, StatusFailed, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// ItemType is the type of an input or output item.
+type ItemType string
+
+const (
+	ItemTypeMessage            ItemType = "message"
+	ItemTypeFunctionCall       ItemType = "function_call"
+	ItemTypeFunctionCallOutput ItemType = "function_call_output"
+	ItemTypeReasoning          ItemType = "reasoning"
+)
+
+// ContentType is the type of a content part of a message item.
+type ContentType string
+
+const (
+	ContentTypeInputText  ContentType = "input_text"
+	ContentTypeInputImage ContentType = "input_image"
+	ContentTypeOutputText ContentType = "output_text"
+)
+
+// Content is a content part of a message item.
+type Content struct {
+	Type        ContentType             `json:"type"`
+	Text        string                  `json:"text,omitempty"`
+	ImageURL    string                  `json:"image_url,omitempty"`
+	Detail      string                  `json:"detail,omitempty"`
+	Annotations []openrouter.Annotation `json:"annotations,omitempty"`
+}
+
+// Item is an input or output item. The fields set depend on Type: Role and
+// Content for messages, CallID, Name and Arguments for function calls, CallID
+// and Output for function call outputs.
+type Item struct {
+	Type      ItemType  `json:"type"`
+	ID        string    `json:"id,omitempty"`
+	Status    Status    `json:"status,omitempty"`
+	Role      string    `json:"role,omitempty"`
+	Content   []Content `json:"content,omitempty"`
+	CallID    string    `json:"call_id,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Arguments string    `json:"arguments,omitempty"`
+	Output    string    `json:"output,omitempty"`
+}
+
+// Message returns a message input item made of text and, optionally, images
+// given as URLs or base64 data URLs.
+func Message(role, text string, imageURLs ...string) Item {
+	content := []Content{{Type: ContentTypeInputText, Text: text}}
+	for _, imageURL := range imageURLs {
+		content = append(content, Content{Type: ContentTypeInputImage, ImageURL: imageURL})
+	}
+	return Item{Type: ItemTypeMessage, Role: role, Content: content}
+}
+
+// FunctionCallOutput returns the input item answering the function call callID.
+func FunctionCallOutput(callID, output string) Item {
+	return Item{Type: ItemTypeFunctionCallOutput, CallID: callID, Output: output}
+}
+
+// Tool is a function the model may call.
+type Tool struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON schema of the arguments, typically a
+	// *jsonschema.Definition.
+	Parameters any  `json:"parameters,omitempty"`
+	Strict     bool `json:"strict,omitempty"`
+}
+
+// FunctionTool returns a function tool.
+func FunctionTool(name, description string, parameters any) Tool {
+	return Tool{Type: "function", Name: name, Description: description, Parameters: parameters}
+}
+
+// Reasoning configures the reasoning of the models supporting it.
+type Reasoning struct {
+	Effort string `json:"effort,omitempty"`
+}
+
+// Request is a request of the Responses API.
+type Request struct {
+	Model        string `json:"model"`
+	Input        []Item `json:"input"`
+	Instructions string `json:"instructions,omitempty"`
+	// PreviousResponseID continues the conversation of a stored response.
+	PreviousResponseID string     `json:"previous_response_id,omitempty"`
+	Tools              []Tool     `json:"tools,omitempty"`
+	ToolChoice         any        `json:"tool_choice,omitempty"`
+	MaxOutputTokens    int        `json:"max_output_tokens,omitempty"`
+	Temperature        *float32   `json:"temperature,omitempty"`
+	TopP               *float32   `json:"top_p,omitempty"`
+	Reasoning          *Reasoning `json:"reasoning,omitempty"`
+	// Background runs the response asynchronously: Create returns a queued
+	// response to poll with Get or Wait.
+	Background bool              `json:"background,omitempty"`
+	Store      *bool             `json:"store,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	// Provider sets the provider routing preferences.
+	Provider *openrouter.ProviderPreferences `json:"provider,omitempty"`
+}
+
+// Usage is the token usage of a response.
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+	// Cost is the cost in credits, when reported.
+	Cost float64 `json:"cost,omitempty"`
+}
+
+// Error is the error of a failed response.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// Response is a response of the Responses API.
+type Response struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	CreatedAt int64  `json:"created_at"`
+	Model     string `json:"model"`
+	Status    Status `json:"status"`
+	Output    []Item `json:"output"`
+	Usage     *Usage `json:"usage,omitempty"`
+	Error     *Error `json:"error,omitempty"`
+}
+
+// OutputText concatenates the text of the output messages.
+func (r *Response) OutputText() string {
+	var text strings.Builder
+	for _, item := range r.Output {
+		if item.Type != ItemTypeMessage {
+			continue
+		}
+		for _, content := range item.Content {
+			if content.Type == ContentTypeOutputText {
+				text.WriteString(content.Text)
+			}
+		}
+	}
+	return text.String()
+}
+
+// FunctionCalls returns the function calls of the output.
+func (r *Response) FunctionCalls() []Item {
+	var calls []Item
+	for _, item := range r.Output {
+		if item.Type == ItemTypeFunctionCall {
+			calls = append(calls, item)
+		}
+	}
+	return calls
+}
+
+// Client calls the Responses API through an openrouter.Client, sharing its
+// authentication, retries and error handling.
+type Client struct {
+	client *openrouter.Client
+	// PollInterval is the interval of Wait, defaults to one second.
+	PollInterval time.Duration
+}
+
+func NewClient(client *openrouter.Client) *Client {
+	return &Client{client: client}
+}
+
+// Create — API call to create a response.
+func (c *Client) Create(ctx context.Context, request *Request) (*Response, error) {
+	var response Response
+	if err := c.client.Do(ctx, http.MethodPost, "/responses", request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Get — API call to retrieve a stored or background response.
+func (c *Client) Get(ctx context.Context, id string) (*Response, error) {
+	var response Response
+	if err := c.client.Do(ctx, http.MethodGet, "/responses/"+url.PathEscape(id), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Cancel — API call to cancel a background response.
+func (c *Client) Cancel(ctx context.Context, id string) (*Response, error) {
+	var response Response
+	if err := c.client.Do(ctx, http.MethodPost, "/responses/"+url.PathEscape(id)+"/cancel", nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Wait polls a background response until it reaches a final status. A failed
+// response is reported by an error matching ErrResponseFailed, which wraps the
+// error of the response: an *openrouter.APIError, as the API reports it, or an
+// *Error.
+func (c *Client) Wait(ctx context.Context, id string) (*Response, error) {
+	interval := c.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		response, err := c.Get(ctx, id)
+		var apiErr *openrouter.APIError
+		if errors.As(err, &apiErr) && apiErr.HTTPStatusCode == http.StatusOK {
+			// the error of a failed response fails its decoding
+			return nil, fmt.Errorf("%w: %w", ErrResponseFailed, apiErr)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if response.Status == StatusFailed {
+			if response.Error != nil {
+				return response, fmt.Errorf("%w: %w", ErrResponseFailed, response.Error)
+			}
+			return response, ErrResponseFailed
+		}
+		if response.Status.Done() {
+			return response, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
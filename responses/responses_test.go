@@ -0,0 +1,85 @@
+package responses
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	openrouter "github.com/dedlockdave/go-openrouter"
+)
+
+func TestClient_BackgroundResponse(t *testing.T) {
+	var polls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/responses":
+			var request Request
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			if !request.Background || request.Input[1].CallID != "call_1" {
+				t.Errorf("unexpected request %+v", request)
+			}
+			_, _ = io.WriteString(w, `{"id":"resp_1","status":"queued"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/responses/resp_1":
+			polls++
+			if polls < 2 {
+				_, _ = io.WriteString(w, `{"id":"resp_1","status":"in_progress"}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"id":"resp_1","status":"completed","output":[`+
+				`{"type":"message","role":"assistant","content":[{"type":"output_text","text":"It is sunny."}]}],`+
+				`"usage":{"input_tokens":10,"output_tokens":4,"total_tokens":14}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	config, err := openrouter.DefaultConfig("test-key", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.BaseURL = server.URL
+	client := NewClient(openrouter.NewClientWithConfig(config))
+	client.PollInterval = time.Millisecond
+
+	created, err := client.Create(context.Background(), &Request{
+		Model:      "openai/gpt-4o",
+		Input:      []Item{Message("user", "Weather in Paris?"), FunctionCallOutput("call_1", `{"sky":"clear"}`)},
+		Background: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := client.Wait(context.Background(), created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.OutputText() != "It is sunny." || response.Usage.TotalTokens != 14 || polls != 2 {
+		t.Errorf("unexpected response %+v after %d polls", response, polls)
+	}
+}
+
+func TestClient_WaitFailedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"id":"resp_1","status":"failed","error":{"code":"server_error","message":"boom"}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	config, err := openrouter.DefaultConfig("test-key", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.BaseURL = server.URL
+	client := NewClient(openrouter.NewClientWithConfig(config))
+
+	_, err = client.Wait(context.Background(), "resp_1")
+	var apiErr *openrouter.APIError
+	if !errors.Is(err, ErrResponseFailed) || !errors.As(err, &apiErr) || apiErr.Message != "boom" {
+		t.Errorf("expected ErrResponseFailed wrapping the API error, got %v", err)
+	}
+}
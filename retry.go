@@ -0,0 +1,91 @@
+package openrouter
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultMaxRetries     = 3
+	defaultInitialBackoff = 1 * time.Second
+)
+
+// RetryConfig controls the retries of non-streaming requests. Only the attempts
+// failed by a network error, a timeout (408), a rate limit (429) or a server
+// error (5xx) are retried.
+type RetryConfig struct {
+	// MaxRetries defaults to 3, a negative value disables retries.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, doubled on each
+	// subsequent retry and jittered. Defaults to one second.
+	InitialBackoff time.Duration
+	// AttemptTimeout bounds each attempt, so a slow attempt doesn't consume
+	// the whole deadline of the caller. Zero means no per-attempt bound.
+	AttemptTimeout time.Duration
+	// MaxElapsedTime bounds all the attempts and backoffs of a request. Zero
+	// means only the deadline of the caller's context applies.
+	MaxElapsedTime time.Duration
+	// ExcludeFailingProvider retries chat completions failed by an upstream
+	// provider with that provider added to provider.ignore, so the retry is
+	// routed to another provider of the model.
+	ExcludeFailingProvider bool
+}
+
+// RetryEvent describes a failed attempt of a request, see ClientConfig.OnRetry.
+type RetryEvent struct {
+	// Attempt is the number of the failed attempt, starting at 1.
+	Attempt int
+	Err     error
+	// Backoff is the delay before the next attempt, zero if WillRetry is false.
+	Backoff   time.Duration
+	WillRetry bool
+}
+
+func (r RetryConfig) withDefaults() RetryConfig {
+	if r.MaxRetries == 0 {
+		r.MaxRetries = defaultMaxRetries
+	}
+	if r.MaxRetries < 0 {
+		r.MaxRetries = 0
+	}
+	if r.InitialBackoff <= 0 {
+		r.InitialBackoff = defaultInitialBackoff
+	}
+	return r
+}
+
+// backoff returns the delay before the given retry: exponential backoff with jitter.
+func (r RetryConfig) backoff(attempt int) time.Duration {
+	backoff := float64(r.InitialBackoff) * math.Pow(2, float64(attempt-1))
+	jitter := (rand.Float64()*0.5 + 0.5) // 50%-150% of base backoff
+	return time.Duration(backoff * jitter)
+}
+
+// isRetryable reports whether a failed attempt may succeed when sent again:
+// network errors, timeouts, rate limits and server errors. An error reported in
+// the body of a 200 response is judged on its error code. With a key pool, the
+// rejections of a key are retried too, with the next key.
+func (c *Client) isRetryable(err error) bool {
+	status := errorStatusCode(err)
+	var apiErr *APIError
+	if status == http.StatusOK && errors.As(err, &apiErr) {
+		status, _ = apiErr.Code.(int)
+	}
+	switch {
+	case status == 0:
+		var netErr net.Error
+		return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+	case status == http.StatusRequestTimeout, status == http.StatusTooManyRequests,
+		status >= http.StatusInternalServerError:
+		return true
+	case c.config.KeyPool != nil:
+		return status == http.StatusUnauthorized || status == http.StatusPaymentRequired ||
+			status == http.StatusForbidden
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package openrouter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+)
+
+var (
+	ErrNoRouterArms = errors.New("router has no arm with a positive weight")
+)
+
+// RouterArm is a variant of an experiment: requests routed to it are sent to
+// Model, with Provider as provider preferences when set.
+type RouterArm struct {
+	Name     string
+	Model    string
+	Provider *ProviderPreferences
+	// Weight is the share of the traffic relative to the other arms.
+	Weight float64
+}
+
+// Router splits chat completions across model variants by weight, e.g. for
+// A/B tests. Responses are tagged with the name of the chosen arm. Assignment
+// is random, or sticky for the requests made with a context from
+// WithRouterKey. It is a ChatClient, usable in place of the client it wraps.
+type Router struct {
+	client ChatClient
+	arms   []RouterArm
+	total  float64
+}
+
+var _ ChatClient = (*Router)(nil)
+
+func NewRouter(client ChatClient, arms ...RouterArm) (*Router, error) {
+	router := &Router{client: client}
+	for _, arm := range arms {
+		if arm.Weight > 0 {
+			router.arms = append(router.arms, arm)
+			router.total += arm.Weight
+		}
+	}
+	if len(router.arms) == 0 {
+		return nil, ErrNoRouterArms
+	}
+	return router, nil
+}
+
+type routerKeyCtxKey struct{}
+
+// WithRouterKey makes the requests made with ctx stick to the arm assigned to
+// key, e.g. a user ID.
+func WithRouterKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, routerKeyCtxKey{}, key)
+}
+
+// Choose returns the arm of key, or a random arm if key is empty.
+func (r *Router) Choose(key string) RouterArm {
+	var point float64
+	if key == "" {
+		point = rand.Float64() * r.total
+	} else {
+		sum := sha256.Sum256([]byte(key))
+		point = float64(binary.BigEndian.Uint64(sum[:8])) / math.MaxUint64 * r.total
+	}
+	for _, arm := range r.arms {
+		if point < arm.Weight {
+			return arm
+		}
+		point -= arm.Weight
+	}
+	return r.arms[len(r.arms)-1]
+}
+
+func (r *Router) route(ctx context.Context, request *ChatCompletionRequest) (*ChatCompletionRequest, RouterArm) {
+	key, _ := ctx.Value(routerKeyCtxKey{}).(string)
+	arm := r.Choose(key)
+	routed := *request
+	routed.Model = arm.Model
+	if arm.Provider != nil {
+		routed.Provider = arm.Provider
+	}
+	return &routed, arm
+}
+
+// CreateChatCompletion — creates a chat completion with the model of the arm
+// chosen for the request, named in the RouterArm of the response.
+func (r *Router) CreateChatCompletion(
+	ctx context.Context,
+	request *ChatCompletionRequest,
+) (*ChatCompletionResponse, error) {
+	routed, arm := r.route(ctx, request)
+	response, err := r.client.CreateChatCompletion(ctx, routed)
+	if err != nil {
+		return nil, err
+	}
+	// the response may be shared by the cache, tag a copy
+	tagged := *response
+	tagged.RouterArm = arm.Name
+	return &tagged, nil
+}
+
+// CreateChatCompletionStream — streams a chat completion with the model of
+// the arm chosen for the request, named in the RouterArm of the stream.
+func (r *Router) CreateChatCompletionStream(
+	ctx context.Context,
+	request *ChatCompletionRequest,
+) (*ChatCompletionStream, error) {
+	routed, arm := r.route(ctx, request)
+	stream, err := r.client.CreateChatCompletionStream(ctx, routed)
+	if err != nil {
+		return nil, err
+	}
+	stream.RouterArm = arm.Name
+	return stream, nil
+}
+
+func (r *Router) ListModels(ctx context.Context) (ModelsList, error) {
+	return r.client.ListModels(ctx)
+}
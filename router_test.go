@@ -0,0 +1,42 @@
+package openrouter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	fake := &FakeClient{}
+	router, err := NewRouter(fake,
+		RouterArm{Name: "control", Model: "openai/gpt-4o-mini", Weight: 90},
+		RouterArm{Name: "candidate", Model: "anthropic/claude-3-haiku", Weight: 10},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	for i := range 1000 {
+		counts[router.Choose(fmt.Sprint("user-", i)).Name]++
+	}
+	if counts["candidate"] < 50 || counts["candidate"] > 150 {
+		t.Errorf("unexpected split %v", counts)
+	}
+
+	ctx := WithRouterKey(context.Background(), "user-42")
+	want := router.Choose("user-42")
+	for range 5 {
+		resp, err := router.CreateChatCompletion(ctx, &ChatCompletionRequest{Model: Gpt4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.RouterArm != want.Name || resp.Model != want.Model {
+			t.Errorf("got arm %q with model %q, want %q", resp.RouterArm, resp.Model, want.Name)
+		}
+	}
+
+	if _, err = NewRouter(fake, RouterArm{Name: "off"}); err != ErrNoRouterArms {
+		t.Errorf("expected ErrNoRouterArms, got %v", err)
+	}
+}
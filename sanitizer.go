@@ -0,0 +1,110 @@
+package openrouter
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Restorer puts back the values replaced by placeholders in a text.
+type Restorer func(text string) string
+
+// RequestSanitizer rewrites chat completion requests just before they are sent,
+// e.g. to scrub PII or mask secrets.
+type RequestSanitizer interface {
+	// Sanitize rewrites the messages of request in place, they are a copy of
+	// the caller's messages. It returns the Restorer applied to the contents of
+	// the response, or nil.
+	Sanitize(ctx context.Context, request *ChatCompletionRequest) (Restorer, error)
+}
+
+// SanitizePattern names the values matched by Regexp.
+type SanitizePattern struct {
+	Name   string
+	Regexp *regexp.Regexp
+}
+
+// PatternSanitizer is a RequestSanitizer replacing the matches of Patterns in
+// the text contents of the messages by placeholders like [EMAIL_1]. The same
+// value gets the same placeholder within a request.
+type PatternSanitizer struct {
+	Patterns []SanitizePattern
+	// Restore puts back the original values in the response contents.
+	Restore bool
+}
+
+var _ RequestSanitizer = (*PatternSanitizer)(nil)
+
+func (s *PatternSanitizer) Sanitize(_ context.Context, request *ChatCompletionRequest) (Restorer, error) {
+	placeholders := map[string]string{}
+	values := map[string]string{}
+	counts := map[string]int{}
+	replace := func(text string) string {
+		for _, pattern := range s.Patterns {
+			text = pattern.Regexp.ReplaceAllStringFunc(text, func(value string) string {
+				if placeholder, ok := placeholders[value]; ok {
+					return placeholder
+				}
+				counts[pattern.Name]++
+				placeholder := fmt.Sprintf("[%s_%d]", strings.ToUpper(pattern.Name), counts[pattern.Name])
+				placeholders[value], values[placeholder] = placeholder, value
+				return placeholder
+			})
+		}
+		return text
+	}
+	for i := range request.Messages {
+		message := &request.Messages[i]
+		message.Content = replace(message.Content)
+		for j := range message.MultiContent {
+			message.MultiContent[j].Text = replace(message.MultiContent[j].Text)
+		}
+	}
+	if !s.Restore || len(values) == 0 {
+		return nil, nil
+	}
+	pairs := make([]string, 0, 2*len(values))
+	for placeholder, value := range values {
+		pairs = append(pairs, placeholder, value)
+	}
+	replacer := strings.NewReplacer(pairs...)
+	return replacer.Replace, nil
+}
+
+// sanitizeChatRequest runs the sanitizer of the config on a copy of request,
+// leaving the caller's messages untouched.
+func (c *Client) sanitizeChatRequest(
+	ctx context.Context,
+	request *ChatCompletionRequest,
+) (*ChatCompletionRequest, Restorer, error) {
+	if c.config.Sanitizer == nil {
+		return request, nil, nil
+	}
+	sanitized := *request
+	sanitized.Messages = slices.Clone(request.Messages)
+	for i := range sanitized.Messages {
+		sanitized.Messages[i].MultiContent = slices.Clone(sanitized.Messages[i].MultiContent)
+	}
+	restore, err := c.config.Sanitizer.Sanitize(ctx, &sanitized)
+	return &sanitized, restore, err
+}
+
+// restoreResponse returns a copy of response with the placeholders restored.
+func restoreResponse(response *ChatCompletionResponse, restore Restorer) *ChatCompletionResponse {
+	if restore == nil {
+		return response
+	}
+	restored := *response
+	restored.Choices = slices.Clone(response.Choices)
+	for i := range restored.Choices {
+		message := &restored.Choices[i].Message
+		message.Content = restore(message.Content)
+		message.ToolCalls = slices.Clone(message.ToolCalls)
+		for j := range message.ToolCalls {
+			message.ToolCalls[j].Function.Arguments = restore(message.ToolCalls[j].Function.Arguments)
+		}
+	}
+	return &restored
+}
@@ -0,0 +1,177 @@
+package openrouter
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultSemanticThreshold  = 0.95
+	defaultSemanticMaxEntries = 1000
+)
+
+// Embedder returns the embedding vector of a text, e.g. from an embeddings API
+// or a local model.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float64, error)
+}
+
+// EmbedderFunc adapts a function to the Embedder interface.
+type EmbedderFunc func(ctx context.Context, text string) ([]float64, error)
+
+func (f EmbedderFunc) Embed(ctx context.Context, text string) ([]float64, error) {
+	return f(ctx, text)
+}
+
+// SemanticCache extends the response cache to requests whose last user message
+// is similar, by cosine similarity of the embeddings, to the one of a cached
+// request. The rest of the request (model, parameters, history) must be equal.
+type SemanticCache struct {
+	Embedder Embedder
+	// Threshold is the minimum cosine similarity of a hit, defaults to 0.95.
+	Threshold float64
+	// MaxEntries bounds the indexed prompts, the oldest are evicted first.
+	// Defaults to 1000.
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries []semanticEntry
+}
+
+type semanticEntry struct {
+	scope     string
+	key       string
+	embedding []float64
+}
+
+// semanticKey locates a request in the semantic index.
+type semanticKey struct {
+	scope     string
+	embedding []float64
+}
+
+func NewSemanticCache(embedder Embedder, threshold float64) *SemanticCache {
+	return &SemanticCache{Embedder: embedder, Threshold: threshold}
+}
+
+func (s *SemanticCache) threshold() float64 {
+	if s.Threshold > 0 {
+		return s.Threshold
+	}
+	return defaultSemanticThreshold
+}
+
+func (s *SemanticCache) maxEntries() int {
+	if s.MaxEntries > 0 {
+		return s.MaxEntries
+	}
+	return defaultSemanticMaxEntries
+}
+
+// lookup returns the cached response of the most similar request of tenant,
+// and the key to index request with once answered.
+func (s *SemanticCache) lookup(
+	ctx context.Context,
+	store CacheStore,
+	request *ChatCompletionRequest,
+	tenant string,
+) (*semanticKey, *ChatCompletionResponse) {
+	if len(request.Messages) == 0 {
+		return nil, nil
+	}
+	last := request.Messages[len(request.Messages)-1]
+	prompt := messageText(last)
+	if last.Role != ChatMessageRoleUser || prompt == "" {
+		return nil, nil
+	}
+	scoped := *request
+	scoped.Messages = append([]ChatCompletionMessage(nil), request.Messages[:len(request.Messages)-1]...)
+	scope, err := requestHash(&scoped)
+	if err != nil {
+		return nil, nil
+	}
+	scope = tenant + scope
+	embedding, err := s.Embedder.Embed(ctx, prompt)
+	if err != nil {
+		return nil, nil
+	}
+	key := &semanticKey{scope: scope, embedding: embedding}
+
+	s.mu.Lock()
+	best, bestScore := -1, s.threshold()
+	for i, entry := range s.entries {
+		if entry.scope != scope {
+			continue
+		}
+		if score := cosineSimilarity(embedding, entry.embedding); score >= bestScore {
+			best, bestScore = i, score
+		}
+	}
+	var hit semanticEntry
+	if best >= 0 {
+		hit = s.entries[best]
+	}
+	s.mu.Unlock()
+	if best < 0 {
+		return key, nil
+	}
+
+	if response, ok := store.Get(ctx, hit.key); ok {
+		return key, response
+	}
+	s.remove(hit.key)
+	return key, nil
+}
+
+// add indexes the response stored under key.
+func (s *SemanticCache) add(semantic *semanticKey, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, semanticEntry{scope: semantic.scope, key: key, embedding: semantic.embedding})
+	if excess := len(s.entries) - s.maxEntries(); excess > 0 {
+		s.entries = append(s.entries[:0:0], s.entries[excess:]...)
+	}
+}
+
+func (s *SemanticCache) remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, entry := range s.entries {
+		if entry.key == key {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// messageText returns the text of a message, content parts included.
+func messageText(message ChatCompletionMessage) string {
+	if len(message.MultiContent) == 0 {
+		return message.Content
+	}
+	var texts []string
+	for _, part := range message.MultiContent {
+		if part.Type == ChatMessagePartTypeText {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,57 @@
+package openrouter
+
+import (
+	"net/http"
+	"sync"
+)
+
+// sharedMaxIdleConnsPerHost replaces the default of 2 idle connections per
+// host, too low for many clients calling the same API.
+const sharedMaxIdleConnsPerHost = 100
+
+// SharedState is the state several clients of a process can share: the HTTP
+// client and its connection pool, the rate limit state and the models catalog.
+// Create it once, e.g. at startup, and set it as ClientConfig.Shared of the
+// clients, so frameworks creating a client per request handler don't open a
+// connection pool each. The zero value is ready to use, with the default
+// TransportConfig. It is safe for concurrent use.
+type SharedState struct {
+	once       sync.Once
+	httpClient *http.Client
+	rateLimit  *rateLimiter
+	catalog    *modelCatalog
+}
+
+// NewSharedState returns a shared state whose HTTP client uses a transport
+// built from tc. MaxIdleConnsPerHost defaults to 100.
+func NewSharedState(tc TransportConfig) *SharedState {
+	return &SharedState{httpClient: newSharedHTTPClient(tc)}
+}
+
+func newSharedHTTPClient(tc TransportConfig) *http.Client {
+	if tc.MaxIdleConnsPerHost == 0 {
+		tc.MaxIdleConnsPerHost = sharedMaxIdleConnsPerHost
+	}
+	return &http.Client{Transport: tc.NewTransport()}
+}
+
+// init fills the state left unset, e.g. by a zero-value SharedState.
+func (s *SharedState) init() {
+	s.once.Do(func() {
+		if s.httpClient == nil {
+			s.httpClient = newSharedHTTPClient(TransportConfig{})
+		}
+		if s.rateLimit == nil {
+			s.rateLimit = &rateLimiter{}
+		}
+		if s.catalog == nil {
+			s.catalog = &modelCatalog{}
+		}
+	})
+}
+
+// HTTPClient returns the HTTP client of the shared state.
+func (s *SharedState) HTTPClient() *http.Client {
+	s.init()
+	return s.httpClient
+}
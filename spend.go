@@ -0,0 +1,141 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrBudgetExceeded = errors.New("budget exceeded")
+)
+
+// BudgetExceededError is returned when a request would cross the budget of its
+// spend key. It matches ErrBudgetExceeded with errors.Is.
+type BudgetExceededError struct {
+	Key       string
+	Limit     float64
+	Spent     float64
+	Estimated float64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s, key: %q, limit: %f, spent: %f, estimated: %f",
+		ErrBudgetExceeded, e.Key, e.Limit, e.Spent, e.Estimated)
+}
+
+func (e *BudgetExceededError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// SpendTracker accumulates the actual cost of requests per spend key (an API
+// key, a tenant...) and enforces per-key budgets. The key of a request is set
+// with WithSpendKey, requests without key are accounted to "".
+type SpendTracker struct {
+	// EstimateBeforeSend also refuses requests whose estimated maximum cost
+	// would cross the budget. It requires fetching the models catalog.
+	EstimateBeforeSend bool
+
+	mu     sync.Mutex
+	limits map[string]float64
+	spent  map[string]float64
+}
+
+func NewSpendTracker() *SpendTracker {
+	return &SpendTracker{
+		limits: make(map[string]float64),
+		spent:  make(map[string]float64),
+	}
+}
+
+// SetBudget sets the spend limit of key, a limit <= 0 removes it.
+func (t *SpendTracker) SetBudget(key string, limit float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if limit <= 0 {
+		delete(t.limits, key)
+		return
+	}
+	t.limits[key] = limit
+}
+
+// Record adds cost to the spending of key.
+func (t *SpendTracker) Record(key string, cost float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spent[key] += cost
+}
+
+// Spent returns the accumulated cost of key.
+func (t *SpendTracker) Spent(key string) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.spent[key]
+}
+
+// Reset clears the accumulated cost of key.
+func (t *SpendTracker) Reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.spent, key)
+}
+
+// Check returns a *BudgetExceededError if spending estimated more would cross
+// the budget of key.
+func (t *SpendTracker) Check(key string, estimated float64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	limit, ok := t.limits[key]
+	if !ok {
+		return nil
+	}
+	spent := t.spent[key]
+	if spent >= limit || spent+estimated > limit {
+		return &BudgetExceededError{Key: key, Limit: limit, Spent: spent, Estimated: estimated}
+	}
+	return nil
+}
+
+type spendKeyCtxKey struct{}
+
+// WithSpendKey sets the spend key used to account requests made with ctx.
+func WithSpendKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, spendKeyCtxKey{}, key)
+}
+
+func spendKey(ctx context.Context) string {
+	key, _ := ctx.Value(spendKeyCtxKey{}).(string)
+	return key
+}
+
+// checkBudget enforces the cost cap per request and the spend budgets.
+func (c *Client) checkBudget(ctx context.Context, request *ChatCompletionRequest) error {
+	if err := c.checkCostCap(ctx, request); err != nil {
+		return err
+	}
+	tracker := c.config.SpendTracker
+	if tracker == nil {
+		return nil
+	}
+	if request.Usage == nil {
+		request.Usage = &UsageConfig{Include: true}
+	}
+
+	var estimated float64
+	if tracker.EstimateBeforeSend {
+		estimate, err := c.EstimateCost(ctx, request, nil)
+		if err != nil {
+			return err
+		}
+		estimated = estimate.MaxTotalCost()
+	}
+	return tracker.Check(spendKey(ctx), estimated)
+}
+
+func (c *Client) recordSpend(ctx context.Context, usage *Usage) {
+	if c.config.SpendTracker == nil || usage == nil {
+		return
+	}
+	c.config.SpendTracker.Record(spendKey(ctx), usage.Cost)
+}
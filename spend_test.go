@@ -0,0 +1,73 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestSpendTracker(t *testing.T) {
+	tracker := NewSpendTracker()
+	tracker.SetBudget("acme", 1)
+
+	if err := tracker.Check("acme", 0.5); err != nil {
+		t.Fatal(err)
+	}
+	tracker.Record("acme", 0.75)
+	var budgetErr *BudgetExceededError
+	if err := tracker.Check("acme", 0.5); !errors.As(err, &budgetErr) || !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected a BudgetExceededError, got %v", err)
+	}
+	if budgetErr.Key != "acme" || budgetErr.Spent != 0.75 || budgetErr.Estimated != 0.5 {
+		t.Errorf("unexpected error %+v", budgetErr)
+	}
+	if err := tracker.Check("other", 100); err != nil {
+		t.Errorf("expected the keys without budget to be unlimited, got %v", err)
+	}
+
+	tracker.Reset("acme")
+	if err := tracker.Check("acme", 0.5); err != nil || tracker.Spent("acme") != 0 {
+		t.Errorf("expected the reset to clear the spending, got %v", err)
+	}
+	tracker.Record("acme", 1)
+	tracker.SetBudget("acme", 0)
+	if err := tracker.Check("acme", 1); err != nil {
+		t.Errorf("expected the budget to be removed, got %v", err)
+	}
+}
+
+func TestClient_SpendTracker(t *testing.T) {
+	var requests int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var request ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if request.Usage == nil || !request.Usage.Include {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"ok"}}],"usage":{"total_tokens":3,"cost":0.5}}`)
+	})
+	tracker := NewSpendTracker()
+	tracker.SetBudget("acme", 1)
+	client.config.SpendTracker = tracker
+
+	acme := WithSpendKey(context.Background(), "acme")
+	for range 2 {
+		if _, err := client.CreateChatCompletion(acme, &ChatCompletionRequest{Model: Gpt4}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.CreateChatCompletion(acme, &ChatCompletionRequest{Model: Gpt4}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected ErrBudgetExceeded, got %v", err)
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), &ChatCompletionRequest{Model: Gpt4}); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 3 || tracker.Spent("acme") != 1 || tracker.Spent("") != 0.5 {
+		t.Errorf("unexpected spending after %d requests: acme %v, default %v", requests, tracker.Spent("acme"), tracker.Spent(""))
+	}
+}
@@ -0,0 +1,74 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var (
+	ErrStreamingUnsupported = errors.New("response writer doesn't support flushing")
+)
+
+// ProxyStream relays a streaming chat completion to w as server-sent events,
+// one data event per chunk terminated by data: [DONE]. The upstream request
+// uses the context of r, so a client disconnect cancels it.
+//
+// Errors occurring before the first event is written are returned untouched so
+// the handler can still answer with an HTTP error. Later errors are sent to the
+// client as a final {"error":...} event and returned.
+func (c *Client) ProxyStream(w http.ResponseWriter, r *http.Request, request *ChatCompletionRequest) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return ErrStreamingUnsupported
+	}
+
+	stream, err := c.CreateChatCompletionStream(r.Context(), request)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			_, err = io.WriteString(w, "data: [DONE]\n\n")
+			flusher.Flush()
+			return err
+		}
+		if err != nil {
+			writeSSEError(w, err)
+			flusher.Flush()
+			return err
+		}
+
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return err
+		}
+		flusher.Flush()
+	}
+}
+
+func writeSSEError(w io.Writer, err error) {
+	apiErr := &APIError{Message: err.Error()}
+	errors.As(err, &apiErr)
+	data, marshalErr := json.Marshal(ErrorResponse{Error: apiErr})
+	if marshalErr != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+}
@@ -0,0 +1,63 @@
+package openrouter
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_ProxyStream(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case strings.Contains(string(body), "invalid"):
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"error":{"code":400,"message":"invalid model"}}`)
+			return
+		case strings.Contains(string(body), "dying"):
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n"+
+				`{"error":{"code":502,"message":"provider died"}}`+"\n")
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n"+
+			"data: {\"choices\":[{\"delta\":{\"content\":\"!\"}}]}\n\n"+
+			"data: [DONE]\n\n")
+	})
+	proxy := func(model string) (*httptest.ResponseRecorder, error) {
+		recorder := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/chat", nil)
+		return recorder, client.ProxyStream(recorder, r, &ChatCompletionRequest{Model: model})
+	}
+
+	recorder, err := proxy(Gpt4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := recorder.Body.String()
+	if recorder.Header().Get("Content-Type") != "text/event-stream" || strings.Count(body, "data: ") != 3 ||
+		!strings.Contains(body, `"content":"!"`) || !strings.HasSuffix(body, "data: [DONE]\n\n") {
+		t.Errorf("unexpected events %q", body)
+	}
+
+	recorder, err = proxy("invalid")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "invalid model" {
+		t.Fatalf("expected the APIError, got %v", err)
+	}
+	if recorder.Body.Len() != 0 || recorder.Header().Get("Content-Type") != "" {
+		t.Errorf("expected nothing to be written before the first event, got %q", recorder.Body.String())
+	}
+
+	recorder, err = proxy("dying")
+	if !errors.As(err, &apiErr) || apiErr.Message != "provider died" {
+		t.Fatalf("expected the APIError, got %v", err)
+	}
+	if body = recorder.Body.String(); !strings.HasSuffix(body, `data: {"error":{"code":502,"message":"provider died","details":null}}`+"\n\n") {
+		t.Errorf("expected a final error event, got %q", body)
+	}
+}
@@ -6,16 +6,25 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	utils "github.com/dedlockdave/go-openrouter/internal"
 )
 
 var (
 	ErrTooManyEmptyStreamMessages = errors.New("stream has sent too many empty messages")
+	ErrStreamClosed               = errors.New("stream is closed")
 )
 
-type streamReader struct {
+type streamable interface {
+	ChatCompletionResponse | CompletionResponse
+}
+
+type streamReader[T streamable] struct {
 	emptyMessagesLimit uint
 	isFinished         bool
 
@@ -23,19 +32,80 @@ type streamReader struct {
 	response       *http.Response
 	errAccumulator utils.ErrorAccumulator
 	unmarshaler    utils.Unmarshaler
+
+	// onUsage, if set, receives the usage reported by the stream and the model
+	// of its chunk.
+	onUsage func(model string, usage *Usage)
+	// logger, if set, receives every raw line of the SSE transcript.
+	logger    *log.Logger
+	logPrefix string
+	// release, if set, cancels the request context, which aborts the
+	// connection, and unregisters the stream from the client lifecycle.
+	release   func()
+	timings   Timings
+	stats     streamStats
+	closeOnce sync.Once
+	closed    atomic.Bool
 }
 
-func (stream *streamReader) Recv() (response *ChatCompletionResponse, err error) {
+func (stream *streamReader[T]) Recv() (response *T, err error) {
+	if stream.closed.Load() {
+		err = ErrStreamClosed
+		return
+	}
 	if stream.isFinished {
 		err = io.EOF
 		return
 	}
 
 	response, err = stream.processLines()
+	stream.recordTimings(response, err)
+	if err == nil && stream.onUsage != nil {
+		if usage := usageOf(response); usage != nil {
+			stream.onUsage(modelOf(response), usage)
+		}
+	}
 	return
 }
 
-func (stream *streamReader) processLines() (*ChatCompletionResponse, error) {
+func (stream *streamReader[T]) recordTimings(response *T, err error) {
+	if stream.timings.Start.IsZero() {
+		return
+	}
+	now := time.Now()
+	if err == nil {
+		stream.stats.record(response, now)
+		if stream.timings.TimeToFirstToken == 0 && hasContent(response) {
+			stream.timings.TimeToFirstToken = now.Sub(stream.timings.Start)
+		}
+	}
+	if errors.Is(err, io.EOF) && stream.timings.Duration == 0 {
+		stream.timings.Duration = now.Sub(stream.timings.Start)
+	}
+}
+
+// modelOf returns the model of a stream chunk.
+func modelOf(response any) string {
+	switch r := response.(type) {
+	case *ChatCompletionResponse:
+		return r.Model
+	case *CompletionResponse:
+		return r.Model
+	}
+	return ""
+}
+
+func usageOf(response any) *Usage {
+	switch r := response.(type) {
+	case *ChatCompletionResponse:
+		return r.Usage
+	case *CompletionResponse:
+		return r.Usage
+	}
+	return nil
+}
+
+func (stream *streamReader[T]) processLines() (*T, error) {
 	var emptyMessagesCount uint
 
 	for {
@@ -48,6 +118,10 @@ func (stream *streamReader) processLines() (*ChatCompletionResponse, error) {
 			return nil, readErr
 		}
 
+		if stream.logger != nil {
+			stream.logger.Printf("%sstream %s", stream.logPrefix, bytes.TrimRight(rawLine, "\n"))
+		}
+
 		var headerData = []byte("data:")
 		noSpaceLine := bytes.TrimSpace(rawLine)
 		if !bytes.HasPrefix(noSpaceLine, headerData) {
@@ -70,7 +144,7 @@ func (stream *streamReader) processLines() (*ChatCompletionResponse, error) {
 			return nil, io.EOF
 		}
 
-		var response ChatCompletionResponse
+		var response T
 		unmarshalErr := stream.unmarshaler.Unmarshal(noPrefixLine, &response)
 		if unmarshalErr != nil {
 			return nil, unmarshalErr
@@ -80,7 +154,7 @@ func (stream *streamReader) processLines() (*ChatCompletionResponse, error) {
 	}
 }
 
-func (stream *streamReader) unmarshalError() (errResp *ErrorResponse) {
+func (stream *streamReader[T]) unmarshalError() (errResp *ErrorResponse) {
 	errBytes := stream.errAccumulator.Bytes()
 	if len(errBytes) == 0 {
 		return
@@ -94,6 +168,14 @@ func (stream *streamReader) unmarshalError() (errResp *ErrorResponse) {
 	return
 }
 
-func (stream *streamReader) Close() {
-	stream.response.Body.Close()
+// Close aborts the stream right away, a Recv blocked on the connection returns
+// an error. It is safe to call Close concurrently with Recv and more than once.
+func (stream *streamReader[T]) Close() {
+	stream.closeOnce.Do(func() {
+		stream.closed.Store(true)
+		if stream.release != nil {
+			stream.release()
+		}
+		stream.response.Body.Close()
+	})
 }
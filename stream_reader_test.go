@@ -0,0 +1,156 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// newHangingStreamClient returns a client whose streams send one chunk then
+// hang, and a channel receiving a value when the server sees the client gone.
+func newHangingStreamClient(t *testing.T) (*Client, <-chan struct{}) {
+	t.Helper()
+	gone := make(chan struct{}, 1)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		gone <- struct{}{}
+	})
+	return client, gone
+}
+
+func waitGone(t *testing.T, gone <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-gone:
+	case <-time.After(time.Second):
+		t.Fatal("the connection was not torn down")
+	}
+}
+
+func TestStream_CloseAbortsBlockedRecv(t *testing.T) {
+	client, gone := newHangingStreamClient(t)
+	stream, err := client.CreateChatCompletionStream(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+
+	recvErr := make(chan error, 1)
+	go func() {
+		_, err := stream.Recv()
+		recvErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	stream.Close()
+	stream.Close()
+
+	select {
+	case err = <-recvErr:
+		if err == nil {
+			t.Error("expected the blocked Recv to fail")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Recv still blocked after Close")
+	}
+	waitGone(t, gone)
+	if _, err = stream.Recv(); !errors.Is(err, ErrStreamClosed) {
+		t.Errorf("expected ErrStreamClosed, got %v", err)
+	}
+}
+
+func TestStream_ContextCancelAbortsConnection(t *testing.T) {
+	client, gone := newHangingStreamClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.CreateChatCompletionStream(ctx, &ChatCompletionRequest{Model: Gpt4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	cancel()
+	waitGone(t, gone)
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestStream_AbandonedStreamsDoNotLeak(t *testing.T) {
+	client, gone := newHangingStreamClient(t)
+	before := runtime.NumGoroutine()
+
+	for range 10 {
+		stream, err := client.CreateChatCompletionStream(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = stream.Recv(); err != nil {
+			t.Fatal(err)
+		}
+		stream.Close()
+		waitGone(t, gone)
+	}
+
+	client.config.HTTPClient.CloseIdleConnections()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("goroutines leaked: %d before, %d after", before, after)
+	}
+}
+
+func TestStream_Stats(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		for _, delta := range []string{"a", "b", "c"} {
+			time.Sleep(2 * time.Millisecond)
+			_, _ = io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\""+delta+"\"}}]}\n\n")
+			w.(http.Flusher).Flush()
+		}
+		_, _ = io.WriteString(w, "data: {\"choices\":[],\"usage\":{\"completion_tokens\":6}}\n\ndata: [DONE]\n\n")
+	})
+	stream, err := client.CreateChatCompletionStream(context.Background(), &ChatCompletionRequest{Model: Gpt4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for err == nil {
+		_, err = stream.Recv()
+	}
+
+	stats := stream.Stats()
+	if stats.Chunks != 3 || stats.CompletionTokens != 6 {
+		t.Errorf("unexpected counts %+v", stats)
+	}
+	if stats.InterTokenP50 < time.Millisecond || stats.InterTokenP99 < stats.InterTokenP50 {
+		t.Errorf("unexpected inter-token latencies %+v", stats)
+	}
+	if stats.TimeToFirstToken <= 0 || stats.TokensPerSecond <= 0 {
+		t.Errorf("unexpected latency and throughput %+v", stats)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	if got := percentile(sorted, 0.5); got != 5 {
+		t.Errorf("p50 = %d, want 5", got)
+	}
+	if got := percentile(sorted, 0.99); got != 10 {
+		t.Errorf("p99 = %d, want 10", got)
+	}
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("p50 of nothing = %d", got)
+	}
+}
@@ -0,0 +1,79 @@
+package openrouter
+
+import (
+	"slices"
+	"time"
+)
+
+// StreamStats are the latency and throughput of a stream, complete once the
+// stream has ended.
+type StreamStats struct {
+	TimeToFirstToken time.Duration
+	Duration         time.Duration
+	// Chunks is the number of chunks carrying content.
+	Chunks int
+	// CompletionTokens is taken from the usage reported by the stream, or
+	// estimated as one token per content chunk.
+	CompletionTokens int
+	// InterTokenP50, P90 and P99 are percentiles of the delay between content chunks.
+	InterTokenP50 time.Duration
+	InterTokenP90 time.Duration
+	InterTokenP99 time.Duration
+	// TokensPerSecond is the throughput from the first token to the end of the stream.
+	TokensPerSecond float64
+}
+
+// streamStats collects the arrival of the content chunks of a stream.
+type streamStats struct {
+	lastChunk time.Time
+	gaps      []time.Duration
+	chunks    int
+	usage     *Usage
+}
+
+func (s *streamStats) record(chunk any, now time.Time) {
+	if usage := usageOf(chunk); usage != nil {
+		s.usage = usage
+	}
+	if !hasContent(chunk) {
+		return
+	}
+	if !s.lastChunk.IsZero() {
+		s.gaps = append(s.gaps, now.Sub(s.lastChunk))
+	}
+	s.lastChunk = now
+	s.chunks++
+}
+
+// Stats returns the stats of the stream, partial until the stream has ended.
+func (stream *streamReader[T]) Stats() StreamStats {
+	stats := StreamStats{
+		TimeToFirstToken: stream.timings.TimeToFirstToken,
+		Duration:         stream.timings.Duration,
+		Chunks:           stream.stats.chunks,
+		CompletionTokens: stream.stats.chunks,
+	}
+	if stream.stats.usage != nil && stream.stats.usage.CompletionTokens > 0 {
+		stats.CompletionTokens = stream.stats.usage.CompletionTokens
+	}
+
+	gaps := slices.Clone(stream.stats.gaps)
+	slices.Sort(gaps)
+	stats.InterTokenP50 = percentile(gaps, 0.50)
+	stats.InterTokenP90 = percentile(gaps, 0.90)
+	stats.InterTokenP99 = percentile(gaps, 0.99)
+
+	if generation := stats.Duration - stats.TimeToFirstToken; stats.Duration > 0 && generation > 0 {
+		stats.TokensPerSecond = float64(stats.CompletionTokens) / generation.Seconds()
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of the sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
@@ -0,0 +1,57 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// StreamText runs a streaming chat completion and writes the text deltas of the
+// first choice to w as they arrive, flushing after each write if w is an
+// http.Flusher. It returns the complete assistant message and the usage
+// reported at the end of the stream, if any.
+func (c *Client) StreamText(
+	ctx context.Context,
+	request *ChatCompletionRequest,
+	w io.Writer,
+) (message ChatCompletionMessage, usage *Usage, err error) {
+	stream, err := c.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		return
+	}
+	defer stream.Close()
+
+	flusher, _ := w.(http.Flusher)
+	var content strings.Builder
+	for {
+		var chunk *ChatCompletionResponse
+		chunk, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			err = nil
+			break
+		}
+		if err != nil {
+			return
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta.Content
+		content.WriteString(delta)
+		if _, err = io.WriteString(w, delta); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	message = ChatCompletionMessage{Role: ChatMessageRoleAssistant, Content: content.String()}
+	return
+}
@@ -0,0 +1,45 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestClient_StreamText(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":\"Hel\"}}]}\n\n"+
+			"data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n"+
+			"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":2,\"total_tokens\":5}}\n\n"+
+			"data: [DONE]\n\n")
+	})
+
+	recorder := httptest.NewRecorder()
+	message, usage, err := client.StreamText(context.Background(), &ChatCompletionRequest{Model: Gpt4}, recorder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Body.String() != "Hello" || !recorder.Flushed {
+		t.Errorf("unexpected output %q, flushed %v", recorder.Body.String(), recorder.Flushed)
+	}
+	if message.Role != ChatMessageRoleAssistant || message.Content != "Hello" {
+		t.Errorf("unexpected message %+v", message)
+	}
+	if usage == nil || usage.TotalTokens != 5 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+
+	if _, _, err = client.StreamText(context.Background(), &ChatCompletionRequest{Model: Gpt4}, failingWriter{}); err == nil {
+		t.Error("expected the write error")
+	}
+}
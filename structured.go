@@ -0,0 +1,154 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dedlockdave/go-openrouter/jsonschema"
+)
+
+const defaultStructuredSchemaName = "response"
+
+// StructuredOptions configures CreateStructured.
+type StructuredOptions struct {
+	// MaxRepairs bounds the follow-up requests asking the model to fix an
+	// output that doesn't match the schema, zero disables the repair.
+	MaxRepairs int
+	// SchemaName names the schema reflected when the request has no response
+	// format, defaults to "response".
+	SchemaName string
+}
+
+// StructuredAttempt is an output of the model, Err is nil for the accepted one.
+type StructuredAttempt struct {
+	Output string
+	Err    error
+}
+
+// StructuredResult is the outcome of CreateStructured.
+type StructuredResult[T any] struct {
+	Value T
+	// Response is the last response of the model.
+	Response *ChatCompletionResponse
+	// Attempts lists every output of the model in order.
+	Attempts []StructuredAttempt
+	// Messages is the repair transcript: the request messages followed by the
+	// invalid outputs and the repair prompts.
+	Messages []ChatCompletionMessage
+}
+
+// StructuredOutputError reports an output that still doesn't match the schema
+// once the repairs are exhausted.
+type StructuredOutputError struct {
+	Output string
+	Err    error
+}
+
+func (e *StructuredOutputError) Error() string {
+	return fmt.Sprintf("invalid structured output: %v", e.Err)
+}
+
+func (e *StructuredOutputError) Unwrap() error {
+	return e.Err
+}
+
+// CreateStructured requests a JSON output matching the schema of T, validates
+// it and unmarshals it. The schema is reflected from T when the request has no
+// response format, see jsonschema.Reflect. An invalid output is sent back to the
+// model with the validation errors, up to options.MaxRepairs times. On failure
+// the result is returned along with a *StructuredOutputError.
+func CreateStructured[T any](
+	ctx context.Context,
+	client ChatClient,
+	request *ChatCompletionRequest,
+	options StructuredOptions,
+) (*StructuredResult[T], error) {
+	var value T
+	schema, err := structuredSchema(request, value)
+	if err != nil {
+		return nil, err
+	}
+	current := *request
+	if current.ResponseFormat == nil {
+		name := options.SchemaName
+		if name == "" {
+			name = defaultStructuredSchemaName
+		}
+		current.ResponseFormat = &ResponseFormat{
+			Type:       ResponseFormatTypeJSONSchema,
+			JSONSchema: &ResponseFormatJSONSchema{Name: name, Schema: schema, Strict: true},
+		}
+	}
+	current.Messages = append([]ChatCompletionMessage(nil), request.Messages...)
+
+	result := &StructuredResult[T]{}
+	for repairs := 0; ; repairs++ {
+		attempt := current
+		resp, err := client.CreateChatCompletion(ctx, &attempt)
+		if err != nil {
+			result.Messages = current.Messages
+			return result, err
+		}
+		if len(resp.Choices) == 0 {
+			result.Messages = current.Messages
+			return result, ErrEmptyChoices
+		}
+		result.Response = resp
+
+		output := resp.Choices[0].Message.Content
+		err = parseStructured(schema, output, &result.Value)
+		result.Attempts = append(result.Attempts, StructuredAttempt{Output: output, Err: err})
+		if err == nil {
+			result.Messages = current.Messages
+			return result, nil
+		}
+		if repairs >= options.MaxRepairs {
+			result.Messages = current.Messages
+			return result, &StructuredOutputError{Output: output, Err: err}
+		}
+		current.Messages = append(current.Messages,
+			ChatCompletionMessage{Role: ChatMessageRoleAssistant, Content: output},
+			ChatCompletionMessage{Role: ChatMessageRoleUser, Content: repairPrompt(err)},
+		)
+	}
+}
+
+// structuredSchema returns the schema outputs are validated against: the one of
+// the response format if it is a *jsonschema.Definition, else the reflection of
+// value.
+func structuredSchema(request *ChatCompletionRequest, value any) (*jsonschema.Definition, error) {
+	if format := request.ResponseFormat; format != nil && format.JSONSchema != nil {
+		if schema, ok := format.JSONSchema.Schema.(*jsonschema.Definition); ok {
+			return schema, nil
+		}
+	}
+	return jsonschema.Reflect(value)
+}
+
+func parseStructured(schema *jsonschema.Definition, output string, v any) error {
+	data := []byte(trimCodeFence(output))
+	if err := jsonschema.Validate(schema, data); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// trimCodeFence strips the markdown code fence some models wrap JSON in.
+func trimCodeFence(output string) string {
+	output = strings.TrimSpace(output)
+	if !strings.HasPrefix(output, "```") {
+		return output
+	}
+	output = strings.TrimSuffix(strings.TrimPrefix(output, "```"), "```")
+	if i := strings.IndexByte(output, '\n'); i >= 0 && !strings.ContainsAny(output[:i], "{[") {
+		output = output[i+1:]
+	}
+	return strings.TrimSpace(output)
+}
+
+func repairPrompt(err error) string {
+	return "Your reply is not valid JSON for the requested schema: " + err.Error() +
+		"\nFix this JSON and reply with the corrected JSON only."
+}
@@ -0,0 +1,71 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dedlockdave/go-openrouter/jsonschema"
+)
+
+type cityInfo struct {
+	Name       string `json:"name"`
+	Population int    `json:"population"`
+}
+
+func assistantResponse(content string) *ChatCompletionResponse {
+	return &ChatCompletionResponse{Choices: []ChatCompletionChoice{{
+		Message: Index{Role: ChatMessageRoleAssistant, Content: content},
+	}}}
+}
+
+func TestCreateStructured_RepairsInvalidOutput(t *testing.T) {
+	client := &FakeClient{Responses: []*ChatCompletionResponse{
+		assistantResponse(`{"name":"Paris"}`),
+		assistantResponse("```json\n{\"name\":\"Paris\",\"population\":2100000}\n```"),
+	}}
+	request := &ChatCompletionRequest{
+		Model:    Gpt4,
+		Messages: []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "Describe Paris"}},
+	}
+
+	result, err := CreateStructured[cityInfo](context.Background(), client, request, StructuredOptions{MaxRepairs: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Value != (cityInfo{Name: "Paris", Population: 2100000}) {
+		t.Errorf("unexpected value %+v", result.Value)
+	}
+	if len(result.Attempts) != 2 || result.Attempts[0].Err == nil || result.Attempts[1].Err != nil {
+		t.Errorf("unexpected attempts %+v", result.Attempts)
+	}
+	if len(result.Messages) != 3 || !strings.Contains(result.Messages[2].Content, `missing required property "population"`) {
+		t.Errorf("unexpected transcript %+v", result.Messages)
+	}
+	format := client.Calls[0].Request.ResponseFormat
+	if format == nil || format.Type != ResponseFormatTypeJSONSchema || format.JSONSchema.Name != "response" {
+		t.Errorf("expected a reflected json schema response format, got %+v", format)
+	}
+	if len(request.Messages) != 1 || request.ResponseFormat != nil {
+		t.Errorf("request was modified: %+v", request)
+	}
+}
+
+func TestCreateStructured_RepairsExhausted(t *testing.T) {
+	client := &FakeClient{Responses: []*ChatCompletionResponse{assistantResponse(`not json`)}}
+
+	result, err := CreateStructured[cityInfo](context.Background(), client, &ChatCompletionRequest{Model: Gpt4},
+		StructuredOptions{MaxRepairs: 2})
+	var outputErr *StructuredOutputError
+	if !errors.As(err, &outputErr) || outputErr.Output != "not json" {
+		t.Fatalf("expected a StructuredOutputError, got %v", err)
+	}
+	if len(client.Calls) != 3 || len(result.Attempts) != 3 {
+		t.Errorf("expected 3 attempts, got %d calls and %d attempts", len(client.Calls), len(result.Attempts))
+	}
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		t.Errorf("expected a decoding error, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package openrouter
+
+import (
+	"strings"
+)
+
+// SystemMessages returns the system and developer messages of messages.
+func SystemMessages(messages []ChatCompletionMessage) []ChatCompletionMessage {
+	var system []ChatCompletionMessage
+	for _, message := range messages {
+		if isSystemRole(message.Role) {
+			system = append(system, message)
+		}
+	}
+	return system
+}
+
+// MergeSystemMessages moves the system and developer messages into a single
+// leading message with the given role. Plain contents are joined by blank lines;
+// when any of them has content parts, e.g. with cache_control breakpoints, the
+// parts are concatenated so the breakpoints are preserved.
+func MergeSystemMessages(messages []ChatCompletionMessage, role string) []ChatCompletionMessage {
+	var (
+		system []ChatCompletionMessage
+		rest   = make([]ChatCompletionMessage, 0, len(messages))
+	)
+	for _, message := range messages {
+		if isSystemRole(message.Role) {
+			system = append(system, message)
+		} else {
+			rest = append(rest, message)
+		}
+	}
+	if len(system) == 0 {
+		return messages
+	}
+
+	merged := ChatCompletionMessage{Role: role}
+	multi := false
+	for _, message := range system {
+		multi = multi || len(message.MultiContent) > 0
+	}
+	if multi {
+		for _, message := range system {
+			if len(message.MultiContent) > 0 {
+				merged.MultiContent = append(merged.MultiContent, message.MultiContent...)
+			} else if message.Content != "" {
+				merged.MultiContent = append(merged.MultiContent, TextPart(message.Content))
+			}
+		}
+	} else {
+		contents := make([]string, 0, len(system))
+		for _, message := range system {
+			if message.Content != "" {
+				contents = append(contents, message.Content)
+			}
+		}
+		merged.Content = strings.Join(contents, "\n\n")
+	}
+	return append([]ChatCompletionMessage{merged}, rest...)
+}
+
+// NormalizeSystemMessages adapts the system prompt of messages to the family of
+// model: the OpenAI reasoning models get a single "developer" message, the other
+// OpenAI models keep their system messages, with "developer" renamed "system",
+// and the other families, which only honor a single leading system prompt, get
+// their system messages merged.
+func NormalizeSystemMessages(model string, messages []ChatCompletionMessage) []ChatCompletionMessage {
+	author, slug, _ := strings.Cut(model, "/")
+	switch {
+	case author == "openai" && usesDeveloperRole(slug):
+		return MergeSystemMessages(messages, ChatMessageRoleDeveloper)
+	case author == "openai":
+		normalized := make([]ChatCompletionMessage, len(messages))
+		for i, message := range messages {
+			if message.Role == ChatMessageRoleDeveloper {
+				message.Role = ChatMessageRoleSystem
+			}
+			normalized[i] = message
+		}
+		return normalized
+	default:
+		return MergeSystemMessages(messages, ChatMessageRoleSystem)
+	}
+}
+
+// usesDeveloperRole reports whether the OpenAI model slug expects the developer
+// role in place of the system role.
+func usesDeveloperRole(slug string) bool {
+	for _, prefix := range []string{"o1", "o3", "o4", "gpt-5"} {
+		if strings.HasPrefix(slug, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSystemRole(role string) bool {
+	return role == ChatMessageRoleSystem || role == ChatMessageRoleDeveloper
+}
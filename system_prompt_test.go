@@ -0,0 +1,41 @@
+package openrouter
+
+import "testing"
+
+func TestNormalizeSystemMessages(t *testing.T) {
+	messages := []ChatCompletionMessage{
+		{Role: ChatMessageRoleSystem, Content: "be brief"},
+		{Role: ChatMessageRoleUser, Content: "hi"},
+		{Role: ChatMessageRoleDeveloper, Content: "answer in French"},
+	}
+
+	got := NormalizeSystemMessages("anthropic/claude-3.5-sonnet", messages)
+	if len(got) != 2 || got[0].Role != ChatMessageRoleSystem || got[0].Content != "be brief\n\nanswer in French" {
+		t.Errorf("unexpected merged messages %#v", got)
+	}
+
+	got = NormalizeSystemMessages("openai/o3-mini", messages)
+	if len(got) != 2 || got[0].Role != ChatMessageRoleDeveloper {
+		t.Errorf("unexpected developer messages %#v", got)
+	}
+
+	got = NormalizeSystemMessages("openai/gpt-4o", messages)
+	if len(got) != 3 || got[2].Role != ChatMessageRoleSystem {
+		t.Errorf("unexpected openai messages %#v", got)
+	}
+}
+
+func TestMergeSystemMessages_KeepsCacheControl(t *testing.T) {
+	got := MergeSystemMessages([]ChatCompletionMessage{
+		{Role: ChatMessageRoleSystem, Content: "rules"},
+		{Role: ChatMessageRoleSystem, MultiContent: []ChatMessagePart{CachedTextPart("long document")}},
+		{Role: ChatMessageRoleUser, Content: "hi"},
+	}, ChatMessageRoleSystem)
+
+	if len(got) != 2 || len(got[0].MultiContent) != 2 {
+		t.Fatalf("unexpected messages %#v", got)
+	}
+	if got[0].MultiContent[0].Text != "rules" || got[0].MultiContent[1].CacheControl == nil {
+		t.Errorf("unexpected parts %#v", got[0].MultiContent)
+	}
+}
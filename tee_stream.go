@@ -0,0 +1,111 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TranscriptRecordType is the type of a line of a stream transcript.
+type TranscriptRecordType string
+
+const (
+	TranscriptRecordRequest TranscriptRecordType = "request"
+	TranscriptRecordChunk   TranscriptRecordType = "chunk"
+	TranscriptRecordEnd     TranscriptRecordType = "end"
+)
+
+// TranscriptRecord is a line of the JSONL transcript written by a TeeStream.
+// The end record carries the assembled content of the first choice, the usage
+// and the error that ended the stream, if any.
+type TranscriptRecord struct {
+	Type    TranscriptRecordType    `json:"type"`
+	Time    time.Time               `json:"time"`
+	Request *ChatCompletionRequest  `json:"request,omitempty"`
+	Chunk   *ChatCompletionResponse `json:"chunk,omitempty"`
+	Content string                  `json:"content,omitempty"`
+	Usage   *Usage                  `json:"usage,omitempty"`
+	Error   string                  `json:"error,omitempty"`
+}
+
+// TeeStream forwards the chunks of a chat completion stream to its consumer
+// while writing the transcript to a sink as JSONL: the request, every chunk and
+// an end record. Failures of the sink don't interrupt the stream, see Err.
+type TeeStream struct {
+	*ChatCompletionStream
+
+	mu      sync.Mutex
+	encoder *json.Encoder
+	content strings.Builder
+	usage   *Usage
+	ended   bool
+	err     error
+}
+
+// NewTeeStream wraps stream, opened for request, and writes the request record
+// to sink.
+func NewTeeStream(stream *ChatCompletionStream, request *ChatCompletionRequest, sink io.Writer) *TeeStream {
+	tee := &TeeStream{ChatCompletionStream: stream, encoder: json.NewEncoder(sink)}
+	tee.write(TranscriptRecord{Type: TranscriptRecordRequest, Request: request})
+	return tee
+}
+
+func (t *TeeStream) Recv() (*ChatCompletionResponse, error) {
+	chunk, err := t.ChatCompletionStream.Recv()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		if !errors.Is(err, ErrStreamClosed) {
+			t.end(err)
+		}
+		return chunk, err
+	}
+	if len(chunk.Choices) > 0 {
+		t.content.WriteString(chunk.Choices[0].Delta.Content)
+	}
+	if chunk.Usage != nil {
+		t.usage = chunk.Usage
+	}
+	t.write(TranscriptRecord{Type: TranscriptRecordChunk, Chunk: chunk})
+	return chunk, nil
+}
+
+// Close closes the stream, writing the end record if the stream didn't end.
+func (t *TeeStream) Close() {
+	t.mu.Lock()
+	t.end(ErrStreamClosed)
+	t.mu.Unlock()
+	t.ChatCompletionStream.Close()
+}
+
+// Err returns the first error of the sink.
+func (t *TeeStream) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// end writes the end record once, io.EOF being the normal end of the stream.
+func (t *TeeStream) end(err error) {
+	if t.ended {
+		return
+	}
+	t.ended = true
+	record := TranscriptRecord{Type: TranscriptRecordEnd, Content: t.content.String(), Usage: t.usage}
+	if !errors.Is(err, io.EOF) {
+		record.Error = err.Error()
+	}
+	t.write(record)
+}
+
+func (t *TeeStream) write(record TranscriptRecord) {
+	if t.err != nil {
+		return
+	}
+	record.Time = time.Now()
+	t.err = t.encoder.Encode(record)
+}
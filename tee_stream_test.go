@@ -0,0 +1,53 @@
+package openrouter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestTeeStream(t *testing.T) {
+	fake := &FakeClient{StreamChunks: [][]ChatCompletionResponse{FakeDeltas("Hel", "lo")}}
+	request := &ChatCompletionRequest{Model: Gpt4}
+	stream, err := fake.CreateChatCompletionStream(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sink bytes.Buffer
+	tee := NewTeeStream(stream, request, &sink)
+	var content string
+	for {
+		chunk, err := tee.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content += chunk.Choices[0].Delta.Content
+	}
+	tee.Close()
+	if content != "Hello" || tee.Err() != nil {
+		t.Fatalf("unexpected content %q, sink error %v", content, tee.Err())
+	}
+
+	var records []TranscriptRecord
+	scanner := bufio.NewScanner(&sink)
+	for scanner.Scan() {
+		var record TranscriptRecord
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 4 || records[0].Type != TranscriptRecordRequest || records[0].Request.Model != Gpt4 ||
+		records[1].Type != TranscriptRecordChunk || records[3].Type != TranscriptRecordEnd ||
+		records[3].Content != "Hello" || records[3].Error != "" {
+		t.Errorf("unexpected transcript %s", sink.String())
+	}
+}
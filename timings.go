@@ -0,0 +1,39 @@
+package openrouter
+
+import "time"
+
+// Timings are the wall-clock timings of a request measured by the client. The
+// latency of the provider itself is reported by GetGeneration.
+type Timings struct {
+	Start time.Time
+	// TimeToFirstToken is the delay until the first content delta of a stream.
+	TimeToFirstToken time.Duration
+	// Duration is the time until the complete response was received, retries
+	// included, or until the end of a stream.
+	Duration time.Duration
+}
+
+// Timings returns the timings of the stream. TimeToFirstToken and Duration are
+// zero until the first token and the end of the stream respectively.
+func (stream *streamReader[T]) Timings() Timings {
+	return stream.timings
+}
+
+// hasContent reports whether a stream chunk carries generated content.
+func hasContent(chunk any) bool {
+	switch c := chunk.(type) {
+	case *ChatCompletionResponse:
+		for _, choice := range c.Choices {
+			if choice.Delta.Content != "" || len(choice.Delta.ToolCalls) > 0 {
+				return true
+			}
+		}
+	case *CompletionResponse:
+		for _, choice := range c.Choices {
+			if choice.Text != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package openrouter
+
+import "strings"
+
+type Verbosity string
+
+const (
+	VerbosityLow    Verbosity = "low"
+	VerbosityMedium Verbosity = "medium"
+	VerbosityHigh   Verbosity = "high"
+)
+
+// maxOutputTokens returns the completion token limit of the request, set by
+// either MaxCompletionTokens or MaxTokens.
+func (r *ChatCompletionRequest) maxOutputTokens() int {
+	if r.MaxCompletionTokens > 0 {
+		return r.MaxCompletionTokens
+	}
+	return r.MaxTokens
+}
+
+// translateTokenLimits sends the completion token limit as max_completion_tokens
+// to the OpenAI reasoning models, which reject max_tokens, and as max_tokens to
+// the other models. The verbosity is dropped for the OpenAI models predating it.
+func translateTokenLimits(request *ChatCompletionRequest) {
+	author, slug, _ := strings.Cut(request.Model, "/")
+	limit := request.maxOutputTokens()
+	if author == "openai" && usesDeveloperRole(slug) {
+		request.MaxTokens, request.MaxCompletionTokens = 0, limit
+	} else {
+		request.MaxTokens, request.MaxCompletionTokens = limit, 0
+	}
+	if author == "openai" && !strings.HasPrefix(slug, "gpt-5") {
+		request.Verbosity = ""
+	}
+}
@@ -0,0 +1,217 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"unicode/utf8"
+)
+
+const (
+	// Overheads of the chat format, as documented for the OpenAI tokenizers.
+	tokensPerMessage = 3
+	tokensPerReply   = 3
+	charsPerToken    = 4
+)
+
+var (
+	ErrContextOverflow = errors.New("messages don't fit in the model context")
+)
+
+// TokenCounter counts the tokens of a text. The package ships
+// HeuristicTokenCounter and BPETokenCounter, for the encodings of the OpenAI
+// models: for accurate counts of other families, plug in their tokenizer wrapped
+// with TokenCounterFunc as a TokenEstimator counter.
+type TokenCounter interface {
+	CountTokens(text string) int
+}
+
+type TokenCounterFunc func(text string) int
+
+func (f TokenCounterFunc) CountTokens(text string) int {
+	return f(text)
+}
+
+// HeuristicTokenCounter approximates a token every four characters. It is off by
+// design: code, non-Latin scripts and numbers usually take more tokens, so keep
+// a margin in the budgets computed with it.
+type HeuristicTokenCounter struct{}
+
+func (HeuristicTokenCounter) CountTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// TokenEstimator estimates the prompt tokens of messages. Encodings are keyed
+// by the encoding of the OpenAI models, see OpenAIEncoding, and take precedence
+// over Counters, keyed by the tokenizer name of the models catalog (e.g. "GPT",
+// "Claude"). Fallback is used for other models and defaults to
+// HeuristicTokenCounter.
+type TokenEstimator struct {
+	Encodings map[BPEEncoding]TokenCounter
+	Counters  map[string]TokenCounter
+	Fallback  TokenCounter
+}
+
+// DefaultTokenEstimator only uses the heuristic counter, see
+// HeuristicTokenCounter.
+var DefaultTokenEstimator = &TokenEstimator{}
+
+func (e *TokenEstimator) counter(model, tokenizer string) TokenCounter {
+	if counter, ok := e.Encodings[OpenAIEncoding(model)]; ok {
+		return counter
+	}
+	if counter, ok := e.Counters[tokenizer]; ok {
+		return counter
+	}
+	if e.Fallback != nil {
+		return e.Fallback
+	}
+	return HeuristicTokenCounter{}
+}
+
+// EstimateMessages returns the estimated prompt tokens of messages for the tokenizer.
+func (e *TokenEstimator) EstimateMessages(tokenizer string, messages []ChatCompletionMessage) int {
+	return e.EstimateModelMessages("", tokenizer, messages)
+}
+
+// EstimateModelMessages returns the estimated prompt tokens of messages for the
+// model, of the given tokenizer in the models catalog.
+func (e *TokenEstimator) EstimateModelMessages(model, tokenizer string, messages []ChatCompletionMessage) int {
+	counter := e.counter(model, tokenizer)
+	tokens := tokensPerReply
+	for _, message := range messages {
+		tokens += estimateMessage(counter, message)
+	}
+	return tokens
+}
+
+func estimateMessage(counter TokenCounter, message ChatCompletionMessage) int {
+	tokens := tokensPerMessage + counter.CountTokens(message.Role) + counter.CountTokens(message.Content)
+	for _, part := range message.MultiContent {
+		tokens += counter.CountTokens(part.Text)
+	}
+	for _, call := range message.ToolCalls {
+		tokens += counter.CountTokens(call.Function.Name) + counter.CountTokens(call.Function.Arguments)
+	}
+	return tokens
+}
+
+// TrimOptions configures TrimToContext.
+type TrimOptions struct {
+	// Estimator defaults to DefaultTokenEstimator.
+	Estimator *TokenEstimator
+	// Model selects the encoding of the OpenAI models, see TokenEstimator.
+	Model     string
+	Tokenizer string
+	// Reserve is the number of tokens kept free for the completion.
+	Reserve int
+	// Summarize, if set, replaces the dropped messages by the message it returns
+	// instead of discarding them.
+	Summarize func(ctx context.Context, dropped []ChatCompletionMessage) (ChatCompletionMessage, error)
+}
+
+// TrimToContext drops the oldest messages until the estimated prompt fits in
+// contextLength minus opts.Reserve. System messages and the last message are
+// always kept, and an assistant message requesting tool calls is dropped with
+// the tool messages answering it. The estimate is only as accurate as the
+// counter of the model: without one in opts.Estimator, the heuristic may keep
+// more than fits, so set a BPETokenCounter or a TokenCounterFunc for the model,
+// or a larger Reserve.
+func TrimToContext(
+	ctx context.Context,
+	messages []ChatCompletionMessage,
+	contextLength int,
+	opts TrimOptions,
+) ([]ChatCompletionMessage, error) {
+	estimator := opts.Estimator
+	if estimator == nil {
+		estimator = DefaultTokenEstimator
+	}
+	budget := contextLength - opts.Reserve
+	estimate := func(messages []ChatCompletionMessage) int {
+		return estimator.EstimateModelMessages(opts.Model, opts.Tokenizer, messages)
+	}
+	if estimate(messages) <= budget {
+		return messages, nil
+	}
+
+	kept := append([]ChatCompletionMessage(nil), messages...)
+	var dropped []ChatCompletionMessage
+	for estimate(kept) > budget {
+		i := oldestDroppable(kept)
+		if i < 0 {
+			return nil, ErrContextOverflow
+		}
+		end := toolTurnEnd(kept, i)
+		if end == len(kept) {
+			// the last message answers the tool calls of kept[i]
+			return nil, ErrContextOverflow
+		}
+		dropped = append(dropped, kept[i:end]...)
+		kept = append(kept[:i], kept[end:]...)
+	}
+
+	if opts.Summarize == nil {
+		return kept, nil
+	}
+	summary, err := opts.Summarize(ctx, dropped)
+	if err != nil {
+		return nil, err
+	}
+	i := oldestDroppable(kept)
+	if i < 0 {
+		i = len(kept) - 1
+	}
+	kept = append(kept[:i], append([]ChatCompletionMessage{summary}, kept[i:]...)...)
+	if estimate(kept) > budget {
+		return nil, ErrContextOverflow
+	}
+	return kept, nil
+}
+
+func oldestDroppable(messages []ChatCompletionMessage) int {
+	if len(messages) == 0 {
+		return -1
+	}
+	for i, message := range messages[:len(messages)-1] {
+		if !isSystemRole(message.Role) {
+			return i
+		}
+	}
+	return -1
+}
+
+// toolTurnEnd returns the end of the messages to drop with messages[i]: the
+// tool messages following it, which answer its tool calls or are left without
+// the calls they answer once it is dropped.
+func toolTurnEnd(messages []ChatCompletionMessage, i int) int {
+	end := i + 1
+	for end < len(messages) && messages[end].Role == ChatMessageRoleTool {
+		end++
+	}
+	return end
+}
+
+// TrimRequestToContext trims the request messages to the context length of its
+// model, reserving request.MaxTokens for the completion.
+func (c *Client) TrimRequestToContext(ctx context.Context, request *ChatCompletionRequest, opts TrimOptions) error {
+	model, err := c.GetModel(ctx, request.Model)
+	if err != nil {
+		return err
+	}
+	if opts.Model == "" {
+		opts.Model = request.Model
+	}
+	if opts.Tokenizer == "" {
+		opts.Tokenizer = model.Architecture.Tokenizer
+	}
+	if opts.Reserve == 0 {
+		opts.Reserve = request.maxOutputTokens()
+	}
+
+	messages, err := TrimToContext(ctx, request.Messages, model.ContextLength, opts)
+	if err != nil {
+		return err
+	}
+	request.Messages = messages
+	return nil
+}
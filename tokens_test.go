@@ -0,0 +1,71 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTrimToContext(t *testing.T) {
+	long := strings.Repeat("a", 400)
+	messages := []ChatCompletionMessage{
+		{Role: ChatMessageRoleSystem, Content: "system"},
+		{Role: ChatMessageRoleUser, Content: long},
+		{Role: ChatMessageRoleAssistant, Content: long},
+		{Role: ChatMessageRoleUser, Content: "last"},
+	}
+
+	trimmed, err := TrimToContext(context.Background(), messages, 150, TrimOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trimmed) != 3 || trimmed[0].Role != ChatMessageRoleSystem || trimmed[2].Content != "last" {
+		t.Errorf("unexpected trimmed messages %#v", trimmed)
+	}
+
+	if _, err = TrimToContext(context.Background(), messages, 10, TrimOptions{}); !errors.Is(err, ErrContextOverflow) {
+		t.Errorf("expected ErrContextOverflow, got %v", err)
+	}
+}
+
+func TestTrimToContext_ToolCalls(t *testing.T) {
+	long := strings.Repeat("a", 400)
+	messages := []ChatCompletionMessage{
+		{Role: ChatMessageRoleUser, Content: "weather?"},
+		{Role: ChatMessageRoleAssistant, ToolCalls: []ToolCall{
+			{ID: "1", Type: ToolTypeFunction, Function: FunctionCall{Name: "weather", Arguments: long}},
+			{ID: "2", Type: ToolTypeFunction, Function: FunctionCall{Name: "weather"}},
+		}},
+		{Role: ChatMessageRoleTool, ToolCallID: "1", Content: "sunny"},
+		{Role: ChatMessageRoleTool, ToolCallID: "2", Content: "rainy"},
+		{Role: ChatMessageRoleUser, Content: "last"},
+	}
+
+	trimmed, err := TrimToContext(context.Background(), messages, 50, TrimOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trimmed) != 1 || trimmed[0].Content != "last" {
+		t.Errorf("expected the tool calls to be dropped with their results, got %#v", trimmed)
+	}
+
+	// The last message answers the calls, which can't be dropped alone.
+	if _, err = TrimToContext(context.Background(), messages[:4], 50, TrimOptions{}); !errors.Is(err, ErrContextOverflow) {
+		t.Errorf("expected ErrContextOverflow, got %v", err)
+	}
+}
+
+func TestTokenEstimator_Encodings(t *testing.T) {
+	estimator := &TokenEstimator{
+		Encodings: map[BPEEncoding]TokenCounter{EncodingO200K: TokenCounterFunc(func(string) int { return 10 })},
+		Counters:  map[string]TokenCounter{"GPT": TokenCounterFunc(func(string) int { return 1 })},
+	}
+	messages := []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "hi"}}
+	if got := estimator.EstimateModelMessages("openai/gpt-4o", "GPT", messages); got != 26 {
+		t.Errorf("expected the encoding of the model, got %d tokens", got)
+	}
+	if got := estimator.EstimateModelMessages("openai/gpt-4", "GPT", messages); got != 8 {
+		t.Errorf("expected the counter of the tokenizer, got %d tokens", got)
+	}
+}
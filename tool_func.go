@@ -0,0 +1,129 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dedlockdave/go-openrouter/jsonschema"
+)
+
+// ToolHandler is a tool definition together with its implementation.
+type ToolHandler interface {
+	Definition() Tool
+	// Call executes the tool call and returns the tool message answering it.
+	Call(ctx context.Context, call ToolCall) (ChatCompletionMessage, error)
+}
+
+// ToolArgumentsError reports tool call arguments that don't match the schema of
+// the tool. Its message is meant to be sent back to the model.
+type ToolArgumentsError struct {
+	Tool string
+	Err  error
+}
+
+func (e *ToolArgumentsError) Error() string {
+	return fmt.Sprintf("invalid arguments for tool %s: %v", e.Tool, e.Err)
+}
+
+func (e *ToolArgumentsError) Unwrap() error {
+	return e.Err
+}
+
+// NewFunctionTool returns a function tool whose parameters schema is reflected
+// from the parameters struct P, see jsonschema.Reflect for the supported tags.
+func NewFunctionTool[P any](name, description string) (Tool, error) {
+	var params P
+	schema, err := jsonschema.Reflect(params)
+	if err != nil {
+		return Tool{}, err
+	}
+	return Tool{
+		Type: ToolTypeFunction,
+		Function: &FunctionDefinition{
+			Name:        name,
+			Description: description,
+			Parameters:  schema,
+		},
+	}, nil
+}
+
+// ParseToolArguments validates the arguments of call against the schema of P and
+// unmarshals them. Failures are reported as a *ToolArgumentsError.
+func ParseToolArguments[P any](call ToolCall) (P, error) {
+	var params P
+	schema, err := jsonschema.Reflect(params)
+	if err != nil {
+		return params, err
+	}
+	args := []byte(call.Function.Arguments)
+	if len(args) == 0 {
+		args = []byte("{}")
+	}
+	if err = jsonschema.Validate(schema, args); err != nil {
+		return params, &ToolArgumentsError{Tool: call.Function.Name, Err: err}
+	}
+	if err = json.Unmarshal(args, &params); err != nil {
+		return params, &ToolArgumentsError{Tool: call.Function.Name, Err: err}
+	}
+	return params, nil
+}
+
+// ToolFunc exposes a Go function taking a parameters struct P as a tool.
+type ToolFunc[P any] struct {
+	tool Tool
+	// fn runs the function and sets the content of the tool message.
+	fn func(ctx context.Context, params P, message *ChatCompletionMessage) error
+}
+
+var _ ToolHandler = (*ToolFunc[struct{}])(nil)
+
+func NewToolFunc[P any](
+	name, description string,
+	fn func(ctx context.Context, params P) (string, error),
+) (*ToolFunc[P], error) {
+	tool, err := NewFunctionTool[P](name, description)
+	if err != nil {
+		return nil, err
+	}
+	return &ToolFunc[P]{tool: tool, fn: func(ctx context.Context, params P, message *ChatCompletionMessage) (err error) {
+		message.Content, err = fn(ctx, params)
+		return err
+	}}, nil
+}
+
+// NewToolPartsFunc is NewToolFunc for a function returning content parts, e.g.
+// images or JSON payloads (see ImagePart and JSONPart), sent as the content of
+// the tool message.
+func NewToolPartsFunc[P any](
+	name, description string,
+	fn func(ctx context.Context, params P) ([]ChatMessagePart, error),
+) (*ToolFunc[P], error) {
+	tool, err := NewFunctionTool[P](name, description)
+	if err != nil {
+		return nil, err
+	}
+	return &ToolFunc[P]{tool: tool, fn: func(ctx context.Context, params P, message *ChatCompletionMessage) (err error) {
+		message.MultiContent, err = fn(ctx, params)
+		return err
+	}}, nil
+}
+
+func (t *ToolFunc[P]) Definition() Tool {
+	return t.tool
+}
+
+// Call parses the arguments and runs the function. Invalid arguments don't
+// fail the call: the returned message explains the error to the model so it
+// can retry. Errors of the function itself are returned.
+func (t *ToolFunc[P]) Call(ctx context.Context, call ToolCall) (ChatCompletionMessage, error) {
+	message := ChatCompletionMessage{Role: ChatMessageRoleTool, ToolCallID: call.ID}
+
+	params, err := ParseToolArguments[P](call)
+	if err != nil {
+		message.Content = err.Error()
+		return message, nil
+	}
+	err = t.fn(ctx, params, &message)
+	return message, err
+}
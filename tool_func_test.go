@@ -0,0 +1,74 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type weatherParams struct {
+	City string `json:"city" jsonschema:"description=City name\\, e.g. Paris"`
+	Unit string `json:"unit,omitempty" jsonschema:"enum=celsius,enum=fahrenheit"`
+}
+
+func TestToolFunc(t *testing.T) {
+	tool, err := NewToolFunc("weather", "Get the weather", func(_ context.Context, p weatherParams) (string, error) {
+		return "sunny in " + p.City, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := json.Marshal(tool.Definition().Function.Parameters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"object","properties":{"city":{"type":"string","description":"City name, e.g. Paris"},` +
+		`"unit":{"type":"string","enum":["celsius","fahrenheit"]}},"required":["city"],"additionalProperties":false}`
+	if string(schema) != want {
+		t.Errorf("got schema %s, want %s", schema, want)
+	}
+
+	msg, err := tool.Call(context.Background(), ToolCall{ID: "1", Function: FunctionCall{Arguments: `{"city":"Paris"}`}})
+	if err != nil || msg.Content != "sunny in Paris" || msg.ToolCallID != "1" {
+		t.Errorf("unexpected result %#v, %v", msg, err)
+	}
+
+	msg, err = tool.Call(context.Background(), ToolCall{Function: FunctionCall{Arguments: `{"unit":"kelvin"}`}})
+	if err != nil || !strings.Contains(msg.Content, `missing required property "city"`) {
+		t.Errorf("expected validation error message, got %#v, %v", msg, err)
+	}
+}
+
+func TestToolPartsFunc(t *testing.T) {
+	tool, err := NewToolPartsFunc("chart", "Plot the weather", func(_ context.Context, p weatherParams) ([]ChatMessagePart, error) {
+		data, err := JSONPart(map[string]any{"city": p.City, "temperature": 21})
+		if err != nil {
+			return nil, err
+		}
+		return []ChatMessagePart{data, ImagePart(ImageDataURL([]byte("png"), "image/png"), "")}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := tool.Call(context.Background(), ToolCall{ID: "1", Function: FunctionCall{Arguments: `{"city":"Paris"}`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"role":"tool","content":[{"type":"text","text":"{\"city\":\"Paris\",\"temperature\":21}"},` +
+		`{"type":"image_url","image_url":{"url":"data:image/png;base64,cG5n"}}],"tool_call_id":"1"}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	var decoded ChatCompletionMessage
+	if err = json.Unmarshal(data, &decoded); err != nil || len(decoded.MultiContent) != 2 || decoded.ToolCallID != "1" {
+		t.Errorf("unexpected round trip %#v, %v", decoded, err)
+	}
+}
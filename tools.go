@@ -0,0 +1,45 @@
+package openrouter
+
+type ToolType string
+
+const (
+	ToolTypeFunction ToolType = "function"
+)
+
+// FunctionDefinition describes a function the model may call. Parameters is
+// its JSON schema, e.g. a *jsonschema.Definition.
+type FunctionDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Strict      bool   `json:"strict,omitempty"`
+	Parameters  any    `json:"parameters"`
+}
+
+type Tool struct {
+	Type     ToolType            `json:"type"`
+	Function *FunctionDefinition `json:"function,omitempty"`
+}
+
+// ToolChoice forces the model to call the given function.
+type ToolChoice struct {
+	Type     ToolType     `json:"type"`
+	Function ToolFunction `json:"function,omitempty"`
+}
+
+type ToolFunction struct {
+	Name string `json:"name"`
+}
+
+type FunctionCall struct {
+	Name string `json:"name,omitempty"`
+	// Arguments is the JSON encoded arguments of the call.
+	Arguments string `json:"arguments,omitempty"`
+}
+
+type ToolCall struct {
+	// Index identifies the call across the chunks of a stream.
+	Index    *int         `json:"index,omitempty"`
+	ID       string       `json:"id,omitempty"`
+	Type     ToolType     `json:"type"`
+	Function FunctionCall `json:"function"`
+}
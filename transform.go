@@ -0,0 +1,104 @@
+package openrouter
+
+import (
+	"slices"
+	"strings"
+)
+
+// RequestTransformer adapts a chat completion request to the quirks of a model
+// family. It is applied to a copy of the request just before it is marshaled,
+// so that the caller's request, the cache and the deduplication are unaffected.
+type RequestTransformer func(request *ChatCompletionRequest)
+
+// Model families of the built-in transformers, see ModelFamily.
+const (
+	ModelFamilyOpenAI    = "openai"
+	ModelFamilyAnthropic = "anthropic"
+	ModelFamilyGoogle    = "google"
+)
+
+// ModelFamily returns the family of a model ID, its author: "anthropic" for
+// "anthropic/claude-3.5-sonnet".
+func ModelFamily(model string) string {
+	author, _, _ := strings.Cut(model, "/")
+	return author
+}
+
+// DefaultRequestTransformers returns the built-in transformers keyed by model
+// family, to use as ClientConfig.RequestTransformers. The map is new at each
+// call and can be extended with other families.
+//
+// The transformers only cover the quirks the client options don't: see
+// NormalizeSystemPrompts for the system messages, DropUnsupportedParameters for
+// the parameters a model rejects. The token limit is always sent as the model
+// expects it.
+func DefaultRequestTransformers() map[string]RequestTransformer {
+	return map[string]RequestTransformer{
+		ModelFamilyOpenAI:    TransformOpenAIRequest,
+		ModelFamilyAnthropic: TransformAnthropicRequest,
+		ModelFamilyGoogle:    TransformGoogleRequest,
+	}
+}
+
+// Bounds of the OpenAI and Google APIs.
+const (
+	openAIMaxStopSequences = 4
+	openAIMaxLogitBias     = 100
+	openAIMaxTopLogprobs   = 20
+	googleMaxStopSequences = 5
+)
+
+// TransformOpenAIRequest adapts a request to the OpenAI models, which reject
+// more than 4 stop sequences, logit biases outside [-100, 100] and more than 20
+// top logprobs: the extra stop sequences are dropped, the biases and the top
+// logprobs are clamped.
+func TransformOpenAIRequest(request *ChatCompletionRequest) {
+	request.Stop = truncateStop(request.Stop, openAIMaxStopSequences)
+	if len(request.LogitBias) > 0 {
+		bias := make(map[string]int, len(request.LogitBias))
+		for token, value := range request.LogitBias {
+			bias[token] = min(max(value, -openAIMaxLogitBias), openAIMaxLogitBias)
+		}
+		request.LogitBias = bias
+	}
+	request.TopLogprobs = min(request.TopLogprobs, openAIMaxTopLogprobs)
+}
+
+// TransformAnthropicRequest adapts a request to the Anthropic models, which
+// reject temperatures above 1: the temperature is capped to 1.
+func TransformAnthropicRequest(request *ChatCompletionRequest) {
+	if request.Temperature != nil && *request.Temperature > 1 {
+		temperature := float32(1)
+		request.Temperature = &temperature
+	}
+}
+
+// TransformGoogleRequest adapts a request to the Google models, which reject
+// more than 5 stop sequences and messages without content: the extra stop
+// sequences and the empty messages are dropped.
+func TransformGoogleRequest(request *ChatCompletionRequest) {
+	request.Stop = truncateStop(request.Stop, googleMaxStopSequences)
+	request.Messages = slices.DeleteFunc(request.Messages, func(message ChatCompletionMessage) bool {
+		return message.Content == "" && len(message.MultiContent) == 0 && len(message.ToolCalls) == 0
+	})
+}
+
+func truncateStop(stop StringOrSlice, limit int) StringOrSlice {
+	if len(stop) > limit {
+		return stop[:limit]
+	}
+	return stop
+}
+
+// transformChatRequest returns a copy of request transformed for its model
+// family, or request itself when no transformer matches.
+func (c *Client) transformChatRequest(request *ChatCompletionRequest) *ChatCompletionRequest {
+	transform := c.config.RequestTransformers[ModelFamily(request.Model)]
+	if transform == nil {
+		return request
+	}
+	transformed := *request
+	transformed.Messages = slices.Clone(request.Messages)
+	transform(&transformed)
+	return &transformed
+}
@@ -0,0 +1,108 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestDefaultRequestTransformers(t *testing.T) {
+	temperature, penalty := float32(1.5), float32(0.5)
+	request := &ChatCompletionRequest{
+		Model: "anthropic/claude-3.5-sonnet",
+		Messages: []ChatCompletionMessage{
+			{Role: ChatMessageRoleSystem, Content: "be brief"},
+			{Role: ChatMessageRoleUser, Content: "hi"},
+			{Role: ChatMessageRoleDeveloper, Content: "answer in French"},
+		},
+		MaxTokens:        100,
+		Temperature:      &temperature,
+		FrequencyPenalty: &penalty,
+	}
+	transformers := DefaultRequestTransformers()
+	transformers[ModelFamilyAnthropic](request)
+	if *request.Temperature != 1 {
+		t.Errorf("expected the temperature to be capped, got %v", *request.Temperature)
+	}
+	if len(request.Messages) != 3 || request.MaxTokens != 100 || request.FrequencyPenalty == nil {
+		t.Errorf("expected the rest of the request to be left to the client options, got %+v", request)
+	}
+
+	request = &ChatCompletionRequest{
+		Model:       "openai/gpt-4o",
+		Messages:    []ChatCompletionMessage{{Role: ChatMessageRoleSystem, Content: "be brief"}, {Role: ChatMessageRoleUser, Content: "hi"}},
+		Stop:        StringOrSlice{"1", "2", "3", "4", "5", "6"},
+		LogitBias:   map[string]int{"1": 150, "2": -150, "3": 5},
+		TopLogprobs: 30,
+	}
+	bias := request.LogitBias
+	transformers[ModelFamilyOpenAI](request)
+	if len(request.Stop) != 4 || request.TopLogprobs != 20 ||
+		request.LogitBias["1"] != 100 || request.LogitBias["2"] != -100 || request.LogitBias["3"] != 5 {
+		t.Errorf("expected the OpenAI bounds to be applied, got %+v", request)
+	}
+	if bias["1"] != 150 || request.Messages[0].Role != ChatMessageRoleSystem {
+		t.Errorf("expected the biases to be copied and the messages left to the client options, got %+v", request)
+	}
+
+	request = &ChatCompletionRequest{
+		Model: "google/gemini-2.0-flash",
+		Messages: []ChatCompletionMessage{
+			{Role: ChatMessageRoleUser, Content: "hi"},
+			{Role: ChatMessageRoleAssistant},
+			{Role: ChatMessageRoleAssistant, ToolCalls: []ToolCall{{ID: "1", Type: ToolTypeFunction}}},
+			{Role: ChatMessageRoleUser, Content: "again"},
+		},
+		Stop: StringOrSlice{"1", "2", "3", "4", "5", "6"},
+	}
+	transformers[ModelFamilyGoogle](request)
+	if len(request.Stop) != 5 || len(request.Messages) != 3 || request.Messages[1].ToolCalls == nil {
+		t.Errorf("expected the Google bounds to be applied, got %+v", request)
+	}
+}
+
+func TestClient_RequestTransformers(t *testing.T) {
+	var body map[string]any
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = io.WriteString(w, `{"model":"m","choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	})
+	client.config.RequestTransformers = DefaultRequestTransformers()
+
+	temperature := float32(1.5)
+	request := &ChatCompletionRequest{
+		Model: "anthropic/claude-3.5-sonnet",
+		Messages: []ChatCompletionMessage{
+			{Role: ChatMessageRoleUser, Content: "hi"},
+			{Role: ChatMessageRoleSystem, Content: "be brief"},
+		},
+		Temperature: &temperature,
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	// NormalizeSystemPrompts is off: the system message stays in place.
+	if body["temperature"] != 1.0 || body["messages"].([]any)[1].(map[string]any)["role"] != "system" {
+		t.Errorf("unexpected body %v", body)
+	}
+	if *request.Temperature != 1.5 {
+		t.Errorf("the request of the caller was modified: %+v", request)
+	}
+
+	request = &ChatCompletionRequest{
+		Model:    "google/gemini-2.0-flash",
+		Messages: []ChatCompletionMessage{{Role: ChatMessageRoleAssistant}, {Role: ChatMessageRoleUser, Content: "hi"}},
+		Stop:     StringOrSlice{"1", "2", "3", "4", "5", "6"},
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	if len(body["messages"].([]any)) != 1 || len(body["stop"].([]any)) != 5 {
+		t.Errorf("unexpected body %v", body)
+	}
+	if len(request.Messages) != 2 || len(request.Stop) != 6 {
+		t.Errorf("the request of the caller was modified: %+v", request)
+	}
+}
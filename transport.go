@@ -0,0 +1,63 @@
+package openrouter
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TransportConfig describes the HTTP transport of the client. Zero fields keep
+// the values of http.DefaultTransport.
+type TransportConfig struct {
+	// ProxyURL overrides the proxy taken from the environment.
+	ProxyURL  *url.URL
+	TLSConfig *tls.Config
+
+	DialTimeout         time.Duration
+	KeepAlive           time.Duration
+	TLSHandshakeTimeout time.Duration
+
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+}
+
+// NewTransport builds an http.Transport from the configuration.
+func (tc TransportConfig) NewTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tc.ProxyURL != nil {
+		transport.Proxy = http.ProxyURL(tc.ProxyURL)
+	}
+	if tc.TLSConfig != nil {
+		transport.TLSClientConfig = tc.TLSConfig
+	}
+	if tc.DialTimeout > 0 || tc.KeepAlive > 0 {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		if tc.DialTimeout > 0 {
+			dialer.Timeout = tc.DialTimeout
+		}
+		if tc.KeepAlive > 0 {
+			dialer.KeepAlive = tc.KeepAlive
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	if tc.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = tc.TLSHandshakeTimeout
+	}
+	if tc.MaxIdleConns > 0 {
+		transport.MaxIdleConns = tc.MaxIdleConns
+	}
+	if tc.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = tc.MaxIdleConnsPerHost
+	}
+	if tc.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = tc.MaxConnsPerHost
+	}
+	if tc.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = tc.IdleConnTimeout
+	}
+	return transport
+}
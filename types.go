@@ -1,5 +1,11 @@
 package openrouter
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+)
+
 const (
 	GooglePalm2CodeChatBison = "google/palm-2-codechat-bison"
 	GooglePalm2ChatBison     = "google/palm-2-chat-bison"
@@ -59,9 +65,220 @@ func checkSupportsModel(model string) bool {
 	// return enableModels[model]
 }
 
+type ChatMessagePartType string
+
+const (
+	ChatMessagePartTypeText       ChatMessagePartType = "text"
+	ChatMessagePartTypeFile       ChatMessagePartType = "file"
+	ChatMessagePartTypeImageURL   ChatMessagePartType = "image_url"
+	ChatMessagePartTypeInputAudio ChatMessagePartType = "input_audio"
+)
+
+type Transform string
+
+const (
+	TransformMiddleOut Transform = "middle-out"
+)
+
+type Modality string
+
+const (
+	ModalityText  Modality = "text"
+	ModalityImage Modality = "image"
+	ModalityAudio Modality = "audio"
+)
+
+const (
+	CacheControlTypeEphemeral = "ephemeral"
+)
+
+// CacheControl marks a content part as a prompt caching breakpoint.
+// Supported by Anthropic and Gemini models.
+type CacheControl struct {
+	Type string `json:"type"`
+}
+
+// ChatMessagePart is a single element of a multi-part message content.
+type ChatMessagePart struct {
+	Type         ChatMessagePartType    `json:"type"`
+	Text         string                 `json:"text,omitempty"`
+	File         *ChatMessageFile       `json:"file,omitempty"`
+	ImageURL     *ChatMessageImageURL   `json:"image_url,omitempty"`
+	InputAudio   *ChatMessageInputAudio `json:"input_audio,omitempty"`
+	CacheControl *CacheControl          `json:"cache_control,omitempty"`
+}
+
+// ChatMessageImageURL is the payload of an image content part. URL is either a
+// public URL or a base64 data URL.
+type ChatMessageImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ChatMessageInputAudio is the payload of an audio content part. Data is the
+// base64 encoded audio and Format its encoding, e.g. "wav" or "mp3".
+type ChatMessageInputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"`
+}
+
+// ChatMessageFile is the payload of a file content part. FileData is either a
+// public URL or a base64 data URL (see PDFDataURL).
+type ChatMessageFile struct {
+	Filename string `json:"filename"`
+	FileData string `json:"file_data"`
+}
+
+// ChatCompletionMessage is a message of the conversation. Content and MultiContent
+// are mutually exclusive: use MultiContent to send content parts, e.g. text parts
+// carrying cache_control breakpoints.
 type ChatCompletionMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role         string            `json:"role"`
+	Content      string            `json:"content"`
+	MultiContent []ChatMessagePart `json:"-"`
+	// ToolCalls are the tool calls requested by an assistant message.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID is the call a tool message answers.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	// Name distinguishes the participants sharing a role, e.g. the users of a
+	// multi-party conversation.
+	Name string `json:"name,omitempty"`
+	// Annotations are the citations of an assistant reply, e.g. from the web plugin.
+	Annotations []Annotation `json:"annotations,omitempty"`
+	// Reasoning is the reasoning of an assistant reply, sent back to the models
+	// continuing from it.
+	Reasoning string `json:"reasoning,omitempty"`
+}
+
+func (m ChatCompletionMessage) MarshalJSON() ([]byte, error) {
+	if m.Content != "" && m.MultiContent != nil {
+		return nil, ErrContentFieldsMisused
+	}
+	if len(m.MultiContent) > 0 {
+		msg := struct {
+			Role         string            `json:"role"`
+			Content      string            `json:"-"`
+			MultiContent []ChatMessagePart `json:"content,omitempty"`
+			ToolCalls    []ToolCall        `json:"tool_calls,omitempty"`
+			ToolCallID   string            `json:"tool_call_id,omitempty"`
+			Name         string            `json:"name,omitempty"`
+			Annotations  []Annotation      `json:"annotations,omitempty"`
+			Reasoning    string            `json:"reasoning,omitempty"`
+		}(m)
+		return json.Marshal(msg)
+	}
+	msg := struct {
+		Role         string            `json:"role"`
+		Content      string            `json:"content"`
+		MultiContent []ChatMessagePart `json:"-"`
+		ToolCalls    []ToolCall        `json:"tool_calls,omitempty"`
+		ToolCallID   string            `json:"tool_call_id,omitempty"`
+		Name         string            `json:"name,omitempty"`
+		Annotations  []Annotation      `json:"annotations,omitempty"`
+		Reasoning    string            `json:"reasoning,omitempty"`
+	}(m)
+	return json.Marshal(msg)
+}
+
+func (m *ChatCompletionMessage) UnmarshalJSON(bs []byte) error {
+	msg := struct {
+		Role         string            `json:"role"`
+		Content      string            `json:"content"`
+		MultiContent []ChatMessagePart `json:"-"`
+		ToolCalls    []ToolCall        `json:"tool_calls,omitempty"`
+		ToolCallID   string            `json:"tool_call_id,omitempty"`
+		Name         string            `json:"name,omitempty"`
+		Annotations  []Annotation      `json:"annotations,omitempty"`
+		Reasoning    string            `json:"reasoning,omitempty"`
+	}{}
+	if err := json.Unmarshal(bs, &msg); err == nil {
+		*m = ChatCompletionMessage(msg)
+		return nil
+	}
+	multiMsg := struct {
+		Role         string            `json:"role"`
+		Content      string            `json:"-"`
+		MultiContent []ChatMessagePart `json:"content"`
+		ToolCalls    []ToolCall        `json:"tool_calls,omitempty"`
+		ToolCallID   string            `json:"tool_call_id,omitempty"`
+		Name         string            `json:"name,omitempty"`
+		Annotations  []Annotation      `json:"annotations,omitempty"`
+		Reasoning    string            `json:"reasoning,omitempty"`
+	}{}
+	if err := json.Unmarshal(bs, &multiMsg); err != nil {
+		return err
+	}
+	*m = ChatCompletionMessage(multiMsg)
+	return nil
+}
+
+// TextPart returns a text content part.
+func TextPart(text string) ChatMessagePart {
+	return ChatMessagePart{Type: ChatMessagePartTypeText, Text: text}
+}
+
+// FilePart returns a file content part. fileData is a URL or a base64 data URL.
+func FilePart(filename, fileData string) ChatMessagePart {
+	return ChatMessagePart{
+		Type: ChatMessagePartTypeFile,
+		File: &ChatMessageFile{Filename: filename, FileData: fileData},
+	}
+}
+
+// PDFDataURL encodes a PDF document as a base64 data URL suitable for FilePart.
+func PDFDataURL(data []byte) string {
+	return "data:application/pdf;base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// InputAudioPart returns an audio content part from raw audio bytes.
+func InputAudioPart(data []byte, format string) ChatMessagePart {
+	return ChatMessagePart{
+		Type: ChatMessagePartTypeInputAudio,
+		InputAudio: &ChatMessageInputAudio{
+			Data:   base64.StdEncoding.EncodeToString(data),
+			Format: format,
+		},
+	}
+}
+
+// ImagePart returns an image content part. url is a public URL or a base64 data
+// URL, detail is optional ("low", "high" or "auto").
+func ImagePart(url, detail string) ChatMessagePart {
+	return ChatMessagePart{
+		Type:     ChatMessagePartTypeImageURL,
+		ImageURL: &ChatMessageImageURL{URL: url, Detail: detail},
+	}
+}
+
+// ImageDataURL encodes an image as a base64 data URL suitable for ImagePart,
+// mimeType is e.g. "image/png".
+func ImageDataURL(data []byte, mimeType string) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// JSONPart returns a text content part carrying the JSON encoding of v, e.g. a
+// structured tool result.
+func JSONPart(v any) (ChatMessagePart, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ChatMessagePart{}, err
+	}
+	return TextPart(string(data)), nil
+}
+
+// ToolResultMessage returns the tool message answering the call callID with
+// content parts.
+func ToolResultMessage(callID string, parts ...ChatMessagePart) ChatCompletionMessage {
+	return ChatCompletionMessage{Role: ChatMessageRoleTool, ToolCallID: callID, MultiContent: parts}
+}
+
+// CachedTextPart returns a text content part marked as an ephemeral cache breakpoint.
+func CachedTextPart(text string) ChatMessagePart {
+	return ChatMessagePart{
+		Type:         ChatMessagePartTypeText,
+		Text:         text,
+		CacheControl: &CacheControl{Type: CacheControlTypeEphemeral},
+	}
 }
 
 // ChatCompletionRequest represents a request structure for chat completion API.
@@ -73,31 +290,290 @@ type ChatCompletionRequest struct {
 	Temperature *float32                `json:"temperature,omitempty"`
 	TopP        *float32                `json:"top_p,omitempty"`
 	TopK        *uint                   `json:"top_k,omitempty"`
+	MinP        *float32                `json:"min_p,omitempty"`
+	TopA        *float32                `json:"top_a,omitempty"`
+	// Penalties and Seed are pointers so that an explicit zero is sent while nil is omitted.
+	FrequencyPenalty  *float32       `json:"frequency_penalty,omitempty"`
+	PresencePenalty   *float32       `json:"presence_penalty,omitempty"`
+	RepetitionPenalty *float32       `json:"repetition_penalty,omitempty"`
+	Seed              *int           `json:"seed,omitempty"`
+	LogitBias         map[string]int `json:"logit_bias,omitempty"`
+	Stop              StringOrSlice  `json:"stop,omitempty"`
+	Logprobs          bool           `json:"logprobs,omitempty"`
+	// TopLogprobs is the number of most likely alternatives returned per token, requires Logprobs.
+	TopLogprobs int                    `json:"top_logprobs,omitempty"`
+	Plugins     []ChatCompletionPlugin `json:"plugins,omitempty"`
+	// Transforms are applied to the prompt by the API, e.g. TransformMiddleOut
+	// compresses the conversations exceeding the context of the model instead
+	// of failing.
+	Transforms []Transform `json:"transforms,omitempty"`
+	Modalities []Modality  `json:"modalities,omitempty"`
+	// Audio configures the audio output, requires ModalityAudio in Modalities.
+	Audio *AudioOutputConfig `json:"audio,omitempty"`
+	// MaxCompletionTokens replaces MaxTokens for the newer OpenAI models. Either
+	// can be set, the client translates to the one the model accepts.
+	MaxCompletionTokens int `json:"max_completion_tokens,omitempty"`
+	// Verbosity sets the length of the answers of the models supporting it.
+	Verbosity Verbosity `json:"verbosity,omitempty"`
+	// Prediction is the expected output, used by models supporting predicted outputs
+	// to reduce latency.
+	Prediction *Prediction `json:"prediction,omitempty"`
+	// Usage asks for usage accounting, including the cost, in the response.
+	Usage *UsageConfig `json:"usage,omitempty"`
+	// Provider sets the provider routing preferences.
+	Provider *ProviderPreferences `json:"provider,omitempty"`
+	Tools    []Tool               `json:"tools,omitempty"`
+	// ToolChoice is "none", "auto", "required" or a ToolChoice.
+	ToolChoice     any             `json:"tool_choice,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	// Metadata is attached to the request for your own bookkeeping.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// User identifies the end user for abuse attribution, see WithUser.
+	User string `json:"user,omitempty"`
+	// ExtraFields are merged into the request body, for API parameters not
+	// covered by this struct yet. They override the fields of the same name.
+	ExtraFields map[string]any `json:"-"`
 }
 
-type Index struct {
-	Role    string `json:"role"`
+type ResponseFormatType string
+
+const (
+	ResponseFormatTypeText       ResponseFormatType = "text"
+	ResponseFormatTypeJSONObject ResponseFormatType = "json_object"
+	ResponseFormatTypeJSONSchema ResponseFormatType = "json_schema"
+)
+
+// ResponseFormat constrains the output of the model, JSONSchema is required
+// with ResponseFormatTypeJSONSchema.
+type ResponseFormat struct {
+	Type       ResponseFormatType        `json:"type"`
+	JSONSchema *ResponseFormatJSONSchema `json:"json_schema,omitempty"`
+}
+
+// ResponseFormatJSONSchema is the schema of a structured output. Schema is
+// typically a *jsonschema.Definition.
+type ResponseFormatJSONSchema struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      any    `json:"schema"`
+	Strict      bool   `json:"strict,omitempty"`
+}
+
+// ProviderPreferences controls how OpenRouter routes the request among the
+// providers serving the model. Providers are identified by their slug.
+type ProviderPreferences struct {
+	Order             []string `json:"order,omitempty"`
+	AllowFallbacks    *bool    `json:"allow_fallbacks,omitempty"`
+	RequireParameters bool     `json:"require_parameters,omitempty"`
+	Only              []string `json:"only,omitempty"`
+	Ignore            []string `json:"ignore,omitempty"`
+	Sort              string   `json:"sort,omitempty"`
+	// DataCollection is DataCollectionAllow or DataCollectionDeny.
+	DataCollection string `json:"data_collection,omitempty"`
+	// ZDR restricts routing to zero-data-retention endpoints.
+	ZDR *bool `json:"zdr,omitempty"`
+	// MaxPrice excludes the endpoints priced above it.
+	MaxPrice *MaxPrice `json:"max_price,omitempty"`
+	// Quantizations restricts routing to the endpoints serving the model with
+	// one of these quantizations, e.g. QuantizationFP8.
+	Quantizations []string `json:"quantizations,omitempty"`
+	// MinContextLength is enforced by the client: the request is restricted
+	// with Only to the endpoints of the models catalog with at least this
+	// context length, see ErrNoEligibleEndpoint.
+	MinContextLength int `json:"-"`
+}
+
+// MaxPrice caps the prices of the endpoints a request is routed to, in USD per
+// million tokens for Prompt and Completion, per image and per request for Image
+// and Request. Zero fields are not capped.
+type MaxPrice struct {
+	Prompt     float64 `json:"prompt,omitempty"`
+	Completion float64 `json:"completion,omitempty"`
+	Image      float64 `json:"image,omitempty"`
+	Request    float64 `json:"request,omitempty"`
+}
+
+type UsageConfig struct {
+	Include bool `json:"include"`
+}
+
+const (
+	PredictionTypeContent = "content"
+)
+
+type Prediction struct {
+	Type    string `json:"type"`
 	Content string `json:"content"`
 }
 
+// ContentPrediction returns a prediction of the given output content.
+func ContentPrediction(content string) *Prediction {
+	return &Prediction{Type: PredictionTypeContent, Content: content}
+}
+
+// AudioOutputConfig selects the voice and encoding of generated audio.
+type AudioOutputConfig struct {
+	Voice  string `json:"voice"`
+	Format string `json:"format"`
+}
+
+// AudioOutput is the audio generated for an assistant message. Data is base64
+// encoded, streamed responses deliver it in chunks.
+type AudioOutput struct {
+	ID         string `json:"id,omitempty"`
+	Data       string `json:"data,omitempty"`
+	Transcript string `json:"transcript,omitempty"`
+	ExpiresAt  int64  `json:"expires_at,omitempty"`
+}
+
+// Decode returns the raw audio bytes.
+func (a AudioOutput) Decode() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(a.Data)
+}
+
+type Index struct {
+	Role        string       `json:"role"`
+	Content     string       `json:"content"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+	// Images holds the images generated when the request asked for the image modality.
+	Images    []ChatMessagePart `json:"images,omitempty"`
+	Audio     *AudioOutput      `json:"audio,omitempty"`
+	ToolCalls []ToolCall        `json:"tool_calls,omitempty"`
+	// Reasoning is the reasoning of the model, when it returns it.
+	Reasoning string `json:"reasoning,omitempty"`
+}
+
 type ChatCompletionChoice struct {
-	Message      Index  `json:"message,omitempty"`
-	FinishReason string `json:"finish_reason,omitempty"`
-	Delta        Index  `json:"delta,omitempty"`
-	Index        uint   `json:"index,omitempty"`
+	Message      Index        `json:"message,omitempty"`
+	FinishReason FinishReason `json:"finish_reason,omitempty"`
+	// NativeFinishReason is the finish reason as reported by the provider.
+	NativeFinishReason string    `json:"native_finish_reason,omitempty"`
+	Delta              Index     `json:"delta,omitempty"`
+	Index              uint      `json:"index,omitempty"`
+	Logprobs           *LogProbs `json:"logprobs,omitempty"`
+}
+
+// LogProbs holds the log probabilities of the generated tokens of a choice.
+type LogProbs struct {
+	Content []LogProb `json:"content"`
+}
+
+// LogProb is the log probability of a single token, with the most likely
+// alternatives when top_logprobs was requested.
+type LogProb struct {
+	Token       string       `json:"token"`
+	LogProb     float64      `json:"logprob"`
+	Bytes       []int        `json:"bytes,omitempty"`
+	TopLogProbs []TopLogProb `json:"top_logprobs"`
+}
+
+type TopLogProb struct {
+	Token   string  `json:"token"`
+	LogProb float64 `json:"logprob"`
+	Bytes   []int   `json:"bytes,omitempty"`
 }
 
 // ChatCompletionResponse represents a response structure for chat completion API.
 type ChatCompletionResponse struct {
-	ID      string                 `json:"id,omitempty"`
-	Object  string                 `json:"object,omitempty"`
-	Created int64                  `json:"created,omitempty"`
-	Model   string                 `json:"model"`
-	Choices []ChatCompletionChoice `json:"choices"`
-	//Usage   Usage                  `json:"usage,omitempty"`
+	ID      string `json:"id,omitempty"`
+	Object  string `json:"object,omitempty"`
+	Created int64  `json:"created,omitempty"`
+	// Model is the model that served the request, including its variant.
+	Model string `json:"model"`
+	// Provider is the provider that served the request.
+	Provider string                 `json:"provider,omitempty"`
+	Choices  []ChatCompletionChoice `json:"choices"`
+	Usage    *Usage                 `json:"usage,omitempty"`
+	// SystemFingerprint identifies the backend configuration of the provider.
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+	// Warning is set when the API reported an error along with the choices.
+	Warning *APIWarning `json:"-"`
+	// Timings are measured by the client, they are nil for stream chunks and
+	// responses built outside of the client.
+	Timings *Timings `json:"-"`
+	// RouterArm is the arm chosen by the Router that sent the request, if any.
+	RouterArm string `json:"-"`
+	// ExtraFields holds the response fields not covered by this struct.
+	ExtraFields map[string]json.RawMessage `json:"-"`
+}
+
+// AppliedTransforms returns the transforms the API reports as applied to the
+// prompt, nil when it reports none.
+func (r *ChatCompletionResponse) AppliedTransforms() []Transform {
+	var transforms []Transform
+	if raw, ok := r.ExtraFields["transforms"]; ok {
+		_ = json.Unmarshal(raw, &transforms)
+	}
+	return transforms
 }
 
+// TransformApplied reports whether the API reports transform as applied to the
+// prompt, e.g. whether middle-out compressed the conversation.
+func (r *ChatCompletionResponse) TransformApplied(transform Transform) bool {
+	return slices.Contains(r.AppliedTransforms(), transform)
+}
+
+// Usage is the token usage of a response. The token counts are normalized with
+// the GPT tokenizer, whatever the model; Native holds the counts of the
+// tokenizer of the model, when known.
 type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	// Cost is the cost in credits, only reported when usage accounting is requested.
+	Cost                float64              `json:"cost,omitempty"`
+	PromptTokensDetails *PromptTokensDetails `json:"prompt_tokens_details,omitempty"`
+
+	CompletionTokensDetails *CompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+	// Native are the native token counts, not part of the API response: they are
+	// set from the generation stats, see ClientConfig.NativeUsage.
+	Native *NativeUsage `json:"native,omitempty"`
+}
+
+// CompletionTokensDetails breaks down the completion tokens. The prediction counts
+// report how many tokens of a predicted output were accepted or rejected.
+type CompletionTokensDetails struct {
+	AcceptedPredictionTokens int `json:"accepted_prediction_tokens,omitempty"`
+	RejectedPredictionTokens int `json:"rejected_prediction_tokens,omitempty"`
+	// ReasoningTokens are the completion tokens spent on reasoning.
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+}
+
+// Add accumulates other into u.
+func (u *Usage) Add(other *Usage) {
+	if other == nil {
+		return
+	}
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.Cost += other.Cost
+	if other.PromptTokensDetails != nil {
+		if u.PromptTokensDetails == nil {
+			u.PromptTokensDetails = &PromptTokensDetails{}
+		}
+		u.PromptTokensDetails.CachedTokens += other.PromptTokensDetails.CachedTokens
+	}
+	if other.CompletionTokensDetails != nil {
+		if u.CompletionTokensDetails == nil {
+			u.CompletionTokensDetails = &CompletionTokensDetails{}
+		}
+		u.CompletionTokensDetails.AcceptedPredictionTokens += other.CompletionTokensDetails.AcceptedPredictionTokens
+		u.CompletionTokensDetails.RejectedPredictionTokens += other.CompletionTokensDetails.RejectedPredictionTokens
+		u.CompletionTokensDetails.ReasoningTokens += other.CompletionTokensDetails.ReasoningTokens
+	}
+	if other.Native != nil {
+		if u.Native == nil {
+			u.Native = &NativeUsage{}
+		}
+		u.Native.PromptTokens += other.Native.PromptTokens
+		u.Native.CompletionTokens += other.Native.CompletionTokens
+		u.Native.ReasoningTokens += other.Native.ReasoningTokens
+	}
+}
+
+// PromptTokensDetails breaks down the prompt tokens. CachedTokens is the number of
+// prompt tokens read from the provider's prompt cache.
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
 }
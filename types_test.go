@@ -0,0 +1,267 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestChatCompletionMessage_MarshalJSON(t *testing.T) {
+	msg := ChatCompletionMessage{
+		Role:         ChatMessageRoleSystem,
+		MultiContent: []ChatMessagePart{CachedTextPart("large document")},
+	}
+	b, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"role":"system","content":[{"type":"text","text":"large document","cache_control":{"type":"ephemeral"}}]}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	var decoded ChatCompletionMessage
+	if err = json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.MultiContent) != 1 || decoded.MultiContent[0].CacheControl == nil {
+		t.Errorf("unexpected decoded message %#v", decoded)
+	}
+
+	msg.Content = "text"
+	if _, err = json.Marshal(msg); !errors.Is(err, ErrContentFieldsMisused) {
+		t.Errorf("expected ErrContentFieldsMisused, got %v", err)
+	}
+}
+
+func TestChatCompletionMessage_NameAndAnnotations(t *testing.T) {
+	b, err := json.Marshal(ChatCompletionMessage{Role: ChatMessageRoleUser, Content: "hi", Name: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"role":"user","content":"hi","name":"alice"}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	var decoded ChatCompletionMessage
+	body := `{"role":"assistant","content":"see","annotations":[{"type":"url_citation","url_citation":{"url":"https://go.dev"}}]}`
+	if err = json.Unmarshal([]byte(body), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Annotations) != 1 || decoded.Annotations[0].URLCitation.URL != "https://go.dev" {
+		t.Errorf("unexpected decoded message %#v", decoded)
+	}
+}
+
+func TestUsage_CachedTokens(t *testing.T) {
+	var resp ChatCompletionResponse
+	body := `{"model":"m","choices":[],"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12,"prompt_tokens_details":{"cached_tokens":8}}}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Usage == nil || resp.Usage.PromptTokensDetails == nil || resp.Usage.PromptTokensDetails.CachedTokens != 8 {
+		t.Errorf("unexpected usage %#v", resp.Usage)
+	}
+}
+
+func TestChatCompletionRequest_ExtraFields(t *testing.T) {
+	req := ChatCompletionRequest{Model: "m", ExtraFields: map[string]any{"reasoning": map[string]any{"effort": "high"}}}
+	b, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"messages":null,"model":"m","reasoning":{"effort":"high"}}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	var resp ChatCompletionResponse
+	if err = json.Unmarshal([]byte(`{"model":"m","choices":[],"new_field":1}`), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.ExtraFields["new_field"]) != "1" || len(resp.ExtraFields) != 1 {
+		t.Errorf("unexpected extra fields %v", resp.ExtraFields)
+	}
+}
+
+func TestTranslateTokenLimits(t *testing.T) {
+	request := ChatCompletionRequest{Model: "openai/o3-mini", MaxTokens: 100, Verbosity: VerbosityLow}
+	translateTokenLimits(&request)
+	if request.MaxTokens != 0 || request.MaxCompletionTokens != 100 || request.Verbosity != "" {
+		t.Errorf("unexpected o3 request %+v", request)
+	}
+
+	request = ChatCompletionRequest{Model: "openai/gpt-5", MaxTokens: 100, Verbosity: VerbosityLow}
+	translateTokenLimits(&request)
+	if request.MaxCompletionTokens != 100 || request.Verbosity != VerbosityLow {
+		t.Errorf("unexpected gpt-5 request %+v", request)
+	}
+
+	request = ChatCompletionRequest{Model: "anthropic/claude-3.5-sonnet", MaxCompletionTokens: 100}
+	translateTokenLimits(&request)
+	if request.MaxTokens != 100 || request.MaxCompletionTokens != 0 {
+		t.Errorf("unexpected claude request %+v", request)
+	}
+}
+
+func TestCapabilityWarnings(t *testing.T) {
+	model := Model{
+		ID:                  "meta-llama/llama-3-8b",
+		Architecture:        ModelArchitecture{InputModalities: []string{"text"}, OutputModalities: []string{"text"}},
+		SupportedParameters: []string{"tools", "response_format"},
+	}
+	if !model.SupportsTools() || model.SupportsVision() || model.SupportsJSONSchema() {
+		t.Errorf("unexpected capabilities of %+v", model)
+	}
+
+	request := &ChatCompletionRequest{
+		Tools: []Tool{{Type: ToolTypeFunction}},
+		Messages: []ChatCompletionMessage{{
+			Role:         ChatMessageRoleUser,
+			MultiContent: []ChatMessagePart{TextPart("What is this?"), ImagePart("https://example.com/cat.png", "")},
+		}},
+		ResponseFormat: &ResponseFormat{Type: ResponseFormatTypeJSONSchema},
+	}
+	warnings := CapabilityWarnings(request, model)
+	if len(warnings) != 2 || warnings[0] != "meta-llama/llama-3-8b doesn't support JSON schema response formats" ||
+		warnings[1] != "meta-llama/llama-3-8b doesn't accept images" {
+		t.Errorf("unexpected warnings %q", warnings)
+	}
+}
+
+func TestChatCompletionRequest_Transforms(t *testing.T) {
+	b, err := json.Marshal(ChatCompletionRequest{Model: "m", Transforms: []Transform{TransformMiddleOut}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"model":"m","messages":null,"transforms":["middle-out"]}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	var resp ChatCompletionResponse
+	if err = json.Unmarshal([]byte(`{"model":"m","choices":[],"transforms":["middle-out"]}`), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.TransformApplied(TransformMiddleOut) {
+		t.Errorf("expected middle-out to be applied, got %v", resp.AppliedTransforms())
+	}
+	resp = ChatCompletionResponse{}
+	if resp.TransformApplied(TransformMiddleOut) {
+		t.Error("expected no transform")
+	}
+}
+
+func TestAudio(t *testing.T) {
+	request := ChatCompletionRequest{
+		Model: "openai/gpt-4o-audio-preview",
+		Messages: []ChatCompletionMessage{{
+			Role:         ChatMessageRoleUser,
+			MultiContent: []ChatMessagePart{InputAudioPart([]byte("wav"), "wav")},
+		}},
+		Modalities: []Modality{ModalityText, ModalityAudio},
+		Audio:      &AudioOutputConfig{Voice: "alloy", Format: "mp3"},
+	}
+	b, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"model":"openai/gpt-4o-audio-preview",` +
+		`"messages":[{"role":"user","content":[{"type":"input_audio","input_audio":{"data":"d2F2","format":"wav"}}]}],` +
+		`"modalities":["text","audio"],"audio":{"voice":"alloy","format":"mp3"}}`
+	if string(b) != want {
+		t.Errorf("got  %s\nwant %s", b, want)
+	}
+
+	var resp ChatCompletionResponse
+	body := `{"choices":[{"message":{"role":"assistant","audio":{"id":"a1","data":"bXAz","transcript":"hi"}}}]}`
+	if err = json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+	audio := resp.Choices[0].Message.Audio
+	if audio == nil || audio.Transcript != "hi" {
+		t.Fatalf("unexpected audio %+v", audio)
+	}
+	if data, err := audio.Decode(); err != nil || string(data) != "mp3" {
+		t.Errorf("got %q, %v", data, err)
+	}
+}
+
+func TestChatCompletionRequest_SamplingParameters(t *testing.T) {
+	topK, minP, topA, penalty, seed := uint(40), float32(0), float32(0.5), float32(1.1), 0
+	request := ChatCompletionRequest{
+		Model:             "m",
+		TopK:              &topK,
+		MinP:              &minP,
+		TopA:              &topA,
+		RepetitionPenalty: &penalty,
+		Seed:              &seed,
+		LogitBias:         map[string]int{"50256": -100},
+	}
+	b, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"model":"m","messages":null,"top_k":40,"min_p":0,"top_a":0.5,"repetition_penalty":1.1,"seed":0,` +
+		`"logit_bias":{"50256":-100}}`
+	if string(b) != want {
+		t.Errorf("got  %s\nwant %s", b, want)
+	}
+
+	if b, err = json.Marshal(ChatCompletionRequest{Model: "m"}); err != nil {
+		t.Fatal(err)
+	}
+	if want = `{"model":"m","messages":null}`; string(b) != want {
+		t.Errorf("expected the unset parameters to be omitted, got %s", b)
+	}
+}
+
+func TestLogprobs(t *testing.T) {
+	request := ChatCompletionRequest{
+		Model:       "m",
+		Messages:    []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "hi"}},
+		TopLogprobs: 2,
+	}
+	if err := request.Validate(); !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), "top_logprobs") {
+		t.Errorf("expected top_logprobs to require logprobs, got %v", err)
+	}
+	request.Logprobs = true
+	if err := request.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var resp ChatCompletionResponse
+	body := `{"choices":[{"message":{"content":"Hi"},"logprobs":{"content":[{"token":"Hi","logprob":-0.1,"bytes":[72,105],` +
+		`"top_logprobs":[{"token":"Hi","logprob":-0.1},{"token":"Hello","logprob":-2.4}]}]}}]}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+	logprobs := resp.Choices[0].Logprobs
+	if logprobs == nil || len(logprobs.Content) != 1 || len(logprobs.Content[0].Bytes) != 2 {
+		t.Fatalf("unexpected logprobs %+v", logprobs)
+	}
+	if top := logprobs.Content[0].TopLogProbs; len(top) != 2 || top[1].Token != "Hello" || top[1].LogProb != -2.4 {
+		t.Errorf("unexpected top logprobs %+v", top)
+	}
+}
+
+func TestPrediction(t *testing.T) {
+	b, err := json.Marshal(ChatCompletionRequest{Model: "m", Prediction: ContentPrediction("package main")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"model":"m","messages":null,"prediction":{"type":"content","content":"package main"}}`; string(b) != want {
+		t.Errorf("got  %s\nwant %s", b, want)
+	}
+
+	var resp ChatCompletionResponse
+	body := `{"choices":[],"usage":{"completion_tokens":10,"completion_tokens_details":{"accepted_prediction_tokens":7,"rejected_prediction_tokens":2}}}`
+	if err = json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+	details := resp.Usage.CompletionTokensDetails
+	if details == nil || details.AcceptedPredictionTokens != 7 || details.RejectedPredictionTokens != 2 {
+		t.Errorf("unexpected completion tokens details %+v", details)
+	}
+}
@@ -0,0 +1,83 @@
+package openrouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+var jsonNull = []byte("null")
+
+// StringOrSlice holds a value the API accepts either as a single string or as an
+// array of strings, e.g. the stop sequences. A single element is marshaled as a
+// plain string, null decodes to nil.
+type StringOrSlice []string
+
+func (s StringOrSlice) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 {
+		return json.Marshal(s[0])
+	}
+	return json.Marshal([]string(s))
+}
+
+func (s *StringOrSlice) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), jsonNull) {
+		*s = nil
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = StringOrSlice{single}
+		return nil
+	}
+	var multi []string
+	if err := json.Unmarshal(data, &multi); err != nil {
+		return err
+	}
+	*s = multi
+	return nil
+}
+
+// TextContent is a message content some providers return as a string and
+// others as an array of content parts. It decodes to the text of the parts,
+// null to "".
+type TextContent string
+
+func (t *TextContent) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), jsonNull) {
+		*t = ""
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*t = TextContent(text)
+		return nil
+	}
+	var parts []ChatMessagePart
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return err
+	}
+	var builder strings.Builder
+	for _, part := range parts {
+		if part.Type == ChatMessagePartTypeText {
+			builder.WriteString(part.Text)
+		}
+	}
+	*t = TextContent(builder.String())
+	return nil
+}
+
+// UnmarshalJSON accepts the content of the message as a string or as an array
+// of content parts, see TextContent.
+func (i *Index) UnmarshalJSON(data []byte) error {
+	type index Index
+	decoded := struct {
+		*index
+		Content TextContent `json:"content"`
+	}{index: (*index)(i)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	i.Content = string(decoded.Content)
+	return nil
+}
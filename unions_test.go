@@ -0,0 +1,62 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStringOrSlice(t *testing.T) {
+	tests := []struct {
+		value StringOrSlice
+		json  string
+	}{
+		{StringOrSlice{"\n"}, `"\n"`},
+		{StringOrSlice{"a", "b"}, `["a","b"]`},
+		{StringOrSlice{}, `[]`},
+	}
+	for _, test := range tests {
+		b, err := json.Marshal(test.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.json {
+			t.Errorf("Marshal(%q) = %s, want %s", test.value, b, test.json)
+		}
+		var decoded StringOrSlice
+		if err = json.Unmarshal(b, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded, test.value) {
+			t.Errorf("Unmarshal(%s) = %q, want %q", b, decoded, test.value)
+		}
+	}
+
+	request := ChatCompletionRequest{Stop: StringOrSlice{"x"}}
+	if err := json.Unmarshal([]byte(`{"stop":null}`), &request); err != nil || request.Stop != nil {
+		t.Errorf("null stop decoded to %q, %v", request.Stop, err)
+	}
+	if err := json.Unmarshal([]byte(`{"stop":1}`), &request); err == nil {
+		t.Error("expected an error for a number")
+	}
+	b, _ := json.Marshal(ChatCompletionRequest{Model: Gpt4})
+	if string(b) != `{"model":"gpt-4","messages":null}` {
+		t.Errorf("empty stop not omitted: %s", b)
+	}
+}
+
+func TestIndex_UnmarshalContentParts(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"role":"assistant","content":"hi"}`:                                                    "hi",
+		`{"role":"assistant","content":null}`:                                                    "",
+		`{"role":"assistant","content":[{"type":"text","text":"a"},{"type":"text","text":"b"}]}`: "ab",
+	} {
+		var index Index
+		if err := json.Unmarshal([]byte(body), &index); err != nil {
+			t.Fatal(err)
+		}
+		if index.Content != want || index.Role != ChatMessageRoleAssistant {
+			t.Errorf("Unmarshal(%s) = %+v, want content %q", body, index, want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package openrouter
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	utils "github.com/dedlockdave/go-openrouter/internal"
+)
+
+// FileUpload is a file sent as multipart/form-data. Field defaults to "file",
+// ContentType is detected when empty. Fields are sent along with the file.
+type FileUpload struct {
+	Field       string
+	FileName    string
+	ContentType string
+	Reader      io.Reader
+	Fields      map[string]string
+}
+
+// UploadFile — API call to upload a file to path (e.g. "/files"), with the auth
+// headers, retries and error handling of the typed methods. The response is
+// decoded into out, as with Do.
+func (c *Client) UploadFile(ctx context.Context, path string, upload FileUpload, out any) error {
+	field := upload.Field
+	if field == "" {
+		field = "file"
+	}
+	req, err := c.requestBuilder.BuildMultipart(ctx, http.MethodPost, c.fullURL(path), utils.MultipartForm{
+		Fields: upload.Fields,
+		Files: []utils.FormFile{{
+			FieldName:   field,
+			FileName:    upload.FileName,
+			ContentType: upload.ContentType,
+			Reader:      upload.Reader,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	return c.sendRequest(req, out)
+}
@@ -0,0 +1,104 @@
+package openrouter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// UsageRecord is the usage of a call recorded by a UsageAggregator.
+type UsageRecord struct {
+	Model string
+	Time  time.Time
+	Usage Usage
+}
+
+// UsageAggregator sums the usage of a session of calls, e.g. a Conversation,
+// and keeps the breakdown per call. The costs require usage accounting, see
+// UsageConfig. It is safe for concurrent use.
+type UsageAggregator struct {
+	mu      sync.Mutex
+	total   Usage
+	records []UsageRecord
+}
+
+// Record adds the usage of a call to model, a nil usage is ignored.
+func (a *UsageAggregator) Record(model string, usage *Usage) {
+	if usage == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.total.Add(usage)
+	record := UsageRecord{Model: model, Time: time.Now()}
+	record.Usage.Add(usage)
+	a.records = append(a.records, record)
+}
+
+// Total returns the usage summed over the recorded calls.
+func (a *UsageAggregator) Total() Usage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	total := Usage{}
+	total.Add(&a.total)
+	return total
+}
+
+// Records returns the usage of every recorded call, in order.
+func (a *UsageAggregator) Records() []UsageRecord {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]UsageRecord(nil), a.records...)
+}
+
+// Reset forgets the recorded calls.
+func (a *UsageAggregator) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.total = Usage{}
+	a.records = nil
+}
+
+// Client returns a ChatClient recording the usage of the chat completions of
+// client, streams included when their usage is reported.
+func (a *UsageAggregator) Client(client ChatClient) ChatClient {
+	return &usageRecordingClient{ChatClient: client, aggregator: a}
+}
+
+type usageRecordingClient struct {
+	ChatClient
+	aggregator *UsageAggregator
+}
+
+func (c *usageRecordingClient) CreateChatCompletion(
+	ctx context.Context,
+	request *ChatCompletionRequest,
+) (*ChatCompletionResponse, error) {
+	response, err := c.ChatClient.CreateChatCompletion(ctx, request)
+	if err == nil {
+		c.aggregator.Record(response.Model, response.Usage)
+	}
+	return response, err
+}
+
+func (c *usageRecordingClient) CreateChatCompletionStream(
+	ctx context.Context,
+	request *ChatCompletionRequest,
+) (*ChatCompletionStream, error) {
+	stream, err := c.ChatClient.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	onUsage := stream.onUsage
+	stream.onUsage = func(model string, usage *Usage) {
+		if onUsage != nil {
+			onUsage(model, usage)
+		}
+		// the model serving the request, as for the non-streamed completions
+		if model == "" {
+			model = request.Model
+		}
+		c.aggregator.Record(model, usage)
+	}
+	return stream, nil
+}
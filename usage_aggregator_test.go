@@ -0,0 +1,48 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestUsageAggregator_Client(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == "text/event-stream" {
+			_, _ = io.WriteString(w, "data: {\"model\":\"openai/gpt-4o-mini\",\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n"+
+				"data: {\"model\":\"openai/gpt-4o-mini\",\"choices\":[],\"usage\":{\"total_tokens\":3}}\n\n"+
+				"data: [DONE]\n\n")
+			return
+		}
+		_, _ = io.WriteString(w, `{"model":"openai/gpt-4o","choices":[{"message":{"content":"ok"}}],"usage":{"total_tokens":2}}`)
+	})
+	aggregator := &UsageAggregator{}
+	recording := aggregator.Client(client)
+
+	request := &ChatCompletionRequest{Model: "openrouter/auto"}
+	if _, err := recording.CreateChatCompletion(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := recording.CreateChatCompletionStream(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for {
+		if _, err = stream.Recv(); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records := aggregator.Records()
+	if len(records) != 2 || records[0].Model != "openai/gpt-4o" || records[1].Model != "openai/gpt-4o-mini" {
+		t.Errorf("expected the usage under the models serving the requests, got %+v", records)
+	}
+	if total := aggregator.Total(); total.TotalTokens != 5 {
+		t.Errorf("unexpected total %+v", total)
+	}
+}
@@ -0,0 +1,44 @@
+package openrouter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// UserIDHasher transforms an end-user ID before it is sent to the API, so that
+// raw IDs aren't shared with the providers. It applies to the IDs set with
+// WithUser and to the user field set on the requests alike.
+type UserIDHasher func(userID string) string
+
+// HMACUserID returns a UserIDHasher sending the hex HMAC-SHA256 of the IDs keyed
+// with secret: IDs stay stable across requests without being reversible.
+func HMACUserID(secret []byte) UserIDHasher {
+	return func(userID string) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(userID))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+}
+
+type userCtxKey struct{}
+
+// WithUser sets the end-user ID of the requests made with ctx. It fills the user
+// field of the chat completion requests leaving it empty. Either way, the ID
+// goes through the UserIDHasher of the config.
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userCtxKey{}, userID)
+}
+
+// userID returns the end-user ID to send: userID, the user field of a request,
+// or else the ID set with WithUser, hashed by the UserIDHasher.
+func (c *Client) userID(ctx context.Context, userID string) string {
+	if userID == "" {
+		userID, _ = ctx.Value(userCtxKey{}).(string)
+	}
+	if userID == "" || c.config.UserIDHasher == nil {
+		return userID
+	}
+	return c.config.UserIDHasher(userID)
+}
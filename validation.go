@@ -0,0 +1,137 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidRequest = errors.New("invalid request")
+)
+
+// FieldError is a validation failure of a request field.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError lists the validation failures of a request. It matches
+// ErrInvalidRequest with errors.Is.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		msgs = append(msgs, fieldErr.Field+": "+fieldErr.Message)
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidRequest, strings.Join(msgs, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidRequest
+}
+
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e *ValidationError) err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Validate checks the request for mistakes that don't depend on the model. It
+// returns a *ValidationError.
+func (r *ChatCompletionRequest) Validate() error {
+	validation := &ValidationError{}
+	r.validate(validation)
+	return validation.err()
+}
+
+func (r *ChatCompletionRequest) validate(validation *ValidationError) {
+	if r.Model == "" {
+		validation.add("model", "is required")
+	}
+	if len(r.Messages) == 0 {
+		validation.add("messages", "must not be empty")
+	}
+	for i, message := range r.Messages {
+		if message.Role == "" {
+			validation.add(fmt.Sprintf("messages[%d].role", i), "is required")
+		}
+	}
+	if r.Temperature != nil && (*r.Temperature < 0 || *r.Temperature > 2) {
+		validation.add("temperature", "must be between 0 and 2, got %v", *r.Temperature)
+	}
+	if r.TopP != nil && (*r.TopP <= 0 || *r.TopP > 1) {
+		validation.add("top_p", "must be in (0, 1], got %v", *r.TopP)
+	}
+	if r.MaxTokens < 0 {
+		validation.add("max_tokens", "must not be negative")
+	}
+	if r.MaxCompletionTokens < 0 {
+		validation.add("max_completion_tokens", "must not be negative")
+	}
+	if r.TopLogprobs > 0 && !r.Logprobs {
+		validation.add("top_logprobs", "requires logprobs")
+	}
+	if r.ResponseFormat != nil && r.ResponseFormat.Type == ResponseFormatTypeJSONSchema &&
+		r.ResponseFormat.JSONSchema == nil {
+		validation.add("response_format.json_schema", "is required with type json_schema")
+	}
+	choice, ok := r.ToolChoice.(ToolChoice)
+	if ptr, isPtr := r.ToolChoice.(*ToolChoice); isPtr && ptr != nil {
+		choice, ok = *ptr, true
+	}
+	if ok {
+		defined := slices.ContainsFunc(r.Tools, func(tool Tool) bool {
+			return tool.Function != nil && tool.Function.Name == choice.Function.Name
+		})
+		if !defined {
+			validation.add("tool_choice", "references undefined tool %q", choice.Function.Name)
+		}
+	}
+}
+
+// ValidateRequest checks the request like Validate and against the catalog
+// entry of its model: max_tokens must fit in the context and the parameters
+// used must be supported. The model checks are skipped when the catalog can't
+// be fetched.
+func (c *Client) ValidateRequest(ctx context.Context, request *ChatCompletionRequest) error {
+	validation := &ValidationError{}
+	request.validate(validation)
+
+	if model, err := c.GetModel(ctx, request.Model); err == nil {
+		validateForModel(request, model, validation)
+	}
+	return validation.err()
+}
+
+func validateForModel(request *ChatCompletionRequest, model Model, validation *ValidationError) {
+	if model.ContextLength > 0 && request.maxOutputTokens() > model.ContextLength {
+		validation.add("max_tokens", "%d exceeds the %d tokens context of %s",
+			request.maxOutputTokens(), model.ContextLength, model.ID)
+	}
+	if len(model.SupportedParameters) == 0 {
+		return
+	}
+	for _, param := range request.usedParameters() {
+		if !slices.Contains(model.SupportedParameters, param) {
+			validation.add(param, "is not supported by %s", model.ID)
+		}
+	}
+}
+
+func (c *Client) validateChatRequest(ctx context.Context, request *ChatCompletionRequest) error {
+	if !c.config.ValidateRequests {
+		return nil
+	}
+	return c.ValidateRequest(ctx, request)
+}
@@ -0,0 +1,237 @@
+// Package vcr provides an http.RoundTripper that records OpenRouter API
+// interactions to a fixture file and replays them deterministically, so tests
+// can exercise realistic payloads, including SSE streams, without the network.
+package vcr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"unicode/utf8"
+)
+
+type Mode int
+
+const (
+	// ModeReplay serves responses from the fixture file and never hits the network.
+	ModeReplay Mode = iota
+	// ModeRecord forwards requests to the Next transport and records them.
+	ModeRecord
+)
+
+var (
+	ErrInteractionNotFound = errors.New("vcr: no recorded interaction matches the request")
+)
+
+// SensitiveHeaders are stripped from recorded requests and responses.
+var SensitiveHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// Interaction is a recorded request/response pair. Streamed responses are
+// stored as their complete SSE transcript.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+	// BodyEncoding is "base64" for bodies which are not valid UTF-8, e.g.
+	// compressed ones, and empty for bodies stored as is.
+	BodyEncoding string `json:"body_encoding,omitempty"`
+}
+
+func newRecordedResponse(resp *http.Response, body []byte) RecordedResponse {
+	recorded := RecordedResponse{StatusCode: resp.StatusCode, Header: sanitize(resp.Header), Body: string(body)}
+	if !utf8.Valid(body) {
+		recorded.Body, recorded.BodyEncoding = base64.StdEncoding.EncodeToString(body), "base64"
+	}
+	return recorded
+}
+
+func (r RecordedResponse) body() ([]byte, error) {
+	if r.BodyEncoding == "base64" {
+		return base64.StdEncoding.DecodeString(r.Body)
+	}
+	return []byte(r.Body), nil
+}
+
+// Cassette is the content of a fixture file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Transport records or replays HTTP interactions. In ModeRecord call Save once
+// the test is done to write the fixture file.
+type Transport struct {
+	Mode Mode
+	Path string
+	Next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New creates a Transport for the fixture file at path. In ModeReplay the file
+// is loaded immediately.
+func New(path string, mode Mode) (*Transport, error) {
+	t := &Transport{Mode: mode, Path: path}
+	if mode != ModeReplay {
+		return t, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("vcr: failed to read fixture: %w", err)
+	}
+	if err = json.Unmarshal(data, &t.cassette); err != nil {
+		return nil, fmt.Errorf("vcr: failed to decode fixture: %w", err)
+	}
+	t.used = make([]bool, len(t.cassette.Interactions))
+	return t, nil
+}
+
+// RoundTrip does not modify req: the request forwarded in ModeRecord is a clone
+// of req when its body cannot be read again through GetBody.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	forward, body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Mode == ModeReplay {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return t.replay(req, body)
+	}
+	return t.record(forward, body)
+}
+
+// Save writes the recorded interactions to the fixture file.
+func (t *Transport) Save() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	data, err := json.MarshalIndent(t.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(t.Path, data, 0o600)
+}
+
+func (t *Transport) replay(req *http.Request, body string) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, interaction := range t.cassette.Interactions {
+		if t.used[i] || !matches(interaction.Request, req, body) {
+			continue
+		}
+		body, err := interaction.Response.body()
+		if err != nil {
+			return nil, fmt.Errorf("vcr: failed to decode recorded body: %w", err)
+		}
+		t.used[i] = true
+		return &http.Response{
+			StatusCode: interaction.Response.StatusCode,
+			Status:     http.StatusText(interaction.Response.StatusCode),
+			Header:     interaction.Response.Header.Clone(),
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Request:    req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, req.URL)
+}
+
+func (t *Transport) record(req *http.Request, body string) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewBuffer(respBody))
+
+	t.mu.Lock()
+	t.cassette.Interactions = append(t.cassette.Interactions, Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: sanitize(req.Header),
+			Body:   body,
+		},
+		Response: newRecordedResponse(resp, respBody),
+	})
+	t.used = append(t.used, false)
+	t.mu.Unlock()
+
+	return resp, nil
+}
+
+func matches(recorded RecordedRequest, req *http.Request, body string) bool {
+	return recorded.Method == req.Method && recorded.URL == req.URL.String() && recorded.Body == body
+}
+
+// readRequestBody returns the body of req and the request to forward: req itself
+// when the body is read through GetBody, otherwise a clone of req carrying the
+// consumed body.
+func readRequestBody(req *http.Request) (*http.Request, string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, "", nil
+	}
+
+	body, forward := req.Body, req
+	if req.GetBody != nil {
+		var err error
+		if body, err = req.GetBody(); err != nil {
+			return nil, "", fmt.Errorf("vcr: failed to read request body: %w", err)
+		}
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return nil, "", fmt.Errorf("vcr: failed to read request body: %w", err)
+	}
+	if req.GetBody == nil {
+		forward = req.Clone(req.Context())
+		forward.Body = io.NopCloser(bytes.NewReader(data))
+	}
+	return forward, string(data), nil
+}
+
+func sanitize(header http.Header) http.Header {
+	clean := header.Clone()
+	for _, name := range SensitiveHeaders {
+		clean.Del(name)
+	}
+	return clean
+}
@@ -0,0 +1,122 @@
+package vcr
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTransport_RecordReplay(t *testing.T) {
+	const sse = "data: {\"id\":\"1\"}\n\ndata: [DONE]\n\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, sse)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	recorder, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	send(t, &http.Client{Transport: recorder}, server.URL)
+	if err = recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := New(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := replayer.cassette.Interactions[0].Request.Header.Get("Authorization"); got != "" {
+		t.Errorf("authorization header was recorded: %q", got)
+	}
+	server.Close()
+	if got := send(t, &http.Client{Transport: replayer}, server.URL); got != sse {
+		t.Errorf("got %q, want %q", got, sse)
+	}
+}
+
+func TestTransport_GzipResponse(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, _ = io.WriteString(gz, `{"id":"1"}`)
+	_ = gz.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	recorder, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The explicit Accept-Encoding keeps the transport from decompressing.
+	req, err := http.NewRequest(http.MethodPost, server.URL, io.NopCloser(strings.NewReader(`{"model":"m"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	body := req.Body
+	resp, err := recorder.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if req.Body != body {
+		t.Error("the request body was replaced")
+	}
+	if err = recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := New(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := replayer.cassette.Interactions[0].Request.Body; got != `{"model":"m"}` {
+		t.Errorf("unexpected recorded request body %q", got)
+	}
+	req, err = http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"model":"m"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = replayer.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	reader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := io.ReadAll(reader); err != nil || string(got) != `{"id":"1"}` {
+		t.Errorf("got %q, %v", got, err)
+	}
+}
+
+func send(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url+"/chat/completions", strings.NewReader(`{"stream":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
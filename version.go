@@ -0,0 +1,15 @@
+package openrouter
+
+// Version is the version of the library, reported in the User-Agent header.
+const Version = "0.1.0"
+
+const userAgentProduct = "go-openrouter/" + Version
+
+// userAgent returns the User-Agent of the requests: the application user agent
+// of the config, if any, followed by the library product.
+func (c *Client) userAgent() string {
+	if c.config.UserAgent == "" {
+		return userAgentProduct
+	}
+	return c.config.UserAgent + " " + userAgentProduct
+}
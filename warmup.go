@@ -0,0 +1,55 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Warmup pre-establishes connections to the API, so the first requests don't
+// pay for the DNS, TCP and TLS handshakes. It sends as many concurrent HEAD
+// requests to the models endpoint as the transport keeps idle connections per
+// host: MaxIdleConnsPerHost bounded by MaxConnsPerHost for an *http.Transport,
+// http.DefaultMaxIdleConnsPerHost (2) for any other transport, including the
+// default one. The number of connections opened is up to the transport: over
+// HTTP/2, the requests are usually multiplexed on a single connection.
+func (c *Client) Warmup(ctx context.Context) error {
+	connections := http.DefaultMaxIdleConnsPerHost
+	if transport, ok := c.config.HTTPClient.Transport.(*http.Transport); ok {
+		if transport.MaxIdleConnsPerHost > 0 {
+			connections = transport.MaxIdleConnsPerHost
+		}
+		if transport.MaxConnsPerHost > 0 {
+			connections = min(connections, transport.MaxConnsPerHost)
+		}
+	}
+
+	errs := make([]error, connections)
+	var wg sync.WaitGroup
+	for i := range connections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = c.warmupConnection(ctx)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (c *Client) warmupConnection(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.fullURL("/models"), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", c.userAgent())
+	res, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	// the connection returns to the idle pool once the body is drained and closed
+	_, _ = io.Copy(io.Discard, res.Body)
+	return res.Body.Close()
+}